@@ -3,9 +3,12 @@ module github.com/Fuabioo/zipfs
 go 1.25.7
 
 require (
+	github.com/fsnotify/fsnotify v1.10.1
 	github.com/google/uuid v1.6.0
+	github.com/klauspost/compress v1.18.0
 	github.com/mark3labs/mcp-go v0.43.2
 	github.com/spf13/cobra v1.8.1
+	github.com/ulikunitz/xz v0.5.12
 	golang.org/x/term v0.28.0
 )
 
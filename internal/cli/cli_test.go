@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Fuabioo/zipfs/internal/core"
 	"github.com/Fuabioo/zipfs/internal/errors"
@@ -378,6 +379,9 @@ func TestSessionsCommand(t *testing.T) {
 	if !strings.Contains(stdout, "session2") {
 		t.Errorf("output missing session2: %s", stdout)
 	}
+	if !strings.Contains(stdout, "Disk usage:") {
+		t.Errorf("output missing disk usage summary: %s", stdout)
+	}
 }
 
 func TestCloseCommand(t *testing.T) {
@@ -631,6 +635,543 @@ func TestReadWriteCommands(t *testing.T) {
 	}
 }
 
+func TestEditCommand(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(editCmd)
+
+	_, _, err = executeCommand(t, cmd, "edit", session.Name+":test.txt", "--search", "world", "--replace", "there")
+	if err != nil {
+		t.Fatalf("edit command failed: %v", err)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	data, err := core.ReadFile(contentsDir, "test.txt")
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(data) != "hello there\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+}
+
+func TestReadCommand_LineRange(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if err := core.WriteFile(contentsDir, "lines.txt", []byte("one\ntwo\nthree\n"), false, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(readCmd)
+	t.Cleanup(func() {
+		readFlagStartLine, readFlagEndLine, readFlagLineNumbers = 0, 0, false
+	})
+
+	stdout, _, err := executeCommand(t, cmd, "read", "test:lines.txt", "--start-line", "2", "--end-line", "3", "-n")
+	if err != nil {
+		t.Fatalf("read command failed: %v", err)
+	}
+
+	if stdout != "     2\ttwo\n     3\tthree\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+}
+
+func TestGrepCommand_FilesAndContext(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if err := core.WriteFile(contentsDir, "notes.txt", []byte("intro\nhello again\n"), false, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(grepCmd)
+	t.Cleanup(func() {
+		grepFlagFilesOnly, grepFlagBefore = false, 0
+	})
+
+	stdout, _, err := executeCommand(t, cmd, "grep", "hello", "test", "-l")
+	if err != nil {
+		t.Fatalf("grep command failed: %v", err)
+	}
+	if stdout != "notes.txt\ntest.txt\n" {
+		t.Errorf("unexpected files output %q", stdout)
+	}
+
+	grepFlagFilesOnly = false
+	stdout, _, err = executeCommand(t, cmd, "grep", "again", "test", "-B", "1")
+	if err != nil {
+		t.Fatalf("grep command failed: %v", err)
+	}
+	if stdout != "notes.txt-1-intro\nnotes.txt:2:hello again\n" {
+		t.Errorf("unexpected context output %q", stdout)
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if err := core.WriteFile(contentsDir, "docs/big.txt", []byte(strings.Repeat("x", 2048)), true, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(findCmd)
+	t.Cleanup(func() {
+		findFlagMinSize, findFlagStatus = "", nil
+	})
+
+	stdout, _, err := executeCommand(t, cmd, "find", "test", "--min-size", "1K")
+	if err != nil {
+		t.Fatalf("find command failed: %v", err)
+	}
+	if stdout != "docs/big.txt\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+
+	findFlagMinSize = ""
+	stdout, _, err = executeCommand(t, cmd, "find", "test", "--status", "added")
+	if err != nil {
+		t.Fatalf("find command failed: %v", err)
+	}
+	if stdout != "docs/\ndocs/big.txt\n" {
+		t.Errorf("unexpected status output %q", stdout)
+	}
+}
+
+func TestDeleteCommand_Glob(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	for _, name := range []string{"a.tmp", "sub/b.tmp"} {
+		if err := core.WriteFile(contentsDir, name, []byte("scratch"), true, nil); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(deleteCmd)
+	t.Cleanup(func() {
+		deleteFlagGlob, deleteFlagDryRun, deleteFlagRecursive = "", false, false
+	})
+
+	stdout, _, err := executeCommand(t, cmd, "delete", "test", "--glob", "*.tmp", "--dry-run")
+	if err != nil {
+		t.Fatalf("delete --dry-run failed: %v", err)
+	}
+	if stdout != "a.tmp\nsub/b.tmp\n" {
+		t.Errorf("unexpected dry run output %q", stdout)
+	}
+	if _, err := core.ReadFile(contentsDir, "a.tmp"); err != nil {
+		t.Errorf("dry run deleted a.tmp: %v", err)
+	}
+
+	deleteFlagDryRun = false
+	if _, _, err := executeCommand(t, cmd, "delete", "test", "--glob", "*.tmp"); err != nil {
+		t.Fatalf("delete --glob failed: %v", err)
+	}
+	entries, err := core.ListFiles(contentsDir, ".", true)
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "sub" || entries[1].Name != "test.txt" {
+		t.Errorf("unexpected remaining entries: %+v", entries)
+	}
+
+	if _, _, err := executeCommand(t, cmd, "delete", "test", "--glob", "**", "-r"); err == nil {
+		t.Error("expected a glob matching the workspace root to be refused")
+	}
+}
+
+func TestStatCommand(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if _, err := core.CreateSession(zipPath, "test", cfg); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(statCmd)
+
+	stdout, _, err := executeCommand(t, cmd, "stat", "test:test.txt")
+	if err != nil {
+		t.Fatalf("stat command failed: %v", err)
+	}
+	for _, want := range []string{"Path:", "test.txt", "State:", "unchanged", "Original:", "CRC32:", "Method:"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+
+	if _, _, err := executeCommand(t, cmd, "stat", "test", "nope.txt"); err == nil {
+		t.Error("expected missing path to fail")
+	}
+}
+
+func TestTxCommand(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(txCmd)
+
+	stdout, _, err := executeCommand(t, cmd, "tx", "begin", "test")
+	if err != nil {
+		t.Fatalf("tx begin failed: %v", err)
+	}
+	if !strings.Contains(stdout, "Transaction started") {
+		t.Errorf("unexpected begin output: %s", stdout)
+	}
+
+	if err := core.WriteFile(contentsDir, "test.txt", []byte("staged\n"), false, nil); err != nil {
+		t.Fatalf("failed to stage write: %v", err)
+	}
+
+	stdout, _, err = executeCommand(t, cmd, "tx", "status", "test")
+	if err != nil {
+		t.Fatalf("tx status failed: %v", err)
+	}
+	if !strings.Contains(stdout, "write") || !strings.Contains(stdout, "test.txt") {
+		t.Errorf("expected the staged write in status output: %s", stdout)
+	}
+
+	stdout, _, err = executeCommand(t, cmd, "tx", "commit", "test")
+	if err != nil {
+		t.Fatalf("tx commit failed: %v", err)
+	}
+	if !strings.Contains(stdout, "Committed 1 change(s)") {
+		t.Errorf("unexpected commit output: %s", stdout)
+	}
+	if data, _ := core.ReadFile(contentsDir, "test.txt"); string(data) != "staged\n" {
+		t.Errorf("test.txt = %q after commit", data)
+	}
+
+	if _, _, err := executeCommand(t, cmd, "tx", "abort", "test"); err == nil {
+		t.Error("expected abort without a transaction to fail")
+	}
+}
+
+func TestSyncCommand_WatchRefusesWhileLocked(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(syncCmd)
+	t.Cleanup(func() {
+		syncFlagWatch, syncFlagForce = false, false
+		for _, name := range []string{"watch", "force"} {
+			syncCmd.Flags().Lookup(name).Changed = false
+		}
+	})
+
+	if _, _, err := executeCommand(t, cmd, "sync", "test", "--watch", "--force"); err == nil {
+		t.Error("expected --watch with --force to fail")
+	}
+	syncFlagForce = false
+	syncCmd.Flags().Lookup("force").Changed = false
+
+	// Another sync holds the session lock
+	lockPath, err := core.LockPath(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get lock path: %v", err)
+	}
+	lock, err := core.AcquireExclusive(lockPath, time.Second)
+	if err != nil {
+		t.Fatalf("failed to acquire lock: %v", err)
+	}
+	defer lock.Release()
+
+	_, _, err = executeCommand(t, cmd, "sync", "test", "--watch")
+	if !errors.Is(err, errors.CodeLocked) {
+		t.Errorf("expected LOCKED, got %v", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{"": 0, "512": 512, "10K": 10240, "2mb": 2 << 20, "1G": 1 << 30}
+	for in, want := range tests {
+		got, err := parseSize(in)
+		if err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"K", "-1", "ten"} {
+		if _, err := parseSize(in); err == nil {
+			t.Errorf("expected parseSize(%q) to fail", in)
+		}
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if err := core.WriteFile(contentsDir, "test.txt", []byte("hello there, world\n"), false, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(diffCmd)
+
+	stdout, _, err := executeCommand(t, cmd, "diff", session.Name)
+	if err != nil {
+		t.Fatalf("diff command failed: %v", err)
+	}
+
+	if !strings.Contains(stdout, "-hello world") || !strings.Contains(stdout, "+hello there, world") {
+		t.Errorf("expected unified diff, got:\n%s", stdout)
+	}
+}
+
+func TestDiffArchivesCommand(t *testing.T) {
+	setupTestEnv(t)
+
+	oldZip := createTestZip(t, t.TempDir(), "v1.zip")
+	newZip := createTestZip(t, t.TempDir(), "v2.zip")
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(diffArchivesCmd)
+
+	stdout, _, err := executeCommand(t, cmd, "diff-archives", oldZip, newZip)
+	if err != nil {
+		t.Fatalf("diff-archives command failed: %v", err)
+	}
+
+	if !strings.Contains(stdout, "0 added, 0 deleted, 0 changed, 1 unchanged") {
+		t.Errorf("expected identical archives, got:\n%s", stdout)
+	}
+}
+
+func TestMvCommand(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(mvCmd)
+
+	_, _, err = executeCommand(t, cmd, "mv", session.Name+":test.txt", "docs/moved.txt")
+	if err != nil {
+		t.Fatalf("mv command failed: %v", err)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	data, err := core.ReadFile(contentsDir, "docs/moved.txt")
+	if err != nil {
+		t.Fatalf("expected moved file: %v", err)
+	}
+	if string(data) != "hello world\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+
+	status, err := core.Status(session)
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if len(status.Renamed) != 1 {
+		t.Errorf("expected rename in status, got %+v", status)
+	}
+}
+
+func TestCpCommand(t *testing.T) {
+	setupTestEnv(t)
+
+	tempDir := t.TempDir()
+	zipPath := createTestZip(t, tempDir, "test.zip")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	session, err := core.CreateSession(zipPath, "test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.AddCommand(cpCmd)
+
+	// Session to host
+	hostPath := filepath.Join(tempDir, "out.txt")
+	_, _, err = executeCommand(t, cmd, "cp", session.Name+":test.txt", hostPath)
+	if err != nil {
+		t.Fatalf("cp command failed: %v", err)
+	}
+
+	data, err := os.ReadFile(hostPath)
+	if err != nil || string(data) != "hello world\n" {
+		t.Errorf("expected copied host file, got %q (%v)", data, err)
+	}
+
+	// Host back into the session
+	_, _, err = executeCommand(t, cmd, "cp", hostPath, session.Name+":copies/")
+	if err != nil {
+		t.Fatalf("cp command failed: %v", err)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if _, err := core.ReadFile(contentsDir, "copies/out.txt"); err != nil {
+		t.Errorf("expected file copied into session: %v", err)
+	}
+}
+
 func TestStatusCommand(t *testing.T) {
 	setupTestEnv(t)
 
@@ -654,7 +1195,7 @@ func TestStatusCommand(t *testing.T) {
 		t.Fatalf("failed to get contents dir: %v", err)
 	}
 
-	err = core.WriteFile(contentsDir, "test.txt", []byte("modified content"), false)
+	err = core.WriteFile(contentsDir, "test.txt", []byte("modified content"), false, nil)
 	if err != nil {
 		t.Fatalf("failed to modify file: %v", err)
 	}
@@ -63,7 +63,7 @@ func runClose(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to check status: %w", err)
 		}
 
-		hasChanges = len(status.Modified) > 0 || len(status.Added) > 0 || len(status.Deleted) > 0
+		hasChanges = status.HasChanges()
 
 		if hasChanges {
 			// Prompt for confirmation on TTY, error on non-TTY
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cpFlagRecursive bool
+	cpFlagForce     bool
+)
+
+var cpCmd = &cobra.Command{
+	Use:   "cp <src> <dst>",
+	Short: "Copy files between sessions or between host and session",
+	Long: `Copies files and directories without reading them through the terminal.
+
+Either side uses colon syntax (session:path) for a workspace path; an argument
+without a colon is a host path. At least one side must be a session:
+
+  zipfs cp a:xl/worksheets/sheet1.xml b:xl/worksheets/sheet2.xml
+  zipfs cp ./logo.png deck:ppt/media/
+  zipfs cp -r deck:ppt/media ./media
+
+The source may be a glob (e.g., "a:xl/media/*.png"). Several matches, a trailing
+slash or an existing directory copy into the destination directory. Existing
+files are only replaced with --force.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCp,
+}
+
+func init() {
+	cpCmd.Flags().BoolVarP(&cpFlagRecursive, "recursive", "r", false, "Copy directories recursively")
+	cpCmd.Flags().BoolVarP(&cpFlagForce, "force", "f", false, "Overwrite existing destination files")
+}
+
+func runCp(cmd *cobra.Command, args []string) error {
+	src, err := resolveCopyLocation(args[0])
+	if err != nil {
+		return err
+	}
+
+	dst, err := resolveCopyLocation(args[1])
+	if err != nil {
+		return err
+	}
+
+	if src.IsHost() && dst.IsHost() {
+		return fmt.Errorf("at least one side must use session:path syntax")
+	}
+
+	// Load configuration for the disk budget check
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	result, err := core.CopyFiles(src, dst, core.CopyOptions{
+		Recursive: cpFlagRecursive,
+		Overwrite: cpFlagForce,
+	}, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Output
+	if flagJSON {
+		return outputJSON(result)
+	}
+
+	if !flagQuiet {
+		for _, skipped := range result.Skipped {
+			fmt.Fprintf(os.Stderr, "Skipped: %s (not a regular file)\n", skipped)
+		}
+		fmt.Fprintf(os.Stderr, "Copied %d file(s), %s\n", len(result.Files), formatBytes(result.Bytes))
+	}
+
+	return nil
+}
+
+// resolveCopyLocation turns a cp argument into a host path or a session path.
+func resolveCopyLocation(arg string) (core.CopyLocation, error) {
+	sessionID, p := parseColonSyntax(arg)
+	if sessionID == "" && p == arg {
+		return core.CopyLocation{Path: arg}, nil
+	}
+
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return core.CopyLocation{}, err
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		return core.CopyLocation{}, err
+	}
+
+	return core.CopyLocation{ContentsDir: contentsDir, Path: p}, nil
+}
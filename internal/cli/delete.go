@@ -10,24 +10,45 @@ import (
 
 var (
 	deleteFlagRecursive bool
+	deleteFlagGlob      string
+	deleteFlagDryRun    bool
 )
 
 var deleteCmd = &cobra.Command{
-	Use:   "delete [<session>] <path>",
+	Use:   "delete [<session>] <path> | delete [<session>] --glob <pattern>",
 	Short: "Delete a file or directory from workspace",
 	Long: `Deletes a file or directory from the workspace.
 
 For directories, use --recursive flag.
-The session argument is optional and will auto-resolve if only one session is open.`,
-	Args: cobra.MinimumNArgs(1),
+The session argument is optional and will auto-resolve if only one session is open.
+
+With --glob, every entry whose workspace-relative path matches the pattern is
+deleted in one operation and the deleted paths are printed. "**" spans
+directories, {a,b} matches either and a leading "!" excludes; a pattern without
+"/" matches names at any depth. Matching directories need --recursive.
+Patterns matching the whole workspace are refused. --dry-run lists the paths
+without deleting them.`,
+	Args: cobra.RangeArgs(0, 2),
 	RunE: runDelete,
 }
 
 func init() {
 	deleteCmd.Flags().BoolVarP(&deleteFlagRecursive, "recursive", "r", false, "Delete directories recursively")
+	deleteCmd.Flags().StringVar(&deleteFlagGlob, "glob", "", "Delete every path matching a glob (e.g., **/*.tmp)")
+	deleteCmd.Flags().BoolVar(&deleteFlagDryRun, "dry-run", false, "With --glob, list matching paths without deleting them")
 }
 
 func runDelete(cmd *cobra.Command, args []string) error {
+	if deleteFlagGlob != "" {
+		return runDeleteGlob(args)
+	}
+	if deleteFlagDryRun {
+		return fmt.Errorf("--dry-run requires --glob")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("path required")
+	}
+
 	var sessionID, relativePath string
 
 	if len(args) == 1 {
@@ -74,3 +95,55 @@ func runDelete(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// runDeleteGlob deletes every path matching --glob; the only argument is the session.
+func runDeleteGlob(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("--glob takes no path argument")
+	}
+
+	var sessionID string
+	if len(args) == 1 {
+		sessionID = args[0]
+	}
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		return err
+	}
+
+	deleted, err := core.DeleteGlob(contentsDir, deleteFlagGlob, deleteFlagRecursive, deleteFlagDryRun)
+	if !flagJSON {
+		for _, p := range deleted {
+			fmt.Println(p)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if flagJSON {
+		return outputJSON(map[string]interface{}{
+			"deleted": !deleteFlagDryRun && len(deleted) > 0,
+			"paths":   deleted,
+			"count":   len(deleted),
+			"dry_run": deleteFlagDryRun,
+		})
+	}
+
+	if !flagQuiet {
+		if deleteFlagDryRun {
+			fmt.Fprintf(os.Stderr, "Would delete %d path(s)\n", len(deleted))
+		} else {
+			fmt.Fprintf(os.Stderr, "Deleted %d path(s)\n", len(deleted))
+		}
+	}
+
+	return nil
+}
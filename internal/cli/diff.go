@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFlagStat    bool
+	diffFlagContext int
+)
+
+// diffStatWidth is the widest +/- bar printed by --stat.
+const diffStatWidth = 40
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [<session>] [<path>...]",
+	Short: "Show content changes against the original zip",
+	Long: `Shows unified diffs between the entries of the original zip and the
+workspace files, limited to the given files or directories if any.
+
+Binary files are summarized by size and SHA-256. --stat prints a per-file
+summary instead of hunks, and --json returns the hunks as structured data.`,
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffFlagStat, "stat", false, "Show a per-file summary of changed lines")
+	diffCmd.Flags().IntVarP(&diffFlagContext, "context", "U", core.DefaultEditContext, "Context lines around each change")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	// Resolve session
+	var sessionID string
+	var paths []string
+	if len(args) > 0 {
+		sessionID = args[0]
+		paths = args[1:]
+	}
+
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	diffs, err := core.DiffSession(session, core.SessionDiffOptions{
+		Paths:   paths,
+		Context: diffFlagContext,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Output
+	if flagJSON {
+		if diffs == nil {
+			diffs = []core.FileDiff{}
+		}
+		return outputJSON(map[string]interface{}{
+			"files": diffs,
+		})
+	}
+
+	if diffFlagStat {
+		printDiffStat(diffs)
+		return nil
+	}
+
+	for i := range diffs {
+		fmt.Print(diffs[i].Unified())
+	}
+
+	return nil
+}
+
+// printDiffStat prints a git-style --stat summary.
+func printDiffStat(diffs []core.FileDiff) {
+	nameWidth := 0
+	maxChanges := 0
+	for _, d := range diffs {
+		nameWidth = max(nameWidth, len(diffStatName(d)))
+		maxChanges = max(maxChanges, d.Additions+d.Deletions)
+	}
+
+	insertions, deletions := 0, 0
+	for _, d := range diffs {
+		insertions += d.Additions
+		deletions += d.Deletions
+
+		name := diffStatName(d)
+		if d.Binary {
+			fmt.Printf(" %-*s | Bin %d -> %d bytes\n", nameWidth, name, d.OldSize, d.NewSize)
+			continue
+		}
+
+		// Scale the bar down when the largest change does not fit
+		plus, minus := d.Additions, d.Deletions
+		if maxChanges > diffStatWidth {
+			plus = plus * diffStatWidth / maxChanges
+			minus = minus * diffStatWidth / maxChanges
+		}
+		fmt.Printf(" %-*s | %d %s%s\n", nameWidth, name, d.Additions+d.Deletions,
+			strings.Repeat("+", plus), strings.Repeat("-", minus))
+	}
+
+	fmt.Printf(" %d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)\n", len(diffs), insertions, deletions)
+}
+
+func diffStatName(d core.FileDiff) string {
+	if d.OldPath != "" {
+		return d.OldPath + " => " + d.Path
+	}
+	return d.Path
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffArchivesFlagContent bool
+	diffArchivesFlagContext int
+)
+
+var diffArchivesCmd = &cobra.Command{
+	Use:   "diff-archives <old.zip> <new.zip>",
+	Short: "Compare two zip files without opening sessions",
+	Long: `Compares the central directories of two zip files and lists added,
+deleted and changed entries (by CRC-32 and size). Nothing is extracted.
+
+With --content, changed entries are streamed from both archives and shown as
+unified diffs; binary entries are summarized by size and SHA-256. Both archives
+must pass the same zip bomb checks as zipfs open.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiffArchives,
+}
+
+func init() {
+	diffArchivesCmd.Flags().BoolVar(&diffArchivesFlagContent, "content", false, "Show diffs of changed entries")
+	diffArchivesCmd.Flags().IntVarP(&diffArchivesFlagContext, "context", "U", core.DefaultEditContext, "Context lines around each change")
+}
+
+func runDiffArchives(cmd *cobra.Command, args []string) error {
+	// Load configuration for the security limits
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	result, err := core.DiffArchives(args[0], args[1], core.ArchiveDiffOptions{
+		Content: diffArchivesFlagContent,
+		Context: diffArchivesFlagContext,
+	}, cfg.ToSecurityLimits())
+	if err != nil {
+		return err
+	}
+
+	// Output
+	if flagJSON {
+		return outputJSON(result)
+	}
+
+	added, deleted, modified := 0, 0, 0
+	for i := range result.Files {
+		d := &result.Files[i]
+		switch d.Status {
+		case core.DiffAdded:
+			added++
+			fmt.Printf("A %s (%s)\n", d.Path, formatBytes(uint64(d.NewSize)))
+		case core.DiffDeleted:
+			deleted++
+			fmt.Printf("D %s (%s)\n", d.Path, formatBytes(uint64(d.OldSize)))
+		default:
+			modified++
+			fmt.Printf("M %s (%s -> %s)\n", d.Path, formatBytes(uint64(d.OldSize)), formatBytes(uint64(d.NewSize)))
+		}
+	}
+
+	if diffArchivesFlagContent {
+		for i := range result.Files {
+			if d := &result.Files[i]; d.Status == core.DiffModified {
+				fmt.Println()
+				fmt.Print(d.Unified())
+			}
+		}
+	}
+
+	if !flagQuiet {
+		if len(result.Files) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%d added, %d deleted, %d changed, %d unchanged\n", added, deleted, modified, result.UnchangedCount)
+	}
+
+	return nil
+}
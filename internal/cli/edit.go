@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	editFlagSearch  []string
+	editFlagReplace []string
+	editFlagAll     bool
+	editFlagPatch   string
+	editFlagContext int
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <session>:<path> | edit [<session>] <path>",
+	Short: "Edit a file in workspace with search/replace or a patch",
+	Long: `Edits a file in the workspace without rewriting it in full.
+
+Pass one or more --search/--replace pairs; each search text must occur exactly
+once unless --all is given. Alternatively pass a unified diff with --patch
+(a file, or - for stdin). Either every change applies or nothing is written,
+and the changed hunks are printed as a unified diff.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runEdit,
+}
+
+func init() {
+	editCmd.Flags().StringArrayVar(&editFlagSearch, "search", nil, "Exact text to find (repeatable, paired with --replace)")
+	editCmd.Flags().StringArrayVar(&editFlagReplace, "replace", nil, "Replacement text (repeatable, paired with --search)")
+	editCmd.Flags().BoolVar(&editFlagAll, "all", false, "Replace every occurrence instead of requiring a unique match")
+	editCmd.Flags().StringVar(&editFlagPatch, "patch", "", "Unified diff to apply (file path, or - for stdin)")
+	editCmd.Flags().IntVarP(&editFlagContext, "context", "U", core.DefaultEditContext, "Context lines around printed hunks")
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	var sessionID, relativePath string
+
+	// Parse arguments - support colon syntax
+	if len(args) == 1 {
+		sessionID, relativePath = parseColonSyntax(args[0])
+	} else {
+		sessionID = args[0]
+		relativePath = args[1]
+	}
+
+	if relativePath == "" {
+		return fmt.Errorf("path cannot be empty")
+	}
+
+	opts, err := editOptions()
+	if err != nil {
+		return err
+	}
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Get contents directory
+	dirName := session.DirName()
+	contentsDir, err := core.ContentsDir(dirName)
+	if err != nil {
+		return err
+	}
+
+	// Load configuration for the disk budget check
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	result, err := core.EditFile(contentsDir, relativePath, opts, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Output
+	if flagJSON {
+		return outputJSON(result)
+	}
+
+	fmt.Print(result.Diff())
+
+	if !flagQuiet {
+		fmt.Fprintf(os.Stderr, "Edited %s: %d change(s), %d -> %d bytes\n",
+			relativePath, result.Replacements, result.BytesBefore, result.BytesAfter)
+	}
+
+	return nil
+}
+
+// editOptions builds core.EditOptions from the edit flags.
+func editOptions() (core.EditOptions, error) {
+	opts := core.EditOptions{Context: editFlagContext}
+
+	if editFlagPatch != "" {
+		if len(editFlagSearch) > 0 {
+			return opts, fmt.Errorf("use either --patch or --search/--replace, not both")
+		}
+
+		var data []byte
+		var err error
+		if editFlagPatch == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(editFlagPatch)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("failed to read patch: %w", err)
+		}
+		opts.Patch = string(data)
+		return opts, nil
+	}
+
+	if len(editFlagSearch) == 0 {
+		return opts, fmt.Errorf("no edit provided; use --search/--replace or --patch")
+	}
+	if len(editFlagSearch) != len(editFlagReplace) {
+		return opts, fmt.Errorf("each --search needs a matching --replace (got %d and %d)", len(editFlagSearch), len(editFlagReplace))
+	}
+
+	for i := range editFlagSearch {
+		opts.Blocks = append(opts.Blocks, core.EditBlock{
+			Search:     editFlagSearch[i],
+			Replace:    editFlagReplace[i],
+			ReplaceAll: editFlagAll,
+		})
+	}
+	return opts, nil
+}
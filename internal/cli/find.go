@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	findFlagPath       string
+	findFlagName       string
+	findFlagType       string
+	findFlagMinSize    string
+	findFlagMaxSize    string
+	findFlagNewer      string
+	findFlagOlder      string
+	findFlagStatus     []string
+	findFlagMaxResults int
+)
+
+var findCmd = &cobra.Command{
+	Use:   "find [<session>] [<path>]",
+	Short: "Find files in workspace by path, name, size, time or change state",
+	Long: `Walks the workspace (or a directory in it) and lists entries matching every
+given filter. Paths are printed relative to the workspace root.
+
+--path takes a glob matched against the whole path, where ** spans
+directories (e.g. "xl/**/*.xml"). --name takes a regular expression matched
+against the base name. Sizes accept K, M and G suffixes. --newer and --older
+take an RFC 3339 time or a duration ago (e.g. 2h, 7d). --status compares with
+the original zip: modified, added, renamed or unchanged.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runFind,
+}
+
+func init() {
+	findCmd.Flags().StringVar(&findFlagPath, "path", "", "Path glob; ** matches any number of directories")
+	findCmd.Flags().StringVar(&findFlagName, "name", "", "Regular expression for the base name")
+	findCmd.Flags().StringVar(&findFlagType, "type", "", "Entry type: file, dir or symlink")
+	findCmd.Flags().StringVar(&findFlagMinSize, "min-size", "", "Minimum size (e.g. 10K)")
+	findCmd.Flags().StringVar(&findFlagMaxSize, "max-size", "", "Maximum size (e.g. 5M)")
+	findCmd.Flags().StringVar(&findFlagNewer, "newer", "", "Modified at or after a time or duration ago")
+	findCmd.Flags().StringVar(&findFlagOlder, "older", "", "Modified before a time or duration ago")
+	findCmd.Flags().StringSliceVar(&findFlagStatus, "status", nil, "Change state: modified, added, renamed, unchanged")
+	findCmd.Flags().IntVar(&findFlagMaxResults, "max-results", 1000, "Maximum entries to return (0 for no limit)")
+}
+
+func runFind(cmd *cobra.Command, args []string) error {
+	// Parse arguments: session is optional, path is optional
+	var sessionID, relativePath string
+
+	switch len(args) {
+	case 1:
+		session, err := core.GetSession(args[0])
+		if err == nil && session != nil {
+			sessionID = args[0]
+		} else {
+			relativePath = args[0]
+		}
+	case 2:
+		sessionID = args[0]
+		relativePath = args[1]
+	}
+
+	opts := core.FindOptions{
+		Path:       findFlagPath,
+		Name:       findFlagName,
+		Type:       findFlagType,
+		Status:     findFlagStatus,
+		MaxResults: findFlagMaxResults,
+	}
+
+	var err error
+	if opts.MinSize, err = parseSize(findFlagMinSize); err != nil {
+		return fmt.Errorf("invalid --min-size: %w", err)
+	}
+	if opts.MaxSize, err = parseSize(findFlagMaxSize); err != nil {
+		return fmt.Errorf("invalid --max-size: %w", err)
+	}
+	if opts.ModifiedAfter, err = parseTimeOrAgo(findFlagNewer); err != nil {
+		return fmt.Errorf("invalid --newer: %w", err)
+	}
+	if opts.ModifiedBefore, err = parseTimeOrAgo(findFlagOlder); err != nil {
+		return fmt.Errorf("invalid --older: %w", err)
+	}
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	result, err := core.FindFiles(session, relativePath, opts)
+	if err != nil {
+		return err
+	}
+
+	// Output
+	if flagJSON {
+		return outputJSON(result)
+	}
+
+	for _, entry := range result.Entries {
+		name := entry.Name
+		switch entry.Type {
+		case "dir":
+			name += "/"
+		case "symlink":
+			name += "@"
+		}
+		fmt.Println(name)
+	}
+
+	if result.Truncated && !flagQuiet {
+		fmt.Fprintf(os.Stderr, "Warning: output truncated to %d entries (total: %d)\n", len(result.Entries), result.Total)
+	}
+
+	return nil
+}
+
+// parseSize parses a byte count with an optional K, M or G suffix (powers of 1024).
+// An empty string is 0.
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	upper := strings.TrimSuffix(strings.ToUpper(s), "B")
+	switch {
+	case strings.HasSuffix(upper, "K"):
+		multiplier = 1024
+	case strings.HasSuffix(upper, "M"):
+		multiplier = 1024 * 1024
+	case strings.HasSuffix(upper, "G"):
+		multiplier = 1024 * 1024 * 1024
+	}
+	if multiplier > 1 {
+		upper = upper[:len(upper)-1]
+	}
+
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a size like 512, 10K or 5M, got %q", s)
+	}
+	return n * multiplier, nil
+}
+
+// parseTimeOrAgo parses an RFC 3339 time or a duration before now (see parseDuration).
+// An empty string is the zero time.
+func parseTimeOrAgo(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := parseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a duration like 2h or 7d, got %q", s)
+	}
+	return time.Now().Add(-d), nil
+}
@@ -13,6 +13,14 @@ var (
 	grepFlagIgnoreCase bool
 	grepFlagLineNumber bool
 	grepFlagMaxResults int
+	grepFlagBefore     int
+	grepFlagAfter      int
+	grepFlagContext    int
+	grepFlagFilesOnly  bool
+	grepFlagCount      bool
+	grepFlagColumn     bool
+	grepFlagText       bool
+	grepFlagDocuments  bool
 )
 
 var grepCmd = &cobra.Command{
@@ -21,16 +29,32 @@ var grepCmd = &cobra.Command{
 	Long: `Searches for a pattern in files within the workspace.
 
 The pattern is a regular expression. Session and path are optional.
-Output format matches standard grep: file:line:content`,
+Output format matches standard grep: file:line:content
+
+Lines of any length are searched; long lines are shown as an excerpt around
+the match. Files with a NUL byte near the start are treated as binary and
+skipped unless --text is given.
+
+With --documents, Office files (xlsx, docx, pptx and their OpenDocument
+counterparts) are opened and their cells and paragraphs searched; matches are
+reported by location, e.g. report.xlsx!Sheet1!B7 or notes.docx!paragraph 12.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runGrep,
 }
 
 func init() {
-	grepCmd.Flags().StringVar(&grepFlagGlob, "glob", "", "File glob filter (e.g., *.txt)")
+	grepCmd.Flags().StringVar(&grepFlagGlob, "glob", "", "Path glob filter (e.g., *.txt, xl/**/*.xml, !docProps/**)")
 	grepCmd.Flags().BoolVarP(&grepFlagIgnoreCase, "ignore-case", "i", false, "Case-insensitive search")
 	grepCmd.Flags().BoolVarP(&grepFlagLineNumber, "line-number", "n", true, "Show line numbers (default true)")
-	grepCmd.Flags().IntVar(&grepFlagMaxResults, "max-results", 100, "Maximum matches to return")
+	grepCmd.Flags().IntVar(&grepFlagMaxResults, "max-results", 100, "Maximum matches (or files, with -l/-c) to return")
+	grepCmd.Flags().IntVarP(&grepFlagBefore, "before-context", "B", 0, "Lines of context before each match")
+	grepCmd.Flags().IntVarP(&grepFlagAfter, "after-context", "A", 0, "Lines of context after each match")
+	grepCmd.Flags().IntVarP(&grepFlagContext, "context", "C", 0, "Lines of context before and after each match")
+	grepCmd.Flags().BoolVarP(&grepFlagFilesOnly, "files-with-matches", "l", false, "Only list files that match")
+	grepCmd.Flags().BoolVarP(&grepFlagCount, "count", "c", false, "Count matching lines per file")
+	grepCmd.Flags().BoolVar(&grepFlagColumn, "column", false, "Show the column of the first match")
+	grepCmd.Flags().BoolVarP(&grepFlagText, "text", "a", false, "Search binary files as text")
+	grepCmd.Flags().BoolVar(&grepFlagDocuments, "documents", false, "Search the text of Office documents")
 }
 
 func runGrep(cmd *cobra.Command, args []string) error {
@@ -70,35 +94,101 @@ func runGrep(cmd *cobra.Command, args []string) error {
 		relativePath = "."
 	}
 
-	// Perform grep
-	matches, totalMatches, err := core.GrepFiles(contentsDir, relativePath, pattern, grepFlagGlob, grepFlagIgnoreCase, grepFlagMaxResults)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Perform grep, bounded by the configured timeout and byte limit
+	opts := cfg.GrepOptions()
+	opts.Glob = grepFlagGlob
+	opts.IgnoreCase = grepFlagIgnoreCase
+	opts.MaxResults = grepFlagMaxResults
+	opts.BeforeContext = max(grepFlagBefore, grepFlagContext)
+	opts.AfterContext = max(grepFlagAfter, grepFlagContext)
+	opts.FilesWithMatches = grepFlagFilesOnly
+	opts.Count = grepFlagCount
+	opts.IncludeBinary = grepFlagText
+	opts.Documents = grepFlagDocuments
+
+	result, err := core.GrepFiles(cmd.Context(), contentsDir, relativePath, pattern, opts)
 	if err != nil {
 		return err
 	}
+	matches, totalMatches := result.Matches, result.TotalMatches
+	filesMode := opts.FilesWithMatches || opts.Count
 
 	// Output
 	if flagJSON {
 		output := map[string]interface{}{
 			"matches":       matches,
 			"total_matches": totalMatches,
-			"truncated":     totalMatches > len(matches),
+			"truncated":     (!filesMode && totalMatches > len(matches)) || result.TruncatedReason != "",
+			"bytes_scanned": result.BytesScanned,
+		}
+		if filesMode {
+			output["files"] = result.Files
+		}
+		if result.SkippedBinary > 0 {
+			output["skipped_binary"] = result.SkippedBinary
+		}
+		if result.IndexSkipped > 0 {
+			output["index_skipped"] = result.IndexSkipped
+		}
+		if result.TruncatedReason != "" {
+			output["truncated_reason"] = result.TruncatedReason
 		}
 		return outputJSON(output)
 	}
 
 	// Human-readable output (grep format)
-	for _, match := range matches {
-		if grepFlagLineNumber {
-			fmt.Printf("%s:%d:%s\n", match.File, match.LineNumber, match.LineContent)
-		} else {
-			fmt.Printf("%s:%s\n", match.File, match.LineContent)
+	if filesMode {
+		for _, f := range result.Files {
+			if opts.Count {
+				fmt.Printf("%s:%d\n", f.File, f.Count)
+			} else {
+				fmt.Println(f.File)
+			}
+		}
+	}
+
+	withContext := opts.BeforeContext > 0 || opts.AfterContext > 0
+	for i, match := range matches {
+		if withContext && i > 0 {
+			fmt.Println("--")
+		}
+		for _, line := range match.Before {
+			printGrepLine(match.File, line.LineNumber, 0, line.LineContent, '-')
+		}
+		if match.Location != "" {
+			fmt.Printf("%s:%s\n", match.Location, match.LineContent)
+			continue
+		}
+		printGrepLine(match.File, match.LineNumber, match.Column, match.LineContent, ':')
+		for _, line := range match.After {
+			printGrepLine(match.File, line.LineNumber, 0, line.LineContent, '-')
 		}
 	}
 
 	// Show truncation warning
-	if totalMatches > len(matches) && !flagQuiet {
+	if !filesMode && totalMatches > len(matches) && !flagQuiet {
 		fmt.Fprintf(os.Stderr, "Warning: output truncated to %d matches (total: %d)\n", len(matches), totalMatches)
 	}
+	if result.TruncatedReason != "" && !flagQuiet {
+		fmt.Fprintf(os.Stderr, "Warning: search stopped early (%s); results are partial\n", result.TruncatedReason)
+	}
 
 	return nil
 }
+
+// printGrepLine prints a match (sep ':') or context line (sep '-') in grep format.
+func printGrepLine(file string, lineNumber, column int, content string, sep byte) {
+	prefix := file + string(sep)
+	if grepFlagLineNumber {
+		prefix += fmt.Sprintf("%d%c", lineNumber, sep)
+	}
+	if grepFlagColumn && column > 0 {
+		prefix += fmt.Sprintf("%d%c", column, sep)
+	}
+	fmt.Println(prefix + content)
+}
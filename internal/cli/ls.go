@@ -13,6 +13,7 @@ import (
 var (
 	lsFlagLong      bool
 	lsFlagRecursive bool
+	lsFlagGlob      string
 )
 
 var lsCmd = &cobra.Command{
@@ -21,7 +22,9 @@ var lsCmd = &cobra.Command{
 	Long: `Lists files and directories in the workspace.
 
 The session argument is optional and will auto-resolve if only one session is open.
-The path argument is optional and defaults to the root of the workspace.`,
+The path argument is optional and defaults to the root of the workspace.
+--glob keeps entries whose workspace-relative path matches the pattern; "**"
+spans directories, {a,b} matches either and a leading "!" excludes.`,
 	Args: cobra.MaximumNArgs(2),
 	RunE: runLs,
 }
@@ -29,6 +32,7 @@ The path argument is optional and defaults to the root of the workspace.`,
 func init() {
 	lsCmd.Flags().BoolVarP(&lsFlagLong, "long", "l", false, "Long format with size and timestamp")
 	lsCmd.Flags().BoolVarP(&lsFlagRecursive, "recursive", "r", false, "List subdirectories recursively")
+	lsCmd.Flags().StringVar(&lsFlagGlob, "glob", "", "Only list entries matching a path glob (e.g., xl/**/*.xml)")
 }
 
 func runLs(cmd *cobra.Command, args []string) error {
@@ -74,7 +78,7 @@ func runLs(cmd *cobra.Command, args []string) error {
 	}
 
 	// List files
-	entries, err := core.ListFiles(contentsDir, relativePath, lsFlagRecursive)
+	entries, err := core.ListFilesGlob(contentsDir, relativePath, lsFlagRecursive, lsFlagGlob)
 	if err != nil {
 		return err
 	}
@@ -101,18 +105,26 @@ func runLs(cmd *cobra.Command, args []string) error {
 			modTime := time.Unix(entry.ModifiedAt, 0).Format("2006-01-02 15:04:05")
 			sizeStr := formatBytes(entry.SizeBytes)
 			typeStr := "FILE"
-			if entry.Type == "dir" {
+			name := entry.Name
+			switch entry.Type {
+			case "dir":
 				typeStr = "DIR"
+			case "symlink":
+				typeStr = "LINK"
+				name += " -> " + entry.Target
 			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", typeStr, sizeStr, modTime, entry.Name)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", typeStr, sizeStr, modTime, name)
 		}
 		w.Flush()
 	} else {
 		// Simple list
 		for _, entry := range entries {
 			name := entry.Name
-			if entry.Type == "dir" {
+			switch entry.Type {
+			case "dir":
 				name += "/"
+			case "symlink":
+				name += "@"
 			}
 			fmt.Println(name)
 		}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mvFlagForce bool
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv [<session>:]<src> <dst>",
+	Short: "Move or rename a file or directory in workspace",
+	Long: `Moves or renames a file or directory within the workspace.
+
+The source may use colon syntax (session:path); the destination is a path in
+the same session. Missing parent directories are created. An existing
+destination is only replaced with --force.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMv,
+}
+
+func init() {
+	mvCmd.Flags().BoolVarP(&mvFlagForce, "force", "f", false, "Overwrite an existing destination")
+}
+
+func runMv(cmd *cobra.Command, args []string) error {
+	// Parse arguments - source supports colon syntax
+	sessionID, src := parseColonSyntax(args[0])
+	dst := args[1]
+
+	// Allow the destination to repeat the same session prefix
+	if dstSession, p := parseColonSyntax(dst); dstSession != "" {
+		if sessionID != "" && dstSession != sessionID {
+			return fmt.Errorf("cannot move between sessions")
+		}
+		sessionID = dstSession
+		dst = p
+	}
+
+	if src == "" || dst == "" {
+		return fmt.Errorf("source and destination paths are required")
+	}
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Get contents directory
+	dirName := session.DirName()
+	contentsDir, err := core.ContentsDir(dirName)
+	if err != nil {
+		return err
+	}
+
+	// Move file/directory
+	if err := core.MoveFile(contentsDir, src, dst, mvFlagForce); err != nil {
+		return err
+	}
+
+	// Output
+	if flagJSON {
+		return outputJSON(map[string]interface{}{
+			"moved": true,
+			"from":  src,
+			"to":    dst,
+		})
+	}
+
+	if !flagQuiet {
+		fmt.Fprintf(os.Stderr, "Moved: %s -> %s\n", src, dst)
+	}
+
+	return nil
+}
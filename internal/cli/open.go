@@ -2,6 +2,8 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/Fuabioo/zipfs/internal/core"
 	"github.com/spf13/cobra"
@@ -64,6 +66,9 @@ func runOpen(cmd *cobra.Command, args []string) error {
 			"file_count":           session.FileCount,
 			"extracted_size_bytes": session.ExtractedSizeBytes,
 		}
+		if len(session.SkippedSymlinks) > 0 {
+			output["skipped_symlinks"] = session.SkippedSymlinks
+		}
 		return outputJSON(output)
 	}
 
@@ -76,5 +81,10 @@ func runOpen(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Files: %d\n", session.FileCount)
 	fmt.Printf("Size: %d bytes\n", session.ExtractedSizeBytes)
 
+	if len(session.SkippedSymlinks) > 0 && !flagQuiet {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d symlink entries (allow_symlinks is false): %s\n",
+			len(session.SkippedSymlinks), strings.Join(session.SkippedSymlinks, ", "))
+	}
+
 	return nil
 }
@@ -10,17 +10,35 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	readFlagStartLine   int
+	readFlagEndLine     int
+	readFlagMaxBytes    int
+	readFlagLineNumbers bool
+)
+
 var readCmd = &cobra.Command{
 	Use:   "read <session>:<path> | read [<session>] <path>",
 	Short: "Read a file from workspace",
 	Long: `Reads a file from the workspace and outputs to stdout.
 
 Supports both colon syntax (session:path) and positional arguments.
-Binary files are base64 encoded with a warning to stderr.`,
+Binary files are base64 encoded with a warning to stderr.
+
+With --start-line or --end-line, the file is streamed and only that range of
+lines is printed, up to --max-bytes of text. When output stops early, the line
+to continue from is reported on stderr.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runRead,
 }
 
+func init() {
+	readCmd.Flags().IntVar(&readFlagStartLine, "start-line", 0, "First line to print (1-based)")
+	readCmd.Flags().IntVar(&readFlagEndLine, "end-line", 0, "Last line to print, inclusive")
+	readCmd.Flags().IntVar(&readFlagMaxBytes, "max-bytes", core.DefaultReadMaxBytes, "Maximum text to print in line mode")
+	readCmd.Flags().BoolVarP(&readFlagLineNumbers, "line-numbers", "n", false, "Prefix lines with their numbers")
+}
+
 func runRead(cmd *cobra.Command, args []string) error {
 	var sessionID, relativePath string
 
@@ -58,6 +76,10 @@ func runRead(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if readFlagStartLine > 0 || readFlagEndLine > 0 || readFlagLineNumbers {
+		return readLines(contentsDir, relativePath)
+	}
+
 	// Read file
 	data, err := core.ReadFile(contentsDir, relativePath)
 	if err != nil {
@@ -77,3 +99,35 @@ func runRead(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// readLines prints a range of lines from a streamed file.
+func readLines(contentsDir, relativePath string) error {
+	result, err := core.ReadLines(contentsDir, relativePath, core.ReadLinesOptions{
+		StartLine: readFlagStartLine,
+		EndLine:   readFlagEndLine,
+		MaxBytes:  readFlagMaxBytes,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Output
+	if flagJSON {
+		return outputJSON(result)
+	}
+
+	for _, line := range result.Lines {
+		if readFlagLineNumbers {
+			fmt.Printf("%6d\t%s\n", line.Number, line.Text)
+		} else {
+			fmt.Println(line.Text)
+		}
+	}
+
+	if !flagQuiet && result.NextLine > 0 {
+		fmt.Fprintf(os.Stderr, "lines %d-%d of %d; continue with --start-line %d\n",
+			result.StartLine, result.EndLine, result.TotalLines, result.NextLine)
+	}
+
+	return nil
+}
@@ -56,9 +56,17 @@ func init() {
 	rootCmd.AddCommand(readCmd)
 	rootCmd.AddCommand(writeCmd)
 	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(mvCmd)
+	rootCmd.AddCommand(cpCmd)
+	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(grepCmd)
+	rootCmd.AddCommand(findCmd)
+	rootCmd.AddCommand(statCmd)
 	rootCmd.AddCommand(syncCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(txCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(diffArchivesCmd)
 	rootCmd.AddCommand(pathCmd)
 	rootCmd.AddCommand(mcpCmd)
 	rootCmd.AddCommand(versionCmd)
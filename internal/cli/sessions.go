@@ -14,7 +14,8 @@ var sessionsCmd = &cobra.Command{
 	Short: "List all open sessions",
 	Long: `Lists all currently open zipfs sessions.
 
-Outputs a table by default, or JSON with the --json flag.`,
+Outputs a table by default, or JSON with the --json flag. Both include the
+disk used by all workspaces and the max_total_disk_bytes limit.`,
 	Args: cobra.NoArgs,
 	RunE: runSessions,
 }
@@ -25,6 +26,16 @@ func runSessions(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	usage, err := core.TotalDiskUsage(cfg)
+	if err != nil {
+		return err
+	}
+
 	if flagJSON {
 		// Build JSON output matching MCP format
 		output := make([]map[string]interface{}, 0, len(sessions))
@@ -44,6 +55,7 @@ func runSessions(cmd *cobra.Command, args []string) error {
 				"last_accessed_at":     s.LastAccessedAt.Format("2006-01-02T15:04:05Z07:00"),
 				"file_count":           s.FileCount,
 				"extracted_size_bytes": s.ExtractedSizeBytes,
+				"disk_usage_bytes":     usage.Workspaces[dirName],
 				"workspace_path":       workspacePath,
 			}
 
@@ -53,7 +65,11 @@ func runSessions(cmd *cobra.Command, args []string) error {
 
 			output = append(output, sessionData)
 		}
-		return outputJSON(output)
+		return outputJSON(map[string]interface{}{
+			"sessions":             output,
+			"disk_usage_bytes":     usage.TotalBytes,
+			"max_total_disk_bytes": usage.LimitBytes,
+		})
 	}
 
 	// Human-readable table output
@@ -65,7 +81,7 @@ func runSessions(cmd *cobra.Command, args []string) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tNAME\tSOURCE\tFILES\tSIZE")
+	fmt.Fprintln(w, "ID\tNAME\tSOURCE\tFILES\tSIZE\tDISK")
 
 	for _, s := range sessions {
 		name := s.Name
@@ -82,11 +98,20 @@ func runSessions(cmd *cobra.Command, args []string) error {
 		// Format size
 		sizeStr := formatBytes(s.ExtractedSizeBytes)
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n",
-			shortID, name, s.SourcePath, s.FileCount, sizeStr)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			shortID, name, s.SourcePath, s.FileCount, sizeStr, formatBytes(usage.Workspaces[s.DirName()]))
 	}
 
 	w.Flush()
+
+	if !flagQuiet {
+		limit := "unlimited"
+		if usage.LimitBytes > 0 {
+			limit = formatBytes(usage.LimitBytes)
+		}
+		fmt.Printf("\nDisk usage: %s of %s\n", formatBytes(usage.TotalBytes), limit)
+	}
+
 	return nil
 }
 
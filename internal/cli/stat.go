@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var statCmd = &cobra.Command{
+	Use:   "stat <session>:<path> | stat [<session>] <path>",
+	Short: "Show details of a single entry",
+	Long: `Shows one workspace entry: type, size, mode and modification time, its change
+state compared with the original zip, and the original zip header for the entry
+(compression method, CRC-32, compressed size and comment).
+
+Paths deleted from the workspace are still reported from the original zip.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runStat,
+}
+
+func runStat(cmd *cobra.Command, args []string) error {
+	var sessionID, relativePath string
+
+	// Parse arguments - support colon syntax
+	if len(args) == 1 {
+		sessionID, relativePath = parseColonSyntax(args[0])
+	} else {
+		sessionID = args[0]
+		relativePath = args[1]
+	}
+
+	if relativePath == "" {
+		return fmt.Errorf("path cannot be empty")
+	}
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	stat, err := core.StatFile(session, relativePath)
+	if err != nil {
+		return err
+	}
+
+	if flagJSON {
+		return outputJSON(stat)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Path:\t%s\n", stat.Path)
+	fmt.Fprintf(w, "Type:\t%s\n", stat.Type)
+	fmt.Fprintf(w, "State:\t%s\n", statState(stat))
+	if stat.Exists {
+		fmt.Fprintf(w, "Size:\t%s (%d bytes)\n", formatBytes(stat.SizeBytes), stat.SizeBytes)
+		fmt.Fprintf(w, "Mode:\t%s\n", stat.Mode)
+		fmt.Fprintf(w, "Modified:\t%s\n", stat.ModifiedAt.Format(time.RFC3339))
+		if stat.Target != "" {
+			fmt.Fprintf(w, "Target:\t%s\n", stat.Target)
+		}
+	}
+	if o := stat.Original; o != nil {
+		fmt.Fprintf(w, "Original:\t%s\n", o.Name)
+		fmt.Fprintf(w, "  Method:\t%s\n", o.MethodName)
+		fmt.Fprintf(w, "  CRC32:\t%s\n", o.CRC32)
+		fmt.Fprintf(w, "  Size:\t%d bytes (%d compressed)\n", o.UncompressedSize, o.CompressedSize)
+		fmt.Fprintf(w, "  Mode:\t%s\n", o.Mode)
+		fmt.Fprintf(w, "  Modified:\t%s\n", o.Modified.Format(time.RFC3339))
+		if o.Comment != "" {
+			fmt.Fprintf(w, "  Comment:\t%s\n", o.Comment)
+		}
+	}
+	return w.Flush()
+}
+
+// statState describes the change state, naming the other side of a rename.
+func statState(stat *core.FileStat) string {
+	switch {
+	case stat.RenamedFrom != "":
+		return fmt.Sprintf("%s (from %s)", stat.State, stat.RenamedFrom)
+	case stat.RenamedTo != "":
+		return fmt.Sprintf("%s (to %s)", stat.State, stat.RenamedTo)
+	}
+	return stat.State
+}
@@ -49,7 +49,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	fmt.Printf("On session: %s\n", sessionRef)
 	fmt.Printf("Source: %s\n\n", session.SourcePath)
 
-	totalChanges := len(status.Modified) + len(status.Added) + len(status.Deleted)
+	totalChanges := status.ChangeCount()
 
 	if totalChanges == 0 {
 		fmt.Println("No changes since extraction")
@@ -81,7 +81,31 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
-	fmt.Printf("%d file(s) changed, %d unchanged\n", totalChanges, status.UnchangedCount)
+	if len(status.Renamed) > 0 {
+		fmt.Printf("Renamed files (%d):\n", len(status.Renamed))
+		for _, r := range status.Renamed {
+			fmt.Printf("  R %s -> %s\n", r.From, r.To)
+		}
+		fmt.Println()
+	}
+
+	if len(status.AddedDirs) > 0 {
+		fmt.Printf("Added directories (%d):\n", len(status.AddedDirs))
+		for _, path := range status.AddedDirs {
+			fmt.Printf("  A %s/\n", path)
+		}
+		fmt.Println()
+	}
+
+	if len(status.DeletedDirs) > 0 {
+		fmt.Printf("Deleted directories (%d):\n", len(status.DeletedDirs))
+		for _, path := range status.DeletedDirs {
+			fmt.Printf("  D %s/\n", path)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%d change(s), %d file(s) unchanged\n", totalChanges, status.UnchangedCount)
 
 	return nil
 }
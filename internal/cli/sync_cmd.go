@@ -1,15 +1,22 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/Fuabioo/zipfs/internal/core"
 	"github.com/spf13/cobra"
 )
 
 var (
-	syncFlagForce  bool
-	syncFlagDryRun bool
+	syncFlagForce    bool
+	syncFlagDryRun   bool
+	syncFlagWatch    bool
+	syncFlagDebounce time.Duration
 )
 
 var syncCmd = &cobra.Command{
@@ -19,7 +26,14 @@ var syncCmd = &cobra.Command{
 
 Creates a backup of the original zip file before syncing.
 Use --force to ignore external modification conflicts.
-Use --dry-run to preview changes without syncing.`,
+Use --dry-run to preview changes without syncing.
+
+With --watch, keeps running and syncs each time changes to the workspace
+contents settle for --debounce, for example while editing files through
+"zipfs path". Every sync runs the normal conflict check; failures are logged
+and retried after the next change. One line is logged per sync (one JSON
+object per line with --json). Stops on Ctrl+C. Refuses to start while another
+sync or watch holds the session.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runSync,
 }
@@ -27,6 +41,10 @@ Use --dry-run to preview changes without syncing.`,
 func init() {
 	syncCmd.Flags().BoolVar(&syncFlagForce, "force", false, "Ignore external modification conflict")
 	syncCmd.Flags().BoolVar(&syncFlagDryRun, "dry-run", false, "Preview changes without syncing")
+	syncCmd.Flags().BoolVar(&syncFlagWatch, "watch", false, "Keep syncing whenever the workspace changes")
+	syncCmd.Flags().DurationVar(&syncFlagDebounce, "debounce", core.DefaultWatchDebounce, "With --watch, quiet period before syncing")
+	syncCmd.MarkFlagsMutuallyExclusive("watch", "force")
+	syncCmd.MarkFlagsMutuallyExclusive("watch", "dry-run")
 }
 
 func runSync(cmd *cobra.Command, args []string) error {
@@ -47,6 +65,10 @@ func runSync(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if syncFlagWatch {
+		return runSyncWatch(cmd, session, cfg)
+	}
+
 	// Dry run: just show status
 	if syncFlagDryRun {
 		status, err := core.Status(session)
@@ -60,9 +82,15 @@ func runSync(cmd *cobra.Command, args []string) error {
 				"files_modified": len(status.Modified),
 				"files_added":    len(status.Added),
 				"files_deleted":  len(status.Deleted),
+				"files_renamed":  len(status.Renamed),
+				"dirs_added":     len(status.AddedDirs),
+				"dirs_deleted":   len(status.DeletedDirs),
 				"modified":       status.Modified,
 				"added":          status.Added,
 				"deleted":        status.Deleted,
+				"renamed":        status.Renamed,
+				"added_dirs":     status.AddedDirs,
+				"deleted_dirs":   status.DeletedDirs,
 			}
 			return outputJSON(output)
 		}
@@ -86,8 +114,26 @@ func runSync(cmd *cobra.Command, args []string) error {
 				fmt.Printf("  D %s\n", path)
 			}
 		}
+		if len(status.Renamed) > 0 {
+			fmt.Printf("\nRenamed (%d):\n", len(status.Renamed))
+			for _, r := range status.Renamed {
+				fmt.Printf("  R %s -> %s\n", r.From, r.To)
+			}
+		}
+		if len(status.AddedDirs) > 0 {
+			fmt.Printf("\nAdded directories (%d):\n", len(status.AddedDirs))
+			for _, path := range status.AddedDirs {
+				fmt.Printf("  A %s/\n", path)
+			}
+		}
+		if len(status.DeletedDirs) > 0 {
+			fmt.Printf("\nDeleted directories (%d):\n", len(status.DeletedDirs))
+			for _, path := range status.DeletedDirs {
+				fmt.Printf("  D %s/\n", path)
+			}
+		}
 
-		if len(status.Modified)+len(status.Added)+len(status.Deleted) == 0 {
+		if !status.HasChanges() {
 			fmt.Println("No changes to sync")
 		}
 
@@ -108,16 +154,25 @@ func runSync(cmd *cobra.Command, args []string) error {
 			"files_modified":     result.FilesModified,
 			"files_added":        result.FilesAdded,
 			"files_deleted":      result.FilesDeleted,
+			"files_renamed":      result.FilesRenamed,
+			"dirs_added":         result.DirsAdded,
+			"dirs_deleted":       result.DirsDeleted,
 			"new_zip_size_bytes": result.NewZipSizeBytes,
 		}
 		if result.StatusError != nil {
 			output["status_error"] = result.StatusError.Error()
 		}
+		if result.Recovery != nil {
+			output["recovery"] = result.Recovery
+		}
 		return outputJSON(output)
 	}
 
 	// Human-readable output
 	if !flagQuiet {
+		if result.Recovery != nil {
+			fmt.Printf("Recovered interrupted sync (step %q): %s\n", result.Recovery.Step, result.Recovery.Action)
+		}
 		fmt.Printf("Synced to: %s\n", session.SourcePath)
 		fmt.Printf("Backup: %s\n", result.BackupPath)
 		fmt.Printf("New size: %s\n", formatBytes(result.NewZipSizeBytes))
@@ -128,3 +183,60 @@ func runSync(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// runSyncWatch syncs the session every time its contents change, until interrupted.
+func runSyncWatch(cmd *cobra.Command, session *core.Session, cfg *core.Config) error {
+	if syncFlagDebounce <= 0 {
+		return fmt.Errorf("--debounce must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !flagQuiet {
+		fmt.Fprintf(os.Stderr, "Watching %s (debounce %s); press Ctrl+C to stop\n", session.DirName(), syncFlagDebounce)
+	}
+
+	err := core.WatchSync(ctx, session, cfg, core.WatchOptions{Debounce: syncFlagDebounce}, logWatchEvent)
+	if err != nil {
+		return err
+	}
+
+	if !flagQuiet {
+		fmt.Fprintln(os.Stderr, "Stopped watching")
+	}
+	return nil
+}
+
+// logWatchEvent prints one line per sync attempted by a watch.
+func logWatchEvent(e core.WatchEvent) {
+	if flagJSON {
+		output := map[string]interface{}{
+			"time":    e.Time,
+			"synced":  e.Err == nil,
+			"changes": e.Changes,
+		}
+		if e.Err != nil {
+			output["error"] = e.Err.Error()
+		} else {
+			output["backup_path"] = e.Result.BackupPath
+			output["files_modified"] = e.Result.FilesModified
+			output["files_added"] = e.Result.FilesAdded
+			output["files_deleted"] = e.Result.FilesDeleted
+			output["files_renamed"] = e.Result.FilesRenamed
+			output["new_zip_size_bytes"] = e.Result.NewZipSizeBytes
+		}
+		// One compact object per line, so the log can be streamed
+		_ = json.NewEncoder(os.Stdout).Encode(output)
+		return
+	}
+
+	stamp := e.Time.Format("15:04:05")
+	if e.Err != nil {
+		fmt.Printf("[%s] Sync failed: %v\n", stamp, e.Err)
+		return
+	}
+	r := e.Result
+	fmt.Printf("[%s] Synced: %d modified, %d added, %d deleted, %d renamed (%s, backup %s)\n",
+		stamp, r.FilesModified, r.FilesAdded, r.FilesDeleted, r.FilesRenamed, formatBytes(r.NewZipSizeBytes), r.BackupPath)
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Group writes and deletes into a transaction",
+	Long: `Groups writes and deletes so that they reach the workspace together.
+
+After "tx begin", write and delete (including delete --glob) stage their changes
+under the workspace directory instead of applying them. "tx commit" applies every
+staged change in order, or none of them if one fails; "tx abort" discards them.
+Reads, listings, status and sync see the committed contents only, and edit, mv
+and cp into the session are refused until the transaction ends.
+
+The session argument is optional and will auto-resolve if only one session is open.`,
+}
+
+var txBeginCmd = &cobra.Command{
+	Use:   "begin [<session>]",
+	Short: "Start staging writes and deletes",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runTxBegin,
+}
+
+var txCommitCmd = &cobra.Command{
+	Use:   "commit [<session>]",
+	Short: "Apply the staged changes",
+	Long: `Applies the staged changes in order. Each file is written to a temp file and
+renamed into place. If any change fails, the workspace is restored and the
+transaction stays open. Committing again finishes a commit cut short by a crash.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTxCommit,
+}
+
+var txAbortCmd = &cobra.Command{
+	Use:   "abort [<session>]",
+	Short: "Discard the staged changes",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runTxAbort,
+}
+
+var txStatusCmd = &cobra.Command{
+	Use:   "status [<session>]",
+	Short: "List the staged changes",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runTxStatus,
+}
+
+func init() {
+	txCmd.AddCommand(txBeginCmd)
+	txCmd.AddCommand(txCommitCmd)
+	txCmd.AddCommand(txAbortCmd)
+	txCmd.AddCommand(txStatusCmd)
+}
+
+func runTxBegin(cmd *cobra.Command, args []string) error {
+	return runTx(args, "begin", core.BeginTransaction)
+}
+
+func runTxCommit(cmd *cobra.Command, args []string) error {
+	return runTx(args, "commit", core.CommitTransaction)
+}
+
+func runTxAbort(cmd *cobra.Command, args []string) error {
+	return runTx(args, "abort", core.AbortTransaction)
+}
+
+func runTxStatus(cmd *cobra.Command, args []string) error {
+	return runTx(args, "status", core.GetTransaction)
+}
+
+// runTx resolves the session, runs one transaction action and reports the
+// transaction it acted on.
+func runTx(args []string, action string, fn func(*core.Session) (*core.Transaction, error)) error {
+	var identifier string
+	if len(args) > 0 {
+		identifier = args[0]
+	}
+
+	session, err := core.ResolveSession(identifier)
+	if err != nil {
+		return err
+	}
+
+	tx, err := fn(session)
+	if err != nil {
+		return err
+	}
+
+	if action != "status" {
+		// Touch session (non-fatal)
+		_ = core.TouchSession(session)
+	}
+
+	if flagJSON {
+		return outputJSON(map[string]interface{}{
+			"action":     action,
+			"session":    session.DirName(),
+			"id":         tx.ID,
+			"started_at": tx.StartedAt,
+			"ops":        tx.Ops,
+			"count":      len(tx.Ops),
+		})
+	}
+
+	if flagQuiet {
+		return nil
+	}
+
+	switch action {
+	case "begin":
+		fmt.Printf("Transaction started on %s: %s\n", session.DirName(), tx.ID)
+		return nil
+	case "commit":
+		fmt.Printf("Committed %d change(s) to %s\n", len(tx.Ops), session.DirName())
+	case "abort":
+		fmt.Printf("Discarded %d change(s) staged for %s\n", len(tx.Ops), session.DirName())
+	case "status":
+		fmt.Printf("Transaction %s on %s, started %s\n", tx.ID, session.DirName(), tx.StartedAt.Format("2006-01-02 15:04:05"))
+		if len(tx.Ops) == 0 {
+			fmt.Println("No changes staged")
+			return nil
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, op := range tx.Ops {
+		switch op.Op {
+		case core.TxOpWrite:
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", op.Op, op.Path, formatBytes(uint64(op.SizeBytes)))
+		default:
+			fmt.Fprintf(w, "  %s\t%s\t\n", op.Op, op.Path)
+		}
+	}
+	return w.Flush()
+}
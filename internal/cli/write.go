@@ -83,8 +83,14 @@ func runWrite(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no content provided; use --content or pipe data to stdin")
 	}
 
+	// Load configuration for the disk budget check
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	// Write file
-	if err := core.WriteFile(contentsDir, relativePath, content, true); err != nil {
+	if err := core.WriteFile(contentsDir, relativePath, content, true, cfg); err != nil {
 		return err
 	}
 
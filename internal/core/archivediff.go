@@ -0,0 +1,156 @@
+package core
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// ArchiveDiffOptions controls DiffArchives.
+type ArchiveDiffOptions struct {
+	Content bool // stream changed entries from both archives and diff them
+	Context int  // unchanged lines around each change when Content is set
+}
+
+// ArchiveDiffResult lists the entries that differ between two archives. Statuses
+// are DiffAdded, DiffDeleted and DiffModified. Sizes always come from the central
+// directories; hunks and hashes are only filled in when content was compared.
+type ArchiveDiffResult struct {
+	OldPath        string     `json:"old_path"`
+	NewPath        string     `json:"new_path"`
+	Files          []FileDiff `json:"files"`
+	UnchangedCount int        `json:"unchanged_count"`
+}
+
+// DiffArchives compares two zip files without extracting them. Entries are
+// matched by name and compared by CRC-32 and uncompressed size from the central
+// directories. Both archives must pass the same zip bomb pre-scan as zipfs open.
+// With opts.Content, changed entries are streamed from both archives and diffed;
+// an entry whose content turns out identical is counted as unchanged.
+func DiffArchives(oldPath, newPath string, opts ArchiveDiffOptions, limits security.Limits) (*ArchiveDiffResult, error) {
+	oldReader, oldAbs, err := openCheckedZip(oldPath, limits)
+	if err != nil {
+		return nil, err
+	}
+	defer oldReader.Close()
+
+	newReader, newAbs, err := openCheckedZip(newPath, limits)
+	if err != nil {
+		return nil, err
+	}
+	defer newReader.Close()
+
+	oldFiles := zipFileIndex(&oldReader.Reader)
+	newFiles := zipFileIndex(&newReader.Reader)
+
+	result := &ArchiveDiffResult{
+		OldPath: oldAbs,
+		NewPath: newAbs,
+		Files:   []FileDiff{},
+	}
+
+	for name, oldFile := range oldFiles {
+		newFile, ok := newFiles[name]
+		if !ok {
+			result.Files = append(result.Files, FileDiff{
+				Path:    name,
+				Status:  DiffDeleted,
+				OldSize: int64(oldFile.UncompressedSize64),
+			})
+			continue
+		}
+
+		if oldFile.CRC32 == newFile.CRC32 && oldFile.UncompressedSize64 == newFile.UncompressedSize64 {
+			result.UnchangedCount++
+			continue
+		}
+
+		d := FileDiff{
+			Path:    name,
+			Status:  DiffModified,
+			OldSize: int64(oldFile.UncompressedSize64),
+			NewSize: int64(newFile.UncompressedSize64),
+		}
+
+		if opts.Content {
+			oldSide, err := readZipSide(oldFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s from %s: %w", name, oldAbs, err)
+			}
+			newSide, err := readZipSide(newFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s from %s: %w", name, newAbs, err)
+			}
+			if !fillFileDiff(&d, oldSide, newSide, opts.Context) {
+				result.UnchangedCount++
+				continue
+			}
+		}
+
+		result.Files = append(result.Files, d)
+	}
+
+	for name, newFile := range newFiles {
+		if _, ok := oldFiles[name]; !ok {
+			result.Files = append(result.Files, FileDiff{
+				Path:    name,
+				Status:  DiffAdded,
+				NewSize: int64(newFile.UncompressedSize64),
+			})
+		}
+	}
+
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].Path < result.Files[j].Path })
+
+	return result, nil
+}
+
+// openCheckedZip opens a zip file after the zip bomb and compression pre-scan.
+func openCheckedZip(zipPath string, limits security.Limits) (*zip.ReadCloser, string, error) {
+	absPath, err := filepath.Abs(zipPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if _, err := os.Stat(absPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", errors.ZipNotFound(zipPath)
+		}
+		return nil, "", fmt.Errorf("failed to stat zip: %w", err)
+	}
+
+	bombCheck, err := security.CheckZipBomb(absPath, limits)
+	if err != nil {
+		return nil, "", errors.ZipInvalid(absPath)
+	}
+	if !bombCheck.IsSafe {
+		return nil, "", errors.ZipBombDetected(bombCheck.Reason)
+	}
+	if len(bombCheck.UnsupportedMethods) > 0 {
+		return nil, "", errors.UnsupportedCompression(absPath, bombCheck.UnsupportedMethods)
+	}
+
+	r, err := zip.OpenReader(absPath)
+	if err != nil {
+		return nil, "", errors.ZipInvalid(absPath)
+	}
+
+	return r, absPath, nil
+}
+
+// zipFileIndex maps entry names to file entries, leaving out directories.
+func zipFileIndex(r *zip.Reader) map[string]*zip.File {
+	files := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files[f.Name] = f
+	}
+	return files
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+func TestDiffArchives(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldZip := filepath.Join(tempDir, "v1.zip")
+	createTestZip(t, oldZip, map[string]string{
+		"same.txt":    "unchanged\n",
+		"changed.xml": "<a>\n<b>1</b>\n</a>\n",
+		"removed.txt": "bye\n",
+	})
+	newZip := filepath.Join(tempDir, "v2.zip")
+	createTestZip(t, newZip, map[string]string{
+		"same.txt":    "unchanged\n",
+		"changed.xml": "<a>\n<b>2</b>\n<c/>\n</a>\n",
+		"added.txt":   "hi\n",
+	})
+
+	limits := security.DefaultLimits()
+
+	result, err := DiffArchives(oldZip, newZip, ArchiveDiffOptions{}, limits)
+	if err != nil {
+		t.Fatalf("DiffArchives failed: %v", err)
+	}
+
+	var summary []string
+	for _, d := range result.Files {
+		summary = append(summary, d.Status+":"+d.Path)
+		if len(d.Hunks) != 0 {
+			t.Errorf("expected no hunks without content, got %+v", d)
+		}
+	}
+	want := "added:added.txt,modified:changed.xml,deleted:removed.txt"
+	if strings.Join(summary, ",") != want {
+		t.Errorf("expected %s, got %v", want, summary)
+	}
+	if result.UnchangedCount != 1 {
+		t.Errorf("expected 1 unchanged entry, got %d", result.UnchangedCount)
+	}
+
+	result, err = DiffArchives(oldZip, newZip, ArchiveDiffOptions{Content: true, Context: 1}, limits)
+	if err != nil {
+		t.Fatalf("DiffArchives with content failed: %v", err)
+	}
+
+	for _, d := range result.Files {
+		if d.Path != "changed.xml" {
+			continue
+		}
+		if d.Additions != 2 || d.Deletions != 1 {
+			t.Errorf("expected +2/-1, got +%d/-%d", d.Additions, d.Deletions)
+		}
+		if !strings.Contains(d.Unified(), "-<b>1</b>\n+<b>2</b>\n+<c/>") {
+			t.Errorf("unexpected diff:\n%s", d.Unified())
+		}
+	}
+}
+
+func TestDiffArchives_Errors(t *testing.T) {
+	tempDir := t.TempDir()
+
+	validZip := filepath.Join(tempDir, "valid.zip")
+	createTestZip(t, validZip, map[string]string{"file.txt": "content"})
+
+	_, err := DiffArchives(filepath.Join(tempDir, "missing.zip"), validZip, ArchiveDiffOptions{}, security.DefaultLimits())
+	if !errors.Is(err, errors.CodeZipNotFound) {
+		t.Errorf("expected ZIP_NOT_FOUND, got %v", err)
+	}
+
+	// The zip bomb limits apply to both archives
+	payload := make([]byte, 2048)
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatalf("failed to generate payload: %v", err)
+	}
+	bigZip := filepath.Join(tempDir, "big.zip")
+	createTestZip(t, bigZip, map[string]string{"big.txt": hex.EncodeToString(payload)})
+
+	limits := security.DefaultLimits()
+	limits.MaxExtractedSize = 1024
+
+	_, err = DiffArchives(validZip, bigZip, ArchiveDiffOptions{}, limits)
+	if !errors.Is(err, errors.CodeZipBombDetected) {
+		t.Errorf("expected ZIP_BOMB_DETECTED, got %v", err)
+	}
+}
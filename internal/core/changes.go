@@ -0,0 +1,316 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// maxTextDiffBytes is the largest file diffed line by line. Larger files are
+// summarized by size and hash like binary files.
+const maxTextDiffBytes = 8 * 1024 * 1024
+
+// binarySniffBytes is how much of a file is checked for NUL bytes, as git does.
+const binarySniffBytes = 8000
+
+// File diff statuses.
+const (
+	DiffModified = "modified"
+	DiffAdded    = "added"
+	DiffDeleted  = "deleted"
+	DiffRenamed  = "renamed"
+)
+
+// FileDiff is the difference between an original.zip entry and its workspace file.
+// Binary files, and text files over 8 MB, carry sizes and SHA-256 hashes instead
+// of hunks.
+type FileDiff struct {
+	Path      string     `json:"path"`
+	OldPath   string     `json:"old_path,omitempty"`
+	Status    string     `json:"status"`
+	Binary    bool       `json:"binary,omitempty"`
+	OldSize   int64      `json:"old_size"`
+	NewSize   int64      `json:"new_size"`
+	OldSHA256 string     `json:"old_sha256,omitempty"`
+	NewSHA256 string     `json:"new_sha256,omitempty"`
+	Additions int        `json:"additions"`
+	Deletions int        `json:"deletions"`
+	Hunks     []DiffHunk `json:"hunks,omitempty"`
+}
+
+// Unified renders the file diff in unified format, using /dev/null for the
+// missing side of an added or deleted file.
+func (d *FileDiff) Unified() string {
+	oldName, newName := "a/"+d.Path, "b/"+d.Path
+	if d.OldPath != "" {
+		oldName = "a/" + d.OldPath
+	}
+	switch d.Status {
+	case DiffAdded:
+		oldName = "/dev/null"
+	case DiffDeleted:
+		newName = "/dev/null"
+	}
+
+	if d.Binary {
+		return fmt.Sprintf("Binary files %s and %s differ (%d -> %d bytes, sha256 %s -> %s)\n",
+			oldName, newName, d.OldSize, d.NewSize, shortHash(d.OldSHA256), shortHash(d.NewSHA256))
+	}
+	if len(d.Hunks) == 0 {
+		return fmt.Sprintf("rename from %s\nrename to %s\n", d.OldPath, d.Path)
+	}
+	return FormatUnifiedDiff(oldName, newName, d.Hunks)
+}
+
+func shortHash(sum string) string {
+	if sum == "" {
+		return "-"
+	}
+	return sum[:min(len(sum), 12)]
+}
+
+// SessionDiffOptions selects what DiffSession compares.
+type SessionDiffOptions struct {
+	Paths   []string // restrict to these files, directories or globs; see CompilePathList
+	Context int      // unchanged lines around each change
+}
+
+// DiffSession compares workspace files with the entries of original.zip. Changes
+// come from Status; files whose content turns out identical (only the mtime
+// changed) are left out. Results are sorted by path.
+func DiffSession(session *Session, opts SessionDiffOptions) ([]FileDiff, error) {
+	for _, p := range opts.Paths {
+		if p != "" && p != "." && p != "/" && !IsGlob(p) {
+			if err := security.ValidateRelativePath(p); err != nil {
+				return nil, fmt.Errorf("invalid path: %w", err)
+			}
+		}
+	}
+	filter, err := CompilePathList(opts.Paths...)
+	if err != nil {
+		return nil, err
+	}
+	selected := func(paths ...string) bool {
+		for _, p := range paths {
+			if filter.Match(p) {
+				return true
+			}
+		}
+		return false
+	}
+
+	status, err := Status(session)
+	if err != nil {
+		return nil, err
+	}
+
+	dirName := session.DirName()
+	contentsDir, err := ContentsDir(dirName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contents directory: %w", err)
+	}
+	originalZipPath, err := OriginalZipPath(dirName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get original zip path: %w", err)
+	}
+
+	zipReader, err := zip.OpenReader(originalZipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open original zip: %w", err)
+	}
+	defer zipReader.Close()
+
+	originals := make(map[string]*zip.File, len(zipReader.File))
+	for _, f := range zipReader.File {
+		originals[f.Name] = f
+	}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	var diffs []FileDiff
+	add := func(d *FileDiff, oldFile *zip.File, newName string) error {
+		var oldSide, newSide diffSide
+		if oldFile != nil {
+			if oldSide, err = readZipSide(oldFile); err != nil {
+				return fmt.Errorf("failed to read original %s: %w", oldFile.Name, err)
+			}
+		}
+		if newName != "" {
+			if newSide, err = readWorkspaceSide(root, newName); err != nil {
+				return rootedError(err, newName, "read file")
+			}
+		}
+
+		if !fillFileDiff(d, oldSide, newSide, opts.Context) {
+			return nil
+		}
+		diffs = append(diffs, *d)
+		return nil
+	}
+
+	for _, p := range status.Modified {
+		if !selected(p) {
+			continue
+		}
+		if err := add(&FileDiff{Path: p, Status: DiffModified}, originals[p], p); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range status.Added {
+		if !selected(p) {
+			continue
+		}
+		if err := add(&FileDiff{Path: p, Status: DiffAdded}, nil, p); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range status.Deleted {
+		if !selected(p) {
+			continue
+		}
+		if err := add(&FileDiff{Path: p, Status: DiffDeleted}, originals[p], ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range status.Renamed {
+		if !selected(r.From, r.To) {
+			continue
+		}
+		diffs = append(diffs, FileDiff{Path: r.To, OldPath: r.From, Status: DiffRenamed})
+	}
+
+	// Renames carry no content change; fill in the size for reporting
+	for i := range diffs {
+		if diffs[i].Status == DiffRenamed {
+			if f := originals[diffs[i].OldPath]; f != nil {
+				diffs[i].OldSize = int64(f.UncompressedSize64)
+				diffs[i].NewSize = diffs[i].OldSize
+			}
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+
+	return diffs, nil
+}
+
+// diffSide is one side of a file comparison. Data is nil when the file is
+// too large to diff as text; the hash is always set for an existing side.
+type diffSide struct {
+	exists bool
+	data   []byte
+	size   int64
+	sum    string
+}
+
+// readDiffSide keeps content up to maxTextDiffBytes and hashes all of it.
+func readDiffSide(r io.Reader, size int64) (diffSide, error) {
+	side := diffSide{exists: true, size: size}
+	hash := sha256.New()
+
+	if size <= maxTextDiffBytes {
+		// The declared size may be wrong, so the read is bounded regardless
+		data, err := io.ReadAll(io.LimitReader(io.TeeReader(r, hash), maxTextDiffBytes+1))
+		if err != nil {
+			return side, err
+		}
+		if len(data) <= maxTextDiffBytes {
+			side.data = data
+			side.size = int64(len(data))
+		}
+	}
+	if side.data == nil {
+		n, err := io.Copy(hash, r)
+		if err != nil {
+			return side, err
+		}
+		if size <= maxTextDiffBytes {
+			side.size = maxTextDiffBytes + 1 + n
+		}
+	}
+
+	side.sum = hex.EncodeToString(hash.Sum(nil))
+	return side, nil
+}
+
+// readZipSide reads an original.zip entry; a symlink entry's content is its target.
+func readZipSide(f *zip.File) (diffSide, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return diffSide{}, err
+	}
+	defer rc.Close()
+
+	return readDiffSide(rc, int64(f.UncompressedSize64))
+}
+
+// readWorkspaceSide reads a workspace file; a symlink is compared by its target.
+func readWorkspaceSide(root *os.Root, name string) (diffSide, error) {
+	info, err := root.Lstat(name)
+	if err != nil {
+		return diffSide{}, err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := root.Readlink(name)
+		if err != nil {
+			return diffSide{}, err
+		}
+		return readDiffSide(strings.NewReader(target), int64(len(target)))
+	}
+
+	file, err := root.Open(name)
+	if err != nil {
+		return diffSide{}, err
+	}
+	defer file.Close()
+
+	return readDiffSide(file, info.Size())
+}
+
+// fillFileDiff computes hunks or a binary summary. It reports false when both
+// sides exist and their content is identical.
+func fillFileDiff(d *FileDiff, oldSide, newSide diffSide, context int) bool {
+	if oldSide.exists && newSide.exists && oldSide.sum == newSide.sum {
+		return false
+	}
+
+	d.OldSize, d.NewSize = oldSide.size, newSide.size
+
+	tooLarge := (oldSide.exists && oldSide.data == nil) || (newSide.exists && newSide.data == nil)
+	if tooLarge || isBinary(oldSide.data) || isBinary(newSide.data) {
+		d.Binary = true
+		d.OldSHA256, d.NewSHA256 = oldSide.sum, newSide.sum
+		return true
+	}
+
+	d.Hunks = DiffText(string(oldSide.data), string(newSide.data), context)
+	for _, h := range d.Hunks {
+		for _, line := range h.Lines {
+			switch line[0] {
+			case '+':
+				d.Additions++
+			case '-':
+				d.Deletions++
+			}
+		}
+	}
+	return true
+}
+
+// isBinary reports whether data looks binary: a NUL byte near the start.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binarySniffBytes)], 0) >= 0
+}
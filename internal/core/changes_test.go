@@ -0,0 +1,113 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffSession(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"doc.xml":      "<a>\n<b>old</b>\n</a>\n",
+		"image.bin":    "PNG\x00\x01\x02",
+		"removed.txt":  "gone\n",
+		"touched.txt":  "same\n",
+		"keep/old.txt": "renamed content\n",
+	})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "diff", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(contentsDir, "doc.xml"), []byte("<a>\n<b>new</b>\n</a>\n"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "image.bin"), []byte("PNG\x00\x01\x02\x03"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "added.txt"), []byte("fresh\n"), 0644)
+	os.Remove(filepath.Join(contentsDir, "removed.txt"))
+	MoveFile(contentsDir, "keep/old.txt", "keep/new.txt", false)
+
+	// Only the mtime changes; the diff leaves it out
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(contentsDir, "touched.txt"), future, future)
+
+	diffs, err := DiffSession(session, SessionDiffOptions{Context: 1})
+	if err != nil {
+		t.Fatalf("DiffSession failed: %v", err)
+	}
+
+	byPath := make(map[string]FileDiff)
+	var paths []string
+	for _, d := range diffs {
+		byPath[d.Path] = d
+		paths = append(paths, d.Path)
+	}
+
+	want := "added.txt,doc.xml,image.bin,keep/new.txt,removed.txt"
+	if strings.Join(paths, ",") != want {
+		t.Fatalf("expected diffs for %s, got %v", want, paths)
+	}
+
+	doc := byPath["doc.xml"]
+	if doc.Status != DiffModified || doc.Additions != 1 || doc.Deletions != 1 {
+		t.Errorf("unexpected doc.xml diff: %+v", doc)
+	}
+	if !strings.Contains(doc.Unified(), "-<b>old</b>\n+<b>new</b>") {
+		t.Errorf("unexpected unified diff:\n%s", doc.Unified())
+	}
+
+	image := byPath["image.bin"]
+	if !image.Binary || image.OldSize != 6 || image.NewSize != 7 || image.OldSHA256 == image.NewSHA256 {
+		t.Errorf("expected binary summary, got %+v", image)
+	}
+	if len(image.Hunks) != 0 {
+		t.Errorf("expected no hunks for binary file, got %d", len(image.Hunks))
+	}
+
+	if added := byPath["added.txt"]; added.Status != DiffAdded || !strings.HasPrefix(added.Unified(), "--- /dev/null\n") {
+		t.Errorf("unexpected added diff: %+v", added)
+	}
+	if removed := byPath["removed.txt"]; removed.Status != DiffDeleted || removed.Deletions != 1 {
+		t.Errorf("unexpected deleted diff: %+v", removed)
+	}
+	if renamed := byPath["keep/new.txt"]; renamed.Status != DiffRenamed || renamed.OldPath != "keep/old.txt" {
+		t.Errorf("unexpected rename diff: %+v", renamed)
+	}
+
+	// Path filters limit the result
+	diffs, err = DiffSession(session, SessionDiffOptions{Paths: []string{"keep"}, Context: 3})
+	if err != nil {
+		t.Fatalf("DiffSession failed: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Path != "keep/new.txt" {
+		t.Errorf("expected only the rename under keep/, got %+v", diffs)
+	}
+
+	// Globs select by pattern and a leading ! excludes
+	diffs, err = DiffSession(session, SessionDiffOptions{Paths: []string{"*.{txt,bin}", "!removed.txt"}})
+	if err != nil {
+		t.Fatalf("DiffSession failed: %v", err)
+	}
+	paths = paths[:0]
+	for _, d := range diffs {
+		paths = append(paths, d.Path)
+	}
+	if want := "added.txt,image.bin,keep/new.txt"; strings.Join(paths, ",") != want {
+		t.Errorf("expected glob-filtered diffs %s, got %v", want, paths)
+	}
+
+	if _, err := DiffSession(session, SessionDiffOptions{Paths: []string{"../x"}}); err == nil {
+		t.Error("expected traversal in path filter to fail")
+	}
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Fuabioo/zipfs/internal/security"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// zstdMaxWindow bounds the memory a single zstd entry can request while decoding.
+const zstdMaxWindow = 128 << 20 // 128MB
+
+// lzmaFlagEOS is the general purpose bit signalling an LZMA end-of-stream marker.
+const lzmaFlagEOS = 0x2
+
+// compressionMethodNames maps supported methods to the names accepted in config.
+var compressionMethodNames = map[uint16]string{
+	security.MethodStore:      "store",
+	security.MethodDeflate:    "deflate",
+	security.MethodBzip2:      "bzip2",
+	security.MethodLZMA:       "lzma",
+	security.MethodZstdPKWare: "zstd",
+	security.MethodZstd:       "zstd",
+}
+
+// writableMethods lists the methods Repack can encode.
+// Go has no bzip2 encoder, so bzip2 entries are rewritten with the fallback method.
+var writableMethods = map[uint16]bool{
+	security.MethodStore:   true,
+	security.MethodDeflate: true,
+	security.MethodLZMA:    true,
+	security.MethodZstd:    true,
+}
+
+func init() {
+	// archive/zip only ships Store and Deflate; register the rest globally so that
+	// every zip.Reader and zip.Writer in the process understands them.
+	zstdDecompressor := zstd.ZipDecompressor(
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxWindow(zstdMaxWindow),
+	)
+	zip.RegisterDecompressor(security.MethodZstd, zstdDecompressor)
+	zip.RegisterDecompressor(security.MethodZstdPKWare, zstdDecompressor)
+	zip.RegisterDecompressor(security.MethodBzip2, newBzip2Reader)
+	zip.RegisterDecompressor(security.MethodLZMA, newLZMAReader)
+
+	zip.RegisterCompressor(security.MethodZstd, zstd.ZipCompressor(zstd.WithEncoderConcurrency(1)))
+	zip.RegisterCompressor(security.MethodLZMA, newLZMAWriter)
+}
+
+// CompressionMethodName returns a human-readable name for a zip compression method.
+func CompressionMethodName(method uint16) string {
+	if name, ok := compressionMethodNames[method]; ok {
+		return name
+	}
+	return fmt.Sprintf("method-%d", method)
+}
+
+// ParseCompressionMethod converts a configured method name into a writable zip method.
+func ParseCompressionMethod(name string) (uint16, error) {
+	switch strings.ToLower(name) {
+	case "", "deflate":
+		return zip.Deflate, nil
+	case "store":
+		return zip.Store, nil
+	case "lzma":
+		return security.MethodLZMA, nil
+	case "zstd":
+		return security.MethodZstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported compression method %q (use store, deflate, lzma or zstd)", name)
+	}
+}
+
+// canCompress reports whether Repack can write entries with the given method.
+func canCompress(method uint16) bool {
+	return writableMethods[method]
+}
+
+// errReadCloser is returned by decompressors that fail before producing data.
+type errReadCloser struct {
+	err error
+}
+
+func (e errReadCloser) Read([]byte) (int, error) { return 0, e.err }
+func (e errReadCloser) Close() error             { return nil }
+
+// newBzip2Reader adapts the stdlib bzip2 reader to a zip.Decompressor.
+func newBzip2Reader(r io.Reader) io.ReadCloser {
+	return io.NopCloser(bzip2.NewReader(r))
+}
+
+// lzmaReadCloser converts the decoder's end-of-input error into io.EOF.
+type lzmaReadCloser struct {
+	r *lzma.Reader
+}
+
+func (l *lzmaReadCloser) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	// Entries written without an EOS marker end when the input runs out. archive/zip
+	// verifies the uncompressed size and CRC-32 on EOF, so truncation is still caught.
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (l *lzmaReadCloser) Close() error { return nil }
+
+// newLZMAReader decodes a zip LZMA entry. Zip stores a 4-byte header (version and
+// properties length) followed by the 5 property bytes, but no uncompressed size, so
+// the classic 13-byte LZMA header is rebuilt with an unknown size.
+func newLZMAReader(r io.Reader) io.ReadCloser {
+	var zipHeader [4]byte
+	if _, err := io.ReadFull(r, zipHeader[:]); err != nil {
+		return errReadCloser{err: fmt.Errorf("failed to read LZMA header: %w", err)}
+	}
+
+	propsLen := binary.LittleEndian.Uint16(zipHeader[2:])
+	if propsLen != 5 {
+		return errReadCloser{err: fmt.Errorf("unexpected LZMA properties length %d", propsLen)}
+	}
+
+	classic := make([]byte, lzma.HeaderLen)
+	if _, err := io.ReadFull(r, classic[:5]); err != nil {
+		return errReadCloser{err: fmt.Errorf("failed to read LZMA properties: %w", err)}
+	}
+	for i := 5; i < lzma.HeaderLen; i++ {
+		classic[i] = 0xFF // unknown uncompressed size
+	}
+
+	lr, err := lzma.NewReader(io.MultiReader(bytes.NewReader(classic), r))
+	if err != nil {
+		return errReadCloser{err: fmt.Errorf("failed to create LZMA reader: %w", err)}
+	}
+
+	return &lzmaReadCloser{r: lr}
+}
+
+// lzmaHeaderWriter rewrites the classic LZMA header emitted by lzma.Writer into the
+// zip form: version 9.20, properties length 5, then the properties themselves.
+type lzmaHeaderWriter struct {
+	w      io.Writer
+	header []byte
+}
+
+func (h *lzmaHeaderWriter) Write(p []byte) (int, error) {
+	n := 0
+	if len(h.header) < lzma.HeaderLen {
+		take := lzma.HeaderLen - len(h.header)
+		if take > len(p) {
+			take = len(p)
+		}
+		h.header = append(h.header, p[:take]...)
+		p = p[take:]
+		n = take
+
+		if len(h.header) == lzma.HeaderLen {
+			zipHeader := []byte{9, 20, 5, 0}
+			zipHeader = append(zipHeader, h.header[:5]...)
+			if _, err := h.w.Write(zipHeader); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if len(p) == 0 {
+		return n, nil
+	}
+
+	written, err := h.w.Write(p)
+	return n + written, err
+}
+
+// newLZMAWriter encodes a zip LZMA entry. The stream always ends with an EOS marker,
+// so entries must also set the lzmaFlagEOS general purpose bit.
+func newLZMAWriter(w io.Writer) (io.WriteCloser, error) {
+	lw, err := lzma.WriterConfig{EOSMarker: true}.NewWriter(&lzmaHeaderWriter{w: w})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LZMA writer: %w", err)
+	}
+	return lw, nil
+}
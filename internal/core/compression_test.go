@@ -0,0 +1,259 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// bzip2Payload is "hello from bzip2\n" repeated 20 times, compressed with bzip2.
+// Go has no bzip2 encoder, so the stream is embedded.
+const bzip2Payload = "QlpoOTFBWSZTWX5suN4AAE/ZgAAQQAAQABNm0BAgAFCAAAFKpDDUz1PhNE/k7JuTRNicEwmieE5JsTCYTCYT0XckU4UJB+bLjeA="
+
+// createMethodsZip writes a zip containing one entry per supported compression method.
+func createMethodsZip(t *testing.T, zipPath string) map[string]string {
+	t.Helper()
+
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("failed to create zip file: %v", err)
+	}
+	defer zipFile.Close()
+
+	w := zip.NewWriter(zipFile)
+	defer w.Close()
+
+	contents := map[string]string{
+		"zstd.txt":  strings.Repeat("hello from zstd\n", 20),
+		"lzma.txt":  strings.Repeat("hello from lzma\n", 20),
+		"bzip2.txt": strings.Repeat("hello from bzip2\n", 20),
+	}
+
+	for name, method := range map[string]uint16{"zstd.txt": security.MethodZstd, "lzma.txt": security.MethodLZMA} {
+		header := &zip.FileHeader{Name: name, Method: method}
+		if method == security.MethodLZMA {
+			header.Flags |= lzmaFlagEOS
+		}
+		fw, err := w.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		if _, err := fw.Write([]byte(contents[name])); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(bzip2Payload)
+	if err != nil {
+		t.Fatalf("failed to decode bzip2 payload: %v", err)
+	}
+	fw, err := w.CreateRaw(&zip.FileHeader{
+		Name:               "bzip2.txt",
+		Method:             security.MethodBzip2,
+		CRC32:              crc32.ChecksumIEEE([]byte(contents["bzip2.txt"])),
+		CompressedSize64:   uint64(len(compressed)),
+		UncompressedSize64: uint64(len(contents["bzip2.txt"])),
+	})
+	if err != nil {
+		t.Fatalf("failed to create bzip2 entry: %v", err)
+	}
+	if _, err := fw.Write(compressed); err != nil {
+		t.Fatalf("failed to write bzip2 entry: %v", err)
+	}
+
+	return contents
+}
+
+func TestExtract_AdditionalCompressionMethods(t *testing.T) {
+	tempDir := t.TempDir()
+	zipPath := filepath.Join(tempDir, "methods.zip")
+	contents := createMethodsZip(t, zipPath)
+
+	destDir := filepath.Join(tempDir, "extracted")
+	count, _, err := Extract(zipPath, destDir, security.DefaultLimits())
+	if err != nil {
+		t.Fatalf("failed to extract: %v", err)
+	}
+	if count != len(contents) {
+		t.Errorf("expected %d files, got %d", len(contents), count)
+	}
+
+	for name, want := range contents {
+		got, err := os.ReadFile(filepath.Join(destDir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: content mismatch", name)
+		}
+	}
+}
+
+func TestExtract_UnsupportedCompressionMethod(t *testing.T) {
+	tempDir := t.TempDir()
+	zipPath := filepath.Join(tempDir, "ppmd.zip")
+
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	w := zip.NewWriter(zipFile)
+	fw, err := w.CreateRaw(&zip.FileHeader{Name: "ppmd.bin", Method: 98, CompressedSize64: 4, UncompressedSize64: 4})
+	if err != nil {
+		t.Fatalf("failed to create raw entry: %v", err)
+	}
+	fw.Write([]byte("data"))
+	w.Close()
+	zipFile.Close()
+
+	destDir := filepath.Join(tempDir, "extracted")
+	_, _, err = Extract(zipPath, destDir, security.DefaultLimits())
+	if !errors.Is(err, errors.CodeZipInvalid) {
+		t.Fatalf("expected ZIP_INVALID error, got %v", err)
+	}
+
+	// Nothing should have been written
+	if _, err := os.Stat(filepath.Join(destDir, "ppmd.bin")); !os.IsNotExist(err) {
+		t.Error("expected no files to be extracted")
+	}
+}
+
+func TestRepackWithOptions_PreservesMethods(t *testing.T) {
+	tempDir := t.TempDir()
+	zipPath := filepath.Join(tempDir, "methods.zip")
+	contents := createMethodsZip(t, zipPath)
+
+	sourceDir := filepath.Join(tempDir, "source")
+	if _, _, err := Extract(zipPath, sourceDir, security.DefaultLimits()); err != nil {
+		t.Fatalf("failed to extract: %v", err)
+	}
+	os.WriteFile(filepath.Join(sourceDir, "new.txt"), []byte("new file"), 0644)
+
+	methods, err := ZipMethods(zipPath)
+	if err != nil {
+		t.Fatalf("failed to read methods: %v", err)
+	}
+
+	outPath := filepath.Join(tempDir, "out.zip")
+	err = RepackWithOptions(sourceDir, outPath, RepackOptions{Methods: methods, Fallback: zip.Store})
+	if err != nil {
+		t.Fatalf("failed to repack: %v", err)
+	}
+
+	r, err := zip.OpenReader(outPath)
+	if err != nil {
+		t.Fatalf("failed to open repacked zip: %v", err)
+	}
+	defer r.Close()
+
+	wantMethods := map[string]uint16{
+		"zstd.txt":  security.MethodZstd,
+		"lzma.txt":  security.MethodLZMA,
+		"bzip2.txt": zip.Store, // no bzip2 encoder, uses fallback
+		"new.txt":   zip.Store,
+	}
+
+	for _, f := range r.File {
+		if want, ok := wantMethods[f.Name]; ok && f.Method != want {
+			t.Errorf("%s: expected method %d, got %d", f.Name, want, f.Method)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Name, err)
+		}
+		if want, ok := contents[f.Name]; ok && !bytes.Equal(data, []byte(want)) {
+			t.Errorf("%s: content mismatch after repack", f.Name)
+		}
+	}
+}
+
+func TestRepackWithOptions_InvalidFallback(t *testing.T) {
+	tempDir := t.TempDir()
+
+	err := RepackWithOptions(tempDir, filepath.Join(tempDir, "out.zip"), RepackOptions{Fallback: security.MethodBzip2})
+	if err == nil {
+		t.Fatal("expected error for bzip2 fallback")
+	}
+}
+
+func TestSync_PreservesCompressionMethods(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "methods.zip")
+	createMethodsZip(t, zipPath)
+
+	cfg := DefaultConfig()
+	cfg.Defaults.CompressionFallback = "zstd"
+	session, err := CreateSession(zipPath, "methods", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.Name)
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if err := WriteFile(contentsDir, "lzma.txt", []byte("rewritten"), false, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := Sync(session, false, cfg); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+
+	methods, err := ZipMethods(zipPath)
+	if err != nil {
+		t.Fatalf("failed to read methods: %v", err)
+	}
+
+	if methods["lzma.txt"] != security.MethodLZMA {
+		t.Errorf("expected lzma.txt to stay LZMA, got %d", methods["lzma.txt"])
+	}
+	if methods["bzip2.txt"] != security.MethodZstd {
+		t.Errorf("expected bzip2.txt to use zstd fallback, got %d", methods["bzip2.txt"])
+	}
+}
+
+func TestParseCompressionMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    uint16
+		wantErr bool
+	}{
+		{"", zip.Deflate, false},
+		{"deflate", zip.Deflate, false},
+		{"STORE", zip.Store, false},
+		{"zstd", security.MethodZstd, false},
+		{"lzma", security.MethodLZMA, false},
+		{"bzip2", 0, true},
+		{"ppmd", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCompressionMethod(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCompressionMethod(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCompressionMethod(%q) = %d, want %d", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -24,12 +24,16 @@ type SecurityConfig struct {
 	MaxTotalDiskBytes     uint64  `json:"max_total_disk_bytes"`
 	MaxSessions           int     `json:"max_sessions"`
 	AllowSymlinks         bool    `json:"allow_symlinks"`
+	AllowMCPHostCopy      bool    `json:"allow_mcp_host_copy"` // zipfs_copy may read and write host paths
 	RegexTimeoutMS        int     `json:"regex_timeout_ms"`
+	MaxGrepBytes          uint64  `json:"max_grep_bytes"`
 }
 
 // DefaultsConfig holds default values for operations.
 type DefaultsConfig struct {
-	BackupRotationDepth int `json:"backup_rotation_depth"`
+	BackupRotationDepth int    `json:"backup_rotation_depth"`
+	CompressionFallback string `json:"compression_fallback"`
+	SearchIndex         string `json:"search_index"` // "off", "lazy" or "open"
 }
 
 // DefaultConfig returns the default configuration as specified in ADR-002.
@@ -42,10 +46,14 @@ func DefaultConfig() *Config {
 			MaxTotalDiskBytes:     10 * 1024 * 1024 * 1024, // 10GB
 			MaxSessions:           32,
 			AllowSymlinks:         false,
+			AllowMCPHostCopy:      false,
 			RegexTimeoutMS:        5000,
+			MaxGrepBytes:          256 * 1024 * 1024, // 256MB
 		},
 		Defaults: DefaultsConfig{
 			BackupRotationDepth: 3,
+			CompressionFallback: "deflate",
+			SearchIndex:         SearchIndexOff,
 		},
 	}
 }
@@ -101,6 +109,15 @@ func applyEnvOverrides(cfg *Config) error {
 		cfg.Security.MaxFileCount = parsed
 	}
 
+	if val, ok := os.LookupEnv("ZIPFS_SEARCH_INDEX"); ok {
+		switch val {
+		case SearchIndexOff, SearchIndexLazy, SearchIndexOpen:
+			cfg.Defaults.SearchIndex = val
+		default:
+			return fmt.Errorf("invalid ZIPFS_SEARCH_INDEX: %q (expected off, lazy or open)", val)
+		}
+	}
+
 	return nil
 }
 
@@ -110,5 +127,6 @@ func (c *Config) ToSecurityLimits() security.Limits {
 		MaxExtractedSize:    c.Security.MaxExtractedSizeBytes,
 		MaxFileCount:        c.Security.MaxFileCount,
 		MaxCompressionRatio: c.Security.MaxCompressionRatio,
+		AllowSymlinks:       c.Security.AllowSymlinks,
 	}
 }
@@ -26,9 +26,16 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("expected max sessions 32, got %d", cfg.Security.MaxSessions)
 	}
 
+	if cfg.Security.MaxGrepBytes != 256*1024*1024 {
+		t.Errorf("expected max grep bytes 256MB, got %d", cfg.Security.MaxGrepBytes)
+	}
+
 	if cfg.Defaults.BackupRotationDepth != 3 {
 		t.Errorf("expected backup rotation depth 3, got %d", cfg.Defaults.BackupRotationDepth)
 	}
+	if cfg.Defaults.CompressionFallback != "deflate" {
+		t.Errorf("expected compression fallback \"deflate\", got %q", cfg.Defaults.CompressionFallback)
+	}
 }
 
 func TestLoadConfig_DefaultsWhenFileDoesntExist(t *testing.T) {
@@ -148,6 +155,26 @@ func TestLoadConfig_InvalidMaxSessions(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_SearchIndex(t *testing.T) {
+	tempDir := t.TempDir()
+
+	os.Setenv("ZIPFS_SEARCH_INDEX", "lazy")
+	defer os.Unsetenv("ZIPFS_SEARCH_INDEX")
+
+	cfg, err := LoadConfig(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Defaults.SearchIndex != SearchIndexLazy {
+		t.Errorf("expected search index lazy, got %q", cfg.Defaults.SearchIndex)
+	}
+
+	os.Setenv("ZIPFS_SEARCH_INDEX", "always")
+	if _, err := LoadConfig(tempDir); err == nil {
+		t.Fatal("expected error for invalid ZIPFS_SEARCH_INDEX")
+	}
+}
+
 func TestLoadConfig_InvalidMaxFileCount(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -0,0 +1,485 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// CopyLocation is one side of a copy. An empty ContentsDir means Path is on the
+// host file system; otherwise Path is relative to that workspace contents dir.
+type CopyLocation struct {
+	ContentsDir string
+	Path        string
+}
+
+// IsHost reports whether the location refers to the host file system.
+func (l CopyLocation) IsHost() bool {
+	return l.ContentsDir == ""
+}
+
+// CopyOptions controls how files are copied.
+type CopyOptions struct {
+	Recursive bool // copy directories and their contents
+	Overwrite bool // replace existing destination files
+}
+
+// CopyResult lists what a copy wrote.
+type CopyResult struct {
+	Files   []string `json:"files"`
+	Bytes   uint64   `json:"bytes"`
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// copySource is one top-level entry matched by the source path.
+type copySource struct {
+	fsys fs.FS
+	name string
+}
+
+// copyItem is a single directory or regular file to create at the destination.
+type copyItem struct {
+	fsys fs.FS
+	name string
+	dst  string
+	dir  bool
+	mode fs.FileMode
+	size int64
+}
+
+// copyTarget is the destination file system; *os.Root satisfies it for workspaces.
+type copyTarget interface {
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	MkdirAll(name string, perm fs.FileMode) error
+	OpenFile(name string, flag int, perm fs.FileMode) (*os.File, error)
+}
+
+// hostTarget writes to the host file system using slash-separated absolute names.
+type hostTarget struct{}
+
+func (hostTarget) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(filepath.FromSlash(name))
+}
+
+func (hostTarget) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(filepath.FromSlash(name))
+}
+
+func (hostTarget) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(filepath.FromSlash(name), perm)
+}
+
+func (hostTarget) OpenFile(name string, flag int, perm fs.FileMode) (*os.File, error) {
+	return os.OpenFile(filepath.FromSlash(name), flag, perm)
+}
+
+// CopyFiles copies files and directories between workspaces, or between a
+// workspace and the host. At least one side must be a workspace. The source path
+// may be a glob; several matches, a trailing slash or an existing directory at
+// the destination copy into that directory, as cp does. Content streams on disk
+// and never passes through memory as a whole. Symlinks and special files are
+// skipped and reported. Copies into a session with an open transaction are
+// refused. cfg enforces MaxTotalDiskBytes on workspace writes and may be nil to
+// skip the check.
+func CopyFiles(src, dst CopyLocation, opts CopyOptions, cfg *Config) (*CopyResult, error) {
+	if src.IsHost() && dst.IsHost() {
+		return nil, fmt.Errorf("at least one side of a copy must be a session")
+	}
+	if !dst.IsHost() {
+		if err := EnsureNoTx(dst.ContentsDir); err != nil {
+			return nil, err
+		}
+	}
+
+	sources, closeSrc, err := resolveCopySources(src)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSrc()
+
+	target, dstName, closeDst, err := openCopyTarget(dst)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDst()
+
+	// Decide whether the destination is the target itself or a directory to copy into
+	into := len(sources) > 1 || strings.HasSuffix(dst.Path, "/")
+	if info, err := target.Stat(dstName); err == nil && info.IsDir() {
+		into = true
+	}
+
+	result := &CopyResult{Files: []string{}}
+	sameWorkspace := !src.IsHost() && !dst.IsHost() && filepath.Clean(src.ContentsDir) == filepath.Clean(dst.ContentsDir)
+
+	var items []copyItem
+	for _, source := range sources {
+		base := dstName
+		if into {
+			base = path.Join(dstName, path.Base(source.name))
+		}
+
+		if sameWorkspace && (base == source.name || strings.HasPrefix(base, source.name+"/")) {
+			return nil, fmt.Errorf("cannot copy %q onto or into itself", source.name)
+		}
+
+		planned, skipped, err := planCopy(source, base, opts.Recursive)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, planned...)
+		result.Skipped = append(result.Skipped, skipped...)
+	}
+
+	// Validate every destination before writing anything. Only growth over the
+	// files being overwritten counts against the disk budget.
+	var growth uint64
+	for _, item := range items {
+		if !dst.IsHost() {
+			if err := validateCopyPath(dst.ContentsDir, item.dst); err != nil {
+				return nil, err
+			}
+		}
+
+		var existing int64
+		if info, err := target.Lstat(item.dst); err == nil {
+			if item.dir {
+				if !info.IsDir() {
+					return nil, errors.PathExists(item.dst)
+				}
+				continue
+			}
+			if !opts.Overwrite || !info.Mode().IsRegular() {
+				return nil, errors.PathExists(item.dst)
+			}
+			existing = info.Size()
+		}
+		if !item.dir && item.size > existing {
+			growth += uint64(item.size - existing)
+		}
+	}
+
+	// Refuse copies that would push all workspaces past MaxTotalDiskBytes
+	if !dst.IsHost() && cfg != nil {
+		if err := EnsureDiskBudget(cfg, growth); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range items {
+		if item.dir {
+			if err := target.MkdirAll(item.dst, 0755); err != nil {
+				return nil, copyError(dst, err, item.dst, "create directory")
+			}
+			continue
+		}
+
+		if err := target.MkdirAll(path.Dir(item.dst), 0755); err != nil {
+			return nil, copyError(dst, err, item.dst, "create parent directories")
+		}
+
+		n, err := copyItemTo(item, target)
+		if err != nil {
+			return nil, copyError(dst, err, item.dst, "copy file")
+		}
+
+		if !dst.IsHost() {
+			markSearchIndexStale(dst.ContentsDir, item.dst)
+		}
+
+		result.Files = append(result.Files, displayCopyPath(dst, item.dst))
+		result.Bytes += uint64(n)
+	}
+
+	return result, nil
+}
+
+// resolveCopySources expands the source path, including globs, into top-level entries.
+func resolveCopySources(src CopyLocation) ([]copySource, func(), error) {
+	noop := func() {}
+	isGlob := IsGlob(src.Path)
+
+	if src.IsHost() {
+		abs, err := filepath.Abs(src.Path)
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to resolve path: %w", err)
+		}
+
+		if base, pattern := splitHostGlob(abs); isGlob && pattern != "" {
+			return hostGlobSources(src.Path, base, pattern)
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return nil, noop, fmt.Errorf("failed to stat %s: %w", src.Path, err)
+		}
+		return []copySource{{fsys: os.DirFS(filepath.Dir(abs)), name: filepath.Base(abs)}}, noop, nil
+	}
+
+	var matcher *PathGlob
+	if isGlob {
+		g, err := CompileGlob(src.Path)
+		if err != nil {
+			return nil, noop, err
+		}
+		matcher = g
+	} else if err := validateCopyPath(src.ContentsDir, src.Path); err != nil {
+		return nil, noop, err
+	}
+
+	root, err := openContentsRoot(src.ContentsDir)
+	if err != nil {
+		return nil, noop, err
+	}
+	closeRoot := func() { root.Close() }
+	fsys := root.FS()
+
+	name := rootName(src.Path)
+	if !isGlob && name == "." {
+		closeRoot()
+		return nil, noop, fmt.Errorf("cannot copy the workspace root")
+	}
+
+	matches := []string{name}
+	if isGlob {
+		matches, err = globCopySources(fsys, matcher)
+		if err != nil {
+			closeRoot()
+			return nil, noop, err
+		}
+		if len(matches) == 0 {
+			closeRoot()
+			return nil, noop, errors.PathNotFound(src.Path)
+		}
+	} else if _, err := fs.Stat(fsys, name); err != nil {
+		closeRoot()
+		return nil, noop, rootedError(err, src.Path, "stat source")
+	}
+
+	sources := make([]copySource, 0, len(matches))
+	for _, m := range matches {
+		sources = append(sources, copySource{fsys: fsys, name: m})
+	}
+	return sources, closeRoot, nil
+}
+
+// splitHostGlob splits an absolute host path into the directory before its
+// first glob segment and the slash-separated pattern from there on. The
+// pattern is empty when no segment uses glob syntax.
+func splitHostGlob(abs string) (string, string) {
+	segments := strings.Split(filepath.ToSlash(abs), "/")
+	for i, s := range segments {
+		if strings.ContainsAny(s, "*?[{") {
+			base := filepath.FromSlash(strings.Join(segments[:i], "/"))
+			if base == "" || strings.HasSuffix(base, ":") {
+				base += string(filepath.Separator)
+			}
+			return base, strings.Join(segments[i:], "/")
+		}
+	}
+	return abs, ""
+}
+
+// hostGlobSources expands a host glob with the workspace glob engine, so "**"
+// and braces behave as they do inside a workspace. The pattern is anchored at
+// base, as a shell would expand it.
+func hostGlobSources(display, base, pattern string) ([]copySource, func(), error) {
+	noop := func() {}
+
+	matcher, err := CompileGlob("./" + pattern)
+	if err != nil {
+		return nil, noop, err
+	}
+
+	fsys := os.DirFS(base)
+	matches, err := globCopySources(fsys, matcher)
+	if err != nil {
+		return nil, noop, err
+	}
+	if len(matches) == 0 {
+		return nil, noop, fmt.Errorf("no files match %q", display)
+	}
+
+	sources := make([]copySource, 0, len(matches))
+	for _, m := range matches {
+		sources = append(sources, copySource{fsys: fsys, name: m})
+	}
+	return sources, noop, nil
+}
+
+// globCopySources walks a file system for entries matching a glob (see
+// PathGlob). A matching directory is taken whole, so nothing below it is listed
+// again; WalkDir never descends into symlinked directories.
+func globCopySources(fsys fs.FS, matcher *PathGlob) ([]string, error) {
+	depth := matcher.maxDepth()
+	var matches []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		if !matcher.Match(name) {
+			// Nothing deeper than the pattern reaches can match
+			if d.IsDir() && depth >= 0 && strings.Count(name, "/")+1 >= depth {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		matches = append(matches, name)
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+	return matches, nil
+}
+
+// openCopyTarget prepares the destination file system and the destination name in it.
+func openCopyTarget(dst CopyLocation) (copyTarget, string, func(), error) {
+	noop := func() {}
+
+	if dst.IsHost() {
+		abs, err := filepath.Abs(dst.Path)
+		if err != nil {
+			return nil, "", noop, fmt.Errorf("failed to resolve path: %w", err)
+		}
+		return hostTarget{}, filepath.ToSlash(abs), noop, nil
+	}
+
+	// An empty destination copies into the workspace root
+	name := "."
+	if dst.Path != "" {
+		if err := validateCopyPath(dst.ContentsDir, dst.Path); err != nil {
+			return nil, "", noop, err
+		}
+		name = rootName(dst.Path)
+	}
+
+	root, err := openContentsRoot(dst.ContentsDir)
+	if err != nil {
+		return nil, "", noop, err
+	}
+	return root, name, func() { root.Close() }, nil
+}
+
+// planCopy walks one source entry and lists the items to create under base.
+func planCopy(source copySource, base string, recursive bool) ([]copyItem, []string, error) {
+	info, err := fs.Stat(source.fsys, source.name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat %s: %w", source.name, err)
+	}
+	if info.IsDir() && !recursive {
+		return nil, nil, fmt.Errorf("%s is a directory, use recursive to copy it", source.name)
+	}
+
+	var items []copyItem
+	var skipped []string
+
+	err = fs.WalkDir(source.fsys, source.name, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		dst := base
+		if p != source.name {
+			dst = path.Join(base, strings.TrimPrefix(p, source.name+"/"))
+		}
+
+		// The top-level entry follows a symlink, as cp does; nested ones are skipped
+		mode := d.Type()
+		if p == source.name {
+			mode = info.Mode().Type()
+		}
+
+		switch {
+		case mode.IsDir():
+			items = append(items, copyItem{dst: dst, dir: true})
+		case mode.IsRegular():
+			fi, err := d.Info()
+			if p == source.name {
+				fi = info
+			} else if err != nil {
+				return err
+			}
+			items = append(items, copyItem{
+				fsys: source.fsys,
+				name: p,
+				dst:  dst,
+				mode: fi.Mode(),
+				size: fi.Size(),
+			})
+		default:
+			skipped = append(skipped, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to walk %s: %w", source.name, err)
+	}
+
+	return items, skipped, nil
+}
+
+// copyItemTo streams one regular file to the target.
+func copyItemTo(item copyItem, target copyTarget) (int64, error) {
+	in, err := item.fsys.Open(item.name)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	// Keep the executable bit but never exceed 0755, as extraction does
+	perm := fs.FileMode(0644)
+	if item.mode&0111 != 0 {
+		perm = 0755
+	}
+
+	out, err := target.OpenFile(item.dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// validateCopyPath applies the workspace path checks used by other file operations.
+func validateCopyPath(contentsDir, relativePath string) error {
+	if err := security.ValidateRelativePath(relativePath); err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	if err := security.ValidatePath(contentsDir, relativePath); err != nil {
+		return errors.PathTraversal(relativePath)
+	}
+	return nil
+}
+
+// copyError maps a destination error onto zipfs error codes for workspaces.
+func copyError(dst CopyLocation, err error, name, action string) error {
+	if dst.IsHost() {
+		return fmt.Errorf("failed to %s %s: %w", action, filepath.FromSlash(name), err)
+	}
+	return rootedError(err, name, action)
+}
+
+// displayCopyPath formats a destination name for results.
+func displayCopyPath(dst CopyLocation, name string) string {
+	if dst.IsHost() {
+		return filepath.FromSlash(name)
+	}
+	return name
+}
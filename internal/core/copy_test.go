@@ -0,0 +1,273 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+)
+
+// setupCopyWorkspaces creates two contents directories with a few files in the first.
+func setupCopyWorkspaces(t *testing.T) (string, string) {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	a := filepath.Join(tempDir, "a")
+	b := filepath.Join(tempDir, "b")
+	os.MkdirAll(filepath.Join(a, "media", "nested"), 0755)
+	os.MkdirAll(b, 0755)
+	os.WriteFile(filepath.Join(a, "media", "one.png"), []byte("one"), 0644)
+	os.WriteFile(filepath.Join(a, "media", "two.png"), []byte("two"), 0644)
+	os.WriteFile(filepath.Join(a, "media", "nested", "three.txt"), []byte("three"), 0644)
+	os.WriteFile(filepath.Join(a, "doc.xml"), []byte("<doc/>"), 0644)
+
+	return a, b
+}
+
+func TestCopyFiles_BetweenSessions(t *testing.T) {
+	a, b := setupCopyWorkspaces(t)
+
+	result, err := CopyFiles(
+		CopyLocation{ContentsDir: a, Path: "doc.xml"},
+		CopyLocation{ContentsDir: b, Path: "renamed/doc2.xml"},
+		CopyOptions{}, nil)
+	if err != nil {
+		t.Fatalf("CopyFiles failed: %v", err)
+	}
+
+	if len(result.Files) != 1 || result.Files[0] != "renamed/doc2.xml" {
+		t.Errorf("unexpected files: %v", result.Files)
+	}
+	if result.Bytes != uint64(len("<doc/>")) {
+		t.Errorf("expected %d bytes, got %d", len("<doc/>"), result.Bytes)
+	}
+
+	data, err := os.ReadFile(filepath.Join(b, "renamed", "doc2.xml"))
+	if err != nil || string(data) != "<doc/>" {
+		t.Errorf("expected copied content, got %q (%v)", data, err)
+	}
+
+	// The source is untouched
+	if _, err := os.Stat(filepath.Join(a, "doc.xml")); err != nil {
+		t.Errorf("expected source to remain: %v", err)
+	}
+}
+
+func TestCopyFiles_RecursiveAndGlob(t *testing.T) {
+	a, b := setupCopyWorkspaces(t)
+
+	// A directory needs recursive
+	_, err := CopyFiles(
+		CopyLocation{ContentsDir: a, Path: "media"},
+		CopyLocation{ContentsDir: b, Path: ""},
+		CopyOptions{}, nil)
+	if err == nil {
+		t.Fatal("expected error copying a directory without recursive")
+	}
+
+	result, err := CopyFiles(
+		CopyLocation{ContentsDir: a, Path: "media"},
+		CopyLocation{ContentsDir: b, Path: ""},
+		CopyOptions{Recursive: true}, nil)
+	if err != nil {
+		t.Fatalf("recursive copy failed: %v", err)
+	}
+
+	sort.Strings(result.Files)
+	want := "media/nested/three.txt,media/one.png,media/two.png"
+	if strings.Join(result.Files, ",") != want {
+		t.Errorf("expected %s, got %v", want, result.Files)
+	}
+
+	// Several glob matches copy into the destination directory
+	result, err = CopyFiles(
+		CopyLocation{ContentsDir: a, Path: "media/*.png"},
+		CopyLocation{ContentsDir: b, Path: "images/"},
+		CopyOptions{}, nil)
+	if err != nil {
+		t.Fatalf("glob copy failed: %v", err)
+	}
+
+	if strings.Join(result.Files, ",") != "images/one.png,images/two.png" {
+		t.Errorf("unexpected glob result: %v", result.Files)
+	}
+}
+
+func TestCopyFiles_GlobSyntax(t *testing.T) {
+	// Copy globs use the same matcher as grep, ls, find and delete
+	tests := map[string]string{
+		"media/**/*.txt":    "out/three.txt",
+		"media/*.{png,jpg}": "out/one.png,out/two.png",
+		"*.png":             "out/one.png,out/two.png",
+		"!{media,media/**}": "out/doc.xml",
+		"media/{one,two}.*": "out/one.png,out/two.png",
+	}
+
+	for pattern, want := range tests {
+		t.Run(pattern, func(t *testing.T) {
+			a, b := setupCopyWorkspaces(t)
+
+			result, err := CopyFiles(
+				CopyLocation{ContentsDir: a, Path: pattern},
+				CopyLocation{ContentsDir: b, Path: "out/"},
+				CopyOptions{}, nil)
+			if err != nil {
+				t.Fatalf("CopyFiles failed: %v", err)
+			}
+
+			sort.Strings(result.Files)
+			if got := strings.Join(result.Files, ","); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCopyFiles_HostToSessionAndBack(t *testing.T) {
+	a, _ := setupCopyWorkspaces(t)
+	hostDir := t.TempDir()
+
+	hostFile := filepath.Join(hostDir, "logo.bin")
+	os.WriteFile(hostFile, []byte{0x00, 0x01, 0xff}, 0755)
+
+	if _, err := CopyFiles(
+		CopyLocation{Path: hostFile},
+		CopyLocation{ContentsDir: a, Path: "media/"},
+		CopyOptions{}, nil); err != nil {
+		t.Fatalf("host to session copy failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(a, "media", "logo.bin"))
+	if err != nil {
+		t.Fatalf("expected copied file: %v", err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected executable mode to be kept, got %v", info.Mode().Perm())
+	}
+
+	outDir := filepath.Join(hostDir, "out")
+	if _, err := CopyFiles(
+		CopyLocation{ContentsDir: a, Path: "media"},
+		CopyLocation{Path: outDir},
+		CopyOptions{Recursive: true}, nil); err != nil {
+		t.Fatalf("session to host copy failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "nested", "three.txt"))
+	if err != nil || string(data) != "three" {
+		t.Errorf("expected copied host file, got %q (%v)", data, err)
+	}
+}
+
+func TestCopyFiles_HostGlob(t *testing.T) {
+	// Host globs use the workspace matcher too, anchored at their directory
+	hostDir := t.TempDir()
+	os.MkdirAll(filepath.Join(hostDir, "deep", "er"), 0755)
+	os.WriteFile(filepath.Join(hostDir, "top.txt"), []byte("top"), 0644)
+	os.WriteFile(filepath.Join(hostDir, "logo.png"), []byte("png"), 0644)
+	os.WriteFile(filepath.Join(hostDir, "deep", "er", "low.txt"), []byte("low"), 0644)
+
+	tests := map[string]string{
+		"**/*.txt":       "out/low.txt,out/top.txt",
+		"*.txt":          "out/top.txt",
+		"*.{png,txt}":    "out/logo.png,out/top.txt",
+		"deep/*/low.txt": "out/low.txt",
+	}
+
+	for pattern, want := range tests {
+		t.Run(pattern, func(t *testing.T) {
+			_, b := setupCopyWorkspaces(t)
+
+			result, err := CopyFiles(
+				CopyLocation{Path: filepath.Join(hostDir, pattern)},
+				CopyLocation{ContentsDir: b, Path: "out/"},
+				CopyOptions{}, nil)
+			if err != nil {
+				t.Fatalf("CopyFiles failed: %v", err)
+			}
+
+			sort.Strings(result.Files)
+			if got := strings.Join(result.Files, ","); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+
+	_, b := setupCopyWorkspaces(t)
+	if _, err := CopyFiles(
+		CopyLocation{Path: filepath.Join(hostDir, "*.xml")},
+		CopyLocation{ContentsDir: b, Path: "out/"},
+		CopyOptions{}, nil); err == nil {
+		t.Error("expected error when nothing matches")
+	}
+}
+
+func TestCopyFiles_Errors(t *testing.T) {
+	a, b := setupCopyWorkspaces(t)
+	os.WriteFile(filepath.Join(b, "doc.xml"), []byte("existing"), 0644)
+
+	tests := []struct {
+		name     string
+		src      CopyLocation
+		dst      CopyLocation
+		opts     CopyOptions
+		wantCode string
+	}{
+		{
+			name:     "existing destination",
+			src:      CopyLocation{ContentsDir: a, Path: "doc.xml"},
+			dst:      CopyLocation{ContentsDir: b, Path: "doc.xml"},
+			wantCode: errors.CodePathExists,
+		},
+		{
+			name:     "missing source",
+			src:      CopyLocation{ContentsDir: a, Path: "missing.xml"},
+			dst:      CopyLocation{ContentsDir: b, Path: "x.xml"},
+			wantCode: errors.CodePathNotFound,
+		},
+		{
+			name: "traversal in destination",
+			src:  CopyLocation{ContentsDir: a, Path: "doc.xml"},
+			dst:  CopyLocation{ContentsDir: b, Path: "../escape.xml"},
+		},
+		{
+			name: "directory into itself",
+			src:  CopyLocation{ContentsDir: a, Path: "media"},
+			dst:  CopyLocation{ContentsDir: a, Path: "media/copy"},
+			opts: CopyOptions{Recursive: true},
+		},
+		{
+			name: "host to host",
+			src:  CopyLocation{Path: filepath.Join(a, "doc.xml")},
+			dst:  CopyLocation{Path: filepath.Join(b, "other.xml")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CopyFiles(tt.src, tt.dst, tt.opts, nil)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.wantCode != "" && !errors.Is(err, tt.wantCode) {
+				t.Errorf("expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+
+	// Overwrite replaces the existing file
+	if _, err := CopyFiles(
+		CopyLocation{ContentsDir: a, Path: "doc.xml"},
+		CopyLocation{ContentsDir: b, Path: "doc.xml"},
+		CopyOptions{Overwrite: true}, nil); err != nil {
+		t.Fatalf("overwrite copy failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(b, "doc.xml"))
+	if string(data) != "<doc/>" {
+		t.Errorf("expected overwritten content, got %q", data)
+	}
+}
@@ -0,0 +1,280 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffEdits bounds the Myers search. Beyond it the differing middle of the two
+// texts is reported as one replacement, which is still a valid diff.
+const maxDiffEdits = 2000
+
+// noNewlineMarker follows a diff line whose file does not end in a newline.
+const noNewlineMarker = `\ No newline at end of file`
+
+// DiffHunk is one hunk of a unified diff. Lines carry their " ", "-" or "+"
+// prefix and no trailing newline; a missing newline at end of file is marked
+// with a `\ No newline at end of file` line, as in the unified format.
+type DiffHunk struct {
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Lines    []string `json:"lines"`
+}
+
+// Header returns the hunk's "@@ -a,b +c,d @@" line.
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// FormatUnifiedDiff renders hunks as a unified diff between oldName and newName.
+// It returns an empty string when there are no hunks.
+func FormatUnifiedDiff(oldName, newName string, hunks []DiffHunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks {
+		b.WriteString(h.Header())
+		b.WriteByte('\n')
+		for _, line := range h.Lines {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// DiffText computes the hunks that turn oldText into newText, with the given
+// number of unchanged context lines around each change.
+func DiffText(oldText, newText string, context int) []DiffHunk {
+	if context < 0 {
+		context = 0
+	}
+	return buildHunks(diffLines(splitLines(oldText), splitLines(newText)), context)
+}
+
+// lineOp is one line of an edit script: ' ' keeps, '-' deletes, '+' inserts.
+type lineOp struct {
+	kind byte
+	line string
+}
+
+// splitLines splits text after each newline, so every line but possibly the last
+// keeps its "\n" and joining the lines restores the text exactly.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns an edit script from a to b. Common prefix and suffix are
+// trimmed first, since edits are usually local.
+func diffLines(a, b []string) []lineOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	ops := make([]lineOp, 0, len(a)+len(b)-pre-suf)
+	for _, line := range a[:pre] {
+		ops = append(ops, lineOp{' ', line})
+	}
+	ops = append(ops, myersDiff(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, line := range a[len(a)-suf:] {
+		ops = append(ops, lineOp{' ', line})
+	}
+	return ops
+}
+
+// myersDiff implements the greedy Myers O(ND) algorithm, keeping one V snapshot
+// per edit step for the backtrack.
+func myersDiff(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+
+	for d := 0; d <= offset; d++ {
+		if d > maxDiffEdits {
+			return replaceLines(a, b)
+		}
+
+		// Snapshot the diagonals reachable after step d-1
+		snap := make([]int, 2*d+1)
+		copy(snap, v[offset-d:offset+d+1])
+		trace = append(trace, snap)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrackMyers(a, b, trace)
+			}
+		}
+	}
+
+	return replaceLines(a, b)
+}
+
+// backtrackMyers walks the snapshots from the end point back to the origin.
+func backtrackMyers(a, b []string, trace [][]int) []lineOp {
+	var ops []lineOp
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, lineOp{' ', a[x-1]})
+			x--
+			y--
+		}
+
+		if prevK == k+1 {
+			ops = append(ops, lineOp{'+', b[prevY]})
+		} else {
+			ops = append(ops, lineOp{'-', a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+
+	for x > 0 && y > 0 {
+		ops = append(ops, lineOp{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// replaceLines is the fallback script: delete all of a, insert all of b.
+func replaceLines(a, b []string) []lineOp {
+	ops := make([]lineOp, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, lineOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, lineOp{'+', line})
+	}
+	return ops
+}
+
+// buildHunks groups an edit script into hunks with context lines, merging
+// changes whose context would overlap.
+func buildHunks(ops []lineOp, context int) []DiffHunk {
+	// Line numbers before each op
+	oldPos := make([]int, len(ops)+1)
+	newPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if op.kind != '+' {
+			oldPos[i+1]++
+		}
+		if op.kind != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	var hunks []DiffHunk
+	i := 0
+	for i < len(ops) {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			j := end
+			for j < len(ops) && ops[j].kind == ' ' {
+				j++
+			}
+			if j < len(ops) && j-end <= 2*context {
+				end = j
+				continue
+			}
+			break
+		}
+		stop := min(end+context, len(ops))
+
+		h := DiffHunk{
+			OldStart: oldPos[start] + 1,
+			NewStart: newPos[start] + 1,
+			OldLines: oldPos[stop] - oldPos[start],
+			NewLines: newPos[stop] - newPos[start],
+		}
+		// An empty range starts at the line before it, as diff(1) prints it
+		if h.OldLines == 0 {
+			h.OldStart--
+		}
+		if h.NewLines == 0 {
+			h.NewStart--
+		}
+		for _, op := range ops[start:stop] {
+			h.Lines = append(h.Lines, string(op.kind)+strings.TrimSuffix(op.line, "\n"))
+			if !strings.HasSuffix(op.line, "\n") {
+				h.Lines = append(h.Lines, noNewlineMarker)
+			}
+		}
+
+		hunks = append(hunks, h)
+		i = stop
+	}
+
+	return hunks
+}
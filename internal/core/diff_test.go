@@ -0,0 +1,100 @@
+package core
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestDiffText_SingleChange(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	newText := "a\nb\nc\nd\nE\nf\ng\nh\n"
+
+	hunks := DiffText(oldText, newText, 2)
+	if len(hunks) != 1 {
+		t.Fatalf("expected 1 hunk, got %d", len(hunks))
+	}
+
+	h := hunks[0]
+	if h.Header() != "@@ -3,5 +3,5 @@" {
+		t.Errorf("unexpected header %q", h.Header())
+	}
+
+	want := []string{" c", " d", "-e", "+E", " f", " g"}
+	if strings.Join(h.Lines, "|") != strings.Join(want, "|") {
+		t.Errorf("expected lines %v, got %v", want, h.Lines)
+	}
+}
+
+func TestDiffText_SeparateAndMergedHunks(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, "line")
+	}
+	oldText := strings.Join(lines, "\n") + "\n"
+
+	changed := append([]string(nil), lines...)
+	changed[1] = "first"
+	changed[15] = "second"
+	newText := strings.Join(changed, "\n") + "\n"
+
+	if hunks := DiffText(oldText, newText, 3); len(hunks) != 2 {
+		t.Errorf("expected 2 hunks with 3 context lines, got %d", len(hunks))
+	}
+	if hunks := DiffText(oldText, newText, 10); len(hunks) != 1 {
+		t.Errorf("expected 1 merged hunk with 10 context lines, got %d", len(hunks))
+	}
+	if hunks := DiffText(oldText, oldText, 3); len(hunks) != 0 {
+		t.Errorf("expected no hunks for identical text, got %d", len(hunks))
+	}
+}
+
+func TestDiffText_NoNewlineAtEnd(t *testing.T) {
+	hunks := DiffText("a\nb", "a\nb\n", 3)
+	if len(hunks) != 1 {
+		t.Fatalf("expected 1 hunk, got %d", len(hunks))
+	}
+
+	want := []string{" a", "-b", noNewlineMarker, "+b"}
+	if strings.Join(hunks[0].Lines, "|") != strings.Join(want, "|") {
+		t.Errorf("expected lines %v, got %v", want, hunks[0].Lines)
+	}
+}
+
+func TestDiffText_RoundTripsThroughPatch(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	words := []string{"alpha", "beta", "gamma", "delta", ""}
+
+	randomText := func() string {
+		n := rng.IntN(30)
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteString(words[rng.IntN(len(words))])
+			if i < n-1 || rng.IntN(4) != 0 {
+				b.WriteByte('\n')
+			}
+		}
+		return b.String()
+	}
+
+	for i := 0; i < 500; i++ {
+		oldText, newText := randomText(), randomText()
+		context := rng.IntN(4)
+
+		patch := FormatUnifiedDiff("a/file", "b/file", DiffText(oldText, newText, context))
+		if patch == "" {
+			if oldText != newText {
+				t.Fatalf("empty diff for different texts %q and %q", oldText, newText)
+			}
+			continue
+		}
+
+		got, _, err := applyPatch(oldText, patch)
+		if err != nil {
+			t.Fatalf("applying generated patch failed: %v\nold=%q\nnew=%q\npatch:\n%s", err, oldText, newText, patch)
+		}
+		if got != newText {
+			t.Fatalf("round trip mismatch\nold=%q\nnew=%q\ngot=%q\npatch:\n%s", oldText, newText, got, patch)
+		}
+	}
+}
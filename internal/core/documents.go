@@ -0,0 +1,505 @@
+package core
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDocumentPartBytes caps the uncompressed size of one XML part read from an
+// Office document, so a nested archive cannot inflate without bound.
+const maxDocumentPartBytes = 64 * 1024 * 1024
+
+// Office document formats searched by grep in documents mode.
+const (
+	documentXLSX = "xlsx"
+	documentDOCX = "docx"
+	documentPPTX = "pptx"
+	documentODS  = "ods"
+	documentODT  = "odt"
+	documentODP  = "odp"
+)
+
+// documentKind returns the document format for a file name, or "" for other files.
+// Macro-enabled and template variants share the layout of their base format.
+func documentKind(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".xlsx", ".xlsm", ".xltx", ".xltm":
+		return documentXLSX
+	case ".docx", ".docm", ".dotx", ".dotm":
+		return documentDOCX
+	case ".pptx", ".pptm", ".potx", ".potm":
+		return documentPPTX
+	case ".ods", ".ots":
+		return documentODS
+	case ".odt", ".ott":
+		return documentODT
+	case ".odp", ".otp":
+		return documentODP
+	}
+	return ""
+}
+
+// documentText receives one unit of text (a cell or a paragraph) with its
+// location inside the document. Returning false stops the extraction.
+type documentText func(location, text string) bool
+
+// grepDocument searches the text of an Office document. Each cell or paragraph
+// is matched on its own and reported with a location such as Sheet1!B7,
+// paragraph 12 or slide 3.
+func grepDocument(scan *grepScan, root *os.Root, name, relPath, kind string, re *regexp.Regexp, opts GrepOptions, maxMatches int) ([]GrepMatch, int, error) {
+	file, err := root.Open(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	zr, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	doc := &documentReader{zr: zr, scan: scan}
+	filesMode := opts.FilesWithMatches || opts.Count
+
+	var matches []GrepMatch
+	count := 0
+
+	emit := func(location, text string) bool {
+		if scan.stopped() {
+			return false
+		}
+
+		loc := re.FindStringIndex(text)
+		if loc == nil {
+			return true
+		}
+
+		count++
+		if opts.FilesWithMatches {
+			return false
+		}
+		if !filesMode {
+			content, truncated := grepExcerpt([]byte(text), loc[0])
+			matches = append(matches, GrepMatch{
+				File:          relPath,
+				Location:      relPath + "!" + location,
+				LineContent:   content,
+				Column:        loc[0] + 1,
+				LineTruncated: truncated,
+			})
+			if maxMatches > 0 && len(matches) >= maxMatches {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch kind {
+	case documentXLSX:
+		err = doc.xlsxText(emit)
+	case documentDOCX:
+		err = doc.docxText(emit)
+	case documentPPTX:
+		err = doc.pptxText(emit)
+	default:
+		err = doc.odfText(kind, emit)
+	}
+	if err != nil && err != errDocumentStop {
+		return nil, 0, err
+	}
+
+	return matches, count, nil
+}
+
+// errDocumentStop ends an extraction early once the callback is done.
+var errDocumentStop = fmt.Errorf("document search stopped")
+
+// documentReader reads XML parts from an Office document, counting the
+// uncompressed bytes against the grep budget.
+type documentReader struct {
+	zr   *zip.Reader
+	scan *grepScan
+}
+
+// open returns a decoder for a part, or nil when the part does not exist.
+func (d *documentReader) open(name string) (*xml.Decoder, io.Closer, error) {
+	for _, f := range d.zr.File {
+		if f.Name != name {
+			continue
+		}
+		if f.UncompressedSize64 > maxDocumentPartBytes {
+			return nil, nil, fmt.Errorf("document part %s is too large", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open document part %s: %w", name, err)
+		}
+		counted := &countingReader{r: io.LimitReader(rc, maxDocumentPartBytes), scan: d.scan}
+		return xml.NewDecoder(counted), rc, nil
+	}
+	return nil, nil, nil
+}
+
+// walk streams the tokens of a part to fn. A missing part is skipped.
+func (d *documentReader) walk(name string, fn func(xml.Token) error) error {
+	dec, closer, err := d.open(name)
+	if err != nil || dec == nil {
+		return err
+	}
+	defer closer.Close()
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse document part %s: %w", name, err)
+		}
+		if err := fn(tok); err != nil {
+			return err
+		}
+	}
+}
+
+// relationships maps relationship IDs to part names for the given rels part.
+// Targets are resolved against dir unless they are absolute.
+func (d *documentReader) relationships(relsName, dir string) (map[string]string, error) {
+	rels := make(map[string]string)
+	err := d.walk(relsName, func(tok xml.Token) error {
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "Relationship" {
+			target := xmlAttr(se, "Target")
+			if strings.HasPrefix(target, "/") {
+				target = strings.TrimPrefix(target, "/")
+			} else {
+				target = path.Join(dir, target)
+			}
+			rels[xmlAttr(se, "Id")] = target
+		}
+		return nil
+	})
+	return rels, err
+}
+
+// orderedParts lists the parts referenced by the r:id of elements named local
+// in a main part, in document order, alongside their name attribute.
+func (d *documentReader) orderedParts(mainName, relsName, dir, local string) ([][2]string, error) {
+	rels, err := d.relationships(relsName, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts [][2]string
+	err = d.walk(mainName, func(tok xml.Token) error {
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == local {
+			if target, ok := rels[relationshipID(se)]; ok {
+				parts = append(parts, [2]string{target, xmlAttr(se, "name")})
+			}
+		}
+		return nil
+	})
+	return parts, err
+}
+
+// xlsxText emits the text of every non-empty cell, located as Sheet!A1.
+func (d *documentReader) xlsxText(emit documentText) error {
+	var shared []string
+	var text strings.Builder
+	inText := false
+	err := d.walk("xl/sharedStrings.xml", func(tok xml.Token) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				text.Reset()
+			case "t":
+				inText = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				shared = append(shared, text.String())
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sheets, err := d.orderedParts("xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl", "sheet")
+	if err != nil {
+		return err
+	}
+
+	for _, sheet := range sheets {
+		var ref, cellType string
+		var value strings.Builder
+		inValue := false
+
+		err := d.walk(sheet[0], func(tok xml.Token) error {
+			switch t := tok.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "c":
+					ref, cellType = xmlAttr(t, "r"), xmlAttr(t, "t")
+					value.Reset()
+				case "v", "t":
+					inValue = true
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "v", "t":
+					inValue = false
+				case "c":
+					cell := value.String()
+					if cellType == "s" {
+						i, err := strconv.Atoi(strings.TrimSpace(cell))
+						if err != nil || i < 0 || i >= len(shared) {
+							return nil
+						}
+						cell = shared[i]
+					}
+					if cell != "" && !emit(sheet[1]+"!"+ref, cell) {
+						return errDocumentStop
+					}
+				}
+			case xml.CharData:
+				if inValue {
+					value.Write(t)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// docxText emits the body paragraphs, located as paragraph N.
+func (d *documentReader) docxText(emit documentText) error {
+	n := 0
+	return d.walk("word/document.xml", paragraphTokens("t", func(text string) bool {
+		n++
+		return text == "" || emit(fmt.Sprintf("paragraph %d", n), text)
+	}))
+}
+
+// pptxText emits the paragraphs of every slide in presentation order, located as slide N.
+func (d *documentReader) pptxText(emit documentText) error {
+	slides, err := d.orderedParts("ppt/presentation.xml", "ppt/_rels/presentation.xml.rels", "ppt", "sldId")
+	if err != nil {
+		return err
+	}
+
+	for i, slide := range slides {
+		location := fmt.Sprintf("slide %d", i+1)
+		err := d.walk(slide[0], paragraphTokens("t", func(text string) bool {
+			return text == "" || emit(location, text)
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// odfText emits text from an OpenDocument content.xml: cells as Sheet!A1 for
+// spreadsheets, slide N for presentations and paragraph N for text documents.
+func (d *documentReader) odfText(kind string, emit documentText) error {
+	switch kind {
+	case documentODS:
+		return d.odsText(emit)
+	case documentODP:
+		slide := 0
+		paragraphs := paragraphTokens("", func(text string) bool {
+			return text == "" || emit(fmt.Sprintf("slide %d", slide), text)
+		})
+		return d.walk("content.xml", func(tok xml.Token) error {
+			if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "page" {
+				slide++
+			}
+			return paragraphs(tok)
+		})
+	default:
+		n := 0
+		return d.walk("content.xml", paragraphTokens("", func(text string) bool {
+			n++
+			return text == "" || emit(fmt.Sprintf("paragraph %d", n), text)
+		}))
+	}
+}
+
+// odsText emits spreadsheet cells, expanding repeated rows and columns only as
+// far as needed to compute the location of cells with text.
+func (d *documentReader) odsText(emit documentText) error {
+	var sheet string
+	row, col, rowRepeat := 0, 0, 1
+	var cell strings.Builder
+	inCell, cellRepeat := false, 1
+
+	paragraphs := paragraphTokens("", func(text string) bool {
+		if inCell {
+			if cell.Len() > 0 {
+				cell.WriteString("\n")
+			}
+			cell.WriteString(text)
+		}
+		return true
+	})
+
+	return d.walk("content.xml", func(tok xml.Token) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "table":
+				sheet, row = xmlAttr(t, "name"), 0
+			case "table-row":
+				row++
+				col = 0
+				rowRepeat = 1
+				if n, err := strconv.Atoi(xmlAttr(t, "number-rows-repeated")); err == nil && n > 1 {
+					rowRepeat = n
+				}
+			case "table-cell", "covered-table-cell":
+				col++
+				inCell = true
+				cell.Reset()
+				cellRepeat = 1
+				if n, err := strconv.Atoi(xmlAttr(t, "number-columns-repeated")); err == nil && n > 1 {
+					cellRepeat = n
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "table-row":
+				// Cells of a repeated row are reported at its first row
+				row += rowRepeat - 1
+			case "table-cell", "covered-table-cell":
+				inCell = false
+				if text := cell.String(); text != "" {
+					for i := 0; i < cellRepeat; i++ {
+						if !emit(fmt.Sprintf("%s!%s%d", sheet, columnName(col+i), row), text) {
+							return errDocumentStop
+						}
+					}
+				}
+				col += cellRepeat - 1
+			}
+		}
+
+		return paragraphs(tok)
+	})
+}
+
+// paragraphTokens returns a token handler that collects the text of each
+// paragraph (elements named p or h) and passes it to fn when the paragraph
+// closes. When textLocal is set, only character data inside elements with
+// that name counts, as in OOXML's <w:t> and <a:t>; otherwise all of it does.
+func paragraphTokens(textLocal string, fn func(text string) bool) func(xml.Token) error {
+	var stack []*strings.Builder
+	inText := 0
+
+	return func(tok xml.Token) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p", "h":
+				stack = append(stack, &strings.Builder{})
+			case "tab":
+				if len(stack) > 0 {
+					stack[len(stack)-1].WriteString("\t")
+				}
+			case "s", "br", "line-break":
+				if len(stack) > 0 {
+					stack[len(stack)-1].WriteString(" ")
+				}
+			}
+			if textLocal != "" && t.Name.Local == textLocal {
+				inText++
+			}
+		case xml.EndElement:
+			if textLocal != "" && t.Name.Local == textLocal {
+				inText--
+			}
+			if (t.Name.Local == "p" || t.Name.Local == "h") && len(stack) > 0 {
+				text := stack[len(stack)-1].String()
+				stack = stack[:len(stack)-1]
+				if !fn(text) {
+					return errDocumentStop
+				}
+			}
+		case xml.CharData:
+			if len(stack) > 0 && (textLocal == "" || inText > 0) {
+				stack[len(stack)-1].Write(t)
+			}
+		}
+		return nil
+	}
+}
+
+// xmlAttr returns the value of the attribute with the given local name.
+func xmlAttr(se xml.StartElement, local string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// relationshipID returns the r:id attribute of an element. PresentationML's
+// sldId also has a plain id attribute, so the namespace has to be set.
+func relationshipID(se xml.StartElement) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == "id" && a.Name.Space != "" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// columnName converts a 1-based column number to spreadsheet letters (1 is A, 27 is AA).
+func columnName(n int) string {
+	var name []byte
+	for n > 0 {
+		n--
+		name = append([]byte{byte('A' + n%26)}, name...)
+		n /= 26
+	}
+	return string(name)
+}
+
+// countingReader adds the bytes it reads to the grep scan budget.
+type countingReader struct {
+	r    io.Reader
+	scan *grepScan
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.scan.bytesScanned += uint64(n)
+	return n, err
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	testRelsNS = `xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+	testODFNS  = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+		`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+		`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+		`xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"`
+)
+
+func createTestDocuments(t *testing.T, contentsDir string) {
+	t.Helper()
+
+	createTestZip(t, filepath.Join(contentsDir, "report.xlsx"), map[string]string{
+		"xl/workbook.xml": `<workbook ` + testRelsNS + `><sheets>` +
+			`<sheet name="Summary" sheetId="2" r:id="rId2"/>` +
+			`<sheet name="Data" sheetId="1" r:id="rId1"/>` +
+			`</sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/>` +
+			`</Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>Revenue</t></si><si><r><t>EBIT</t></r><r><t>DA</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="7">` +
+			`<c r="A7" t="s"><v>0</v></c><c r="B7" t="s"><v>1</v></c><c r="C7"><f>SUM(X1)</f><v>1234</v></c>` +
+			`</row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData><row r="1">` +
+			`<c r="A1" t="inlineStr"><is><t>EBITDA margin</t></is></c>` +
+			`</row></sheetData></worksheet>`,
+	})
+
+	createTestZip(t, filepath.Join(contentsDir, "notes.docx"), map[string]string{
+		"word/document.xml": `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+			`<w:p><w:r><w:t>Intro</w:t></w:r></w:p>` +
+			`<w:p/>` +
+			`<w:p><w:r><w:t>Our EB</w:t></w:r><w:r><w:instrText>HIDDEN</w:instrText><w:t>ITDA grew</w:t></w:r></w:p>` +
+			`</w:body></w:document>`,
+	})
+
+	createTestZip(t, filepath.Join(contentsDir, "deck.pptx"), map[string]string{
+		"ppt/presentation.xml": `<p:presentation ` + testRelsNS + ` xmlns:p="urn:p"><p:sldIdLst>` +
+			`<p:sldId id="256" r:id="rId3"/><p:sldId id="257" r:id="rId2"/>` +
+			`</p:sldIdLst></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": `<Relationships>` +
+			`<Relationship Id="rId2" Target="slides/slide1.xml"/>` +
+			`<Relationship Id="rId3" Target="slides/slide2.xml"/>` +
+			`</Relationships>`,
+		"ppt/slides/slide1.xml": `<p:sld xmlns:p="urn:p" xmlns:a="urn:a"><a:p><a:r><a:t>EBITDA bridge</a:t></a:r></a:p></p:sld>`,
+		"ppt/slides/slide2.xml": `<p:sld xmlns:p="urn:p" xmlns:a="urn:a"><a:p><a:r><a:t>Title</a:t></a:r></a:p></p:sld>`,
+	})
+
+	createTestZip(t, filepath.Join(contentsDir, "budget.ods"), map[string]string{
+		"content.xml": `<office:document-content ` + testODFNS + `><office:body><office:spreadsheet>` +
+			`<table:table table:name="Plan">` +
+			`<table:table-row table:number-rows-repeated="2"><table:table-cell/></table:table-row>` +
+			`<table:table-row><table:table-cell table:number-columns-repeated="2"/>` +
+			`<table:table-cell><text:p>EBITDA</text:p></table:table-cell></table:table-row>` +
+			`</table:table></office:spreadsheet></office:body></office:document-content>`,
+	})
+
+	createTestZip(t, filepath.Join(contentsDir, "memo.odt"), map[string]string{
+		"content.xml": `<office:document-content ` + testODFNS + `><office:body><office:text>` +
+			`<text:h>Heading</text:h><text:p>Adjusted <text:span>EBITDA</text:span></text:p>` +
+			`</office:text></office:body></office:document-content>`,
+	})
+}
+
+func TestGrepFiles_Documents(t *testing.T) {
+	contentsDir := t.TempDir()
+	createTestDocuments(t, contentsDir)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "EBITDA", GrepOptions{Documents: true})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+
+	var locations []string
+	for _, m := range result.Matches {
+		locations = append(locations, m.Location+"="+m.LineContent)
+	}
+
+	want := []string{
+		"budget.ods!Plan!C3=EBITDA",
+		"deck.pptx!slide 2=EBITDA bridge",
+		"memo.odt!paragraph 2=Adjusted EBITDA",
+		"notes.docx!paragraph 3=Our EBITDA grew",
+		"report.xlsx!Summary!A1=EBITDA margin",
+		"report.xlsx!Data!B7=EBITDA",
+	}
+	if !reflect.DeepEqual(locations, want) {
+		t.Errorf("unexpected matches:\ngot  %v\nwant %v", locations, want)
+	}
+
+	// Numeric cell values are searched too
+	result, err = GrepFiles(context.Background(), contentsDir, ".", "^1234$", GrepOptions{Documents: true, Count: true})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if !reflect.DeepEqual(result.Files, []GrepFileCount{{File: "report.xlsx", Count: 1}}) {
+		t.Errorf("expected numeric cell match, got %+v", result.Files)
+	}
+
+	// Without documents mode the archives are skipped as binary
+	result, err = GrepFiles(context.Background(), contentsDir, ".", "EBITDA", GrepOptions{})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 0 || result.SkippedBinary != 5 {
+		t.Errorf("expected documents skipped as binary, got %+v", result)
+	}
+}
+
+func TestGrepFiles_DocumentsInvalid(t *testing.T) {
+	contentsDir := t.TempDir()
+	os.WriteFile(filepath.Join(contentsDir, "broken.docx"), []byte("not a zip"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "plain.txt"), []byte("EBITDA\n"), 0644)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "EBITDA", GrepOptions{Documents: true})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 1 || result.Matches[0].File != "plain.txt" || result.Matches[0].Location != "" {
+		t.Errorf("expected only the text file to match, got %+v", result.Matches)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for n, want := range map[int]string{1: "A", 26: "Z", 27: "AA", 52: "AZ", 703: "AAA"} {
+		if got := columnName(n); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// DefaultEditContext is the number of context lines around returned edit hunks.
+const DefaultEditContext = 3
+
+// EditBlock replaces an exact run of text. Search must match exactly once unless
+// ReplaceAll is set, so an ambiguous edit never lands in the wrong place.
+type EditBlock struct {
+	Search     string `json:"search"`
+	Replace    string `json:"replace"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// EditOptions describes one edit: either search/replace blocks or a unified diff.
+type EditOptions struct {
+	Blocks  []EditBlock
+	Patch   string
+	Context int // context lines around returned hunks; negative means DefaultEditContext
+}
+
+// EditResult reports what an edit changed. Hunks cover only the changed lines.
+type EditResult struct {
+	Path         string     `json:"path"`
+	Replacements int        `json:"replacements"`
+	BytesBefore  int        `json:"bytes_before"`
+	BytesAfter   int        `json:"bytes_after"`
+	Hunks        []DiffHunk `json:"hunks"`
+}
+
+// Diff renders the changed hunks as a unified diff.
+func (r *EditResult) Diff() string {
+	return FormatUnifiedDiff("a/"+r.Path, "b/"+r.Path, r.Hunks)
+}
+
+// EditFile applies search/replace blocks or a unified diff to a workspace file.
+// All blocks or hunks are applied in memory first; if any fails to match, an
+// EDIT_MISMATCH error describes it and the file is left untouched. Otherwise the
+// result replaces the file atomically. cfg enforces MaxTotalDiskBytes and may be
+// nil to skip the check.
+func EditFile(contentsDir, relativePath string, opts EditOptions, cfg *Config) (*EditResult, error) {
+	if (len(opts.Blocks) == 0) == (opts.Patch == "") {
+		return nil, fmt.Errorf("provide either search/replace blocks or a patch")
+	}
+
+	// Validate relative path
+	if err := security.ValidateRelativePath(relativePath); err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	// Validate the resolved path is within contents directory
+	if err := security.ValidatePath(contentsDir, relativePath); err != nil {
+		return nil, errors.PathTraversal(relativePath)
+	}
+
+	// An edit reads the committed file, so it cannot be staged
+	if err := EnsureNoTx(contentsDir); err != nil {
+		return nil, err
+	}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	name := rootName(relativePath)
+
+	// Only regular files are edited; replacing a symlink would turn it into a file
+	info, err := root.Lstat(name)
+	if err != nil {
+		return nil, rootedError(err, relativePath, "stat file")
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("path is not a regular file: %s", relativePath)
+	}
+
+	data, err := root.ReadFile(name)
+	if err != nil {
+		return nil, rootedError(err, relativePath, "read file")
+	}
+	before := string(data)
+
+	var after string
+	var replacements int
+	if opts.Patch != "" {
+		after, replacements, err = applyPatch(before, opts.Patch)
+	} else {
+		after, replacements, err = applyBlocks(before, opts.Blocks)
+	}
+	if err != nil {
+		return nil, errors.EditMismatch(relativePath, err.Error())
+	}
+
+	context := opts.Context
+	if context < 0 {
+		context = DefaultEditContext
+	}
+
+	result := &EditResult{
+		Path:         relativePath,
+		Replacements: replacements,
+		BytesBefore:  len(before),
+		BytesAfter:   len(after),
+		Hunks:        DiffText(before, after, context),
+	}
+
+	if after == before {
+		return result, nil
+	}
+
+	// Refuse edits that would push all workspaces past MaxTotalDiskBytes
+	if cfg != nil && len(after) > len(before) {
+		if err := EnsureDiskBudget(cfg, uint64(len(after)-len(before))); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeRootedAtomic(root, name, []byte(after), info.Mode().Perm()); err != nil {
+		return nil, rootedError(err, relativePath, "write file")
+	}
+
+	markSearchIndexStale(contentsDir, name)
+
+	return result, nil
+}
+
+// applyBlocks applies search/replace blocks in order to text.
+func applyBlocks(text string, blocks []EditBlock) (string, int, error) {
+	replacements := 0
+
+	for i, block := range blocks {
+		if block.Search == "" {
+			return "", 0, fmt.Errorf("search block %d is empty", i+1)
+		}
+
+		count := strings.Count(text, block.Search)
+		switch {
+		case count == 0:
+			return "", 0, fmt.Errorf("search block %d not found%s", i+1, nearMissHint(text, block.Search))
+		case count > 1 && !block.ReplaceAll:
+			return "", 0, fmt.Errorf("search block %d matches %d times (at lines %s); add surrounding lines to make it unique or set replace_all",
+				i+1, count, formatLineNumbers(matchLines(text, block.Search, 5)))
+		}
+
+		if block.ReplaceAll {
+			text = strings.ReplaceAll(text, block.Search, block.Replace)
+		} else {
+			text = strings.Replace(text, block.Search, block.Replace, 1)
+		}
+		replacements += count
+	}
+
+	return text, replacements, nil
+}
+
+// nearMissHint points at where the first line of a failed search does occur,
+// which usually means the following lines differ (whitespace, stale content).
+func nearMissHint(text, search string) string {
+	first := strings.TrimSpace(strings.SplitN(search, "\n", 2)[0])
+	if first == "" {
+		return ""
+	}
+
+	for i, line := range splitLines(text) {
+		if strings.Contains(line, first) {
+			return fmt.Sprintf(" (its first line appears at line %d, but the rest differs)", i+1)
+		}
+	}
+	return ""
+}
+
+// matchLines returns the 1-based line numbers of up to limit occurrences of search.
+func matchLines(text, search string, limit int) []int {
+	var lines []int
+	pos := 0
+	for len(lines) < limit {
+		idx := strings.Index(text[pos:], search)
+		if idx < 0 {
+			break
+		}
+		lines = append(lines, strings.Count(text[:pos+idx], "\n")+1)
+		pos += idx + len(search)
+	}
+	return lines
+}
+
+func formatLineNumbers(lines []int) string {
+	parts := make([]string, len(lines))
+	for i, n := range lines {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// patchHunk is one parsed hunk of a unified diff. Lines keep their newline,
+// except where a "\ No newline at end of file" marker removed it.
+type patchHunk struct {
+	header   string
+	oldStart int
+	old      []string
+	new      []string
+}
+
+var hunkHeaderRegexp = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parsePatch parses a single-file unified diff. File headers and any text
+// before the first hunk are ignored.
+func parsePatch(patch string) ([]patchHunk, error) {
+	lines := strings.Split(strings.TrimSuffix(patch, "\n"), "\n")
+
+	var hunks []patchHunk
+	files := 0
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		if strings.HasPrefix(line, "+++ ") {
+			files++
+			if files > 1 {
+				return nil, fmt.Errorf("patch touches more than one file")
+			}
+		}
+
+		m := hunkHeaderRegexp.FindStringSubmatch(line)
+		if m == nil {
+			i++
+			continue
+		}
+
+		oldStart, _ := strconv.Atoi(m[1])
+		oldCount := hunkCount(m[2])
+		newCount := hunkCount(m[4])
+
+		h := patchHunk{header: line, oldStart: oldStart}
+		i++
+
+		// Read exactly as many lines as the header announces
+		var last *[]string
+		for (len(h.old) < oldCount || len(h.new) < newCount) && i < len(lines) {
+			body := lines[i]
+			i++
+
+			if body == "" {
+				// Editors often strip the single space of an empty context line
+				body = " "
+			}
+
+			switch body[0] {
+			case ' ':
+				h.old = append(h.old, body[1:]+"\n")
+				h.new = append(h.new, body[1:]+"\n")
+				last = nil
+			case '-':
+				h.old = append(h.old, body[1:]+"\n")
+				last = &h.old
+			case '+':
+				h.new = append(h.new, body[1:]+"\n")
+				last = &h.new
+			case '\\':
+				stripLastNewline(last, &h)
+			default:
+				return nil, fmt.Errorf("malformed line in hunk %q: %q", h.header, body)
+			}
+		}
+
+		if len(h.old) != oldCount || len(h.new) != newCount {
+			return nil, fmt.Errorf("hunk %q is truncated", h.header)
+		}
+
+		// A trailing marker strips the newline from the line before it
+		if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+			stripLastNewline(last, &h)
+			i++
+		}
+
+		hunks = append(hunks, h)
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("patch contains no hunks")
+	}
+	return hunks, nil
+}
+
+// stripLastNewline applies a "\ No newline at end of file" marker. After a
+// context line both sides lose the newline.
+func stripLastNewline(last *[]string, h *patchHunk) {
+	trim := func(s []string) {
+		if len(s) > 0 {
+			s[len(s)-1] = strings.TrimSuffix(s[len(s)-1], "\n")
+		}
+	}
+	if last == nil {
+		trim(h.old)
+		trim(h.new)
+		return
+	}
+	trim(*last)
+}
+
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// applyPatch applies a unified diff to text. Each hunk must match exactly; a hunk
+// whose position drifted is found by searching for its old lines after the
+// previous hunk, nearest to the stated line first.
+func applyPatch(text, patch string) (string, int, error) {
+	hunks, err := parsePatch(patch)
+	if err != nil {
+		return "", 0, err
+	}
+
+	lines := splitLines(text)
+	shift := 0
+	floor := 0
+
+	for i, h := range hunks {
+		// A hunk without old lines inserts after line oldStart
+		base := h.oldStart - 1
+		if len(h.old) == 0 {
+			base = h.oldStart
+		}
+		want := base + shift
+
+		pos := findHunk(lines, h.old, want, floor)
+		if pos < 0 {
+			return "", 0, fmt.Errorf("hunk %d (%s) does not match%s", i+1, h.header, hunkMismatch(lines, h.old, want))
+		}
+
+		updated := make([]string, 0, len(lines)-len(h.old)+len(h.new))
+		updated = append(updated, lines[:pos]...)
+		updated = append(updated, h.new...)
+		updated = append(updated, lines[pos+len(h.old):]...)
+		lines = updated
+
+		shift = pos - base + len(h.new) - len(h.old)
+		floor = pos + len(h.new)
+	}
+
+	return strings.Join(lines, ""), len(hunks), nil
+}
+
+// findHunk returns where old occurs in lines at or after floor, preferring the
+// position closest to want, or -1.
+func findHunk(lines, old []string, want, floor int) int {
+	want = min(max(want, floor), len(lines))
+	if len(old) == 0 {
+		return want
+	}
+
+	matches := func(pos int) bool {
+		if pos < floor || pos+len(old) > len(lines) {
+			return false
+		}
+		for j, line := range old {
+			if lines[pos+j] != line {
+				return false
+			}
+		}
+		return true
+	}
+
+	for delta := 0; want-delta >= floor || want+delta <= len(lines); delta++ {
+		if matches(want - delta) {
+			return want - delta
+		}
+		if delta > 0 && matches(want+delta) {
+			return want + delta
+		}
+	}
+	return -1
+}
+
+// hunkMismatch describes the first line where old differs from lines at pos.
+func hunkMismatch(lines, old []string, pos int) string {
+	pos = max(pos, 0)
+	for j, expected := range old {
+		if pos+j >= len(lines) {
+			return fmt.Sprintf(": expected %q at line %d, found end of file", expected, pos+j+1)
+		}
+		if lines[pos+j] != expected {
+			return fmt.Sprintf(": expected %q at line %d, found %q", expected, pos+j+1, lines[pos+j])
+		}
+	}
+	return ""
+}
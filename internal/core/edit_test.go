@@ -0,0 +1,179 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+)
+
+const editSample = `<sheet>
+  <row r="1"><c>Name</c></row>
+  <row r="2"><c>Alice</c></row>
+  <row r="3"><c>Bob</c></row>
+  <row r="4"><c>Alice</c></row>
+</sheet>
+`
+
+// setupEditFile writes editSample into a fresh contents directory.
+func setupEditFile(t *testing.T) string {
+	t.Helper()
+
+	contentsDir := filepath.Join(t.TempDir(), "contents")
+	os.MkdirAll(contentsDir, 0755)
+	os.WriteFile(filepath.Join(contentsDir, "sheet.xml"), []byte(editSample), 0640)
+
+	return contentsDir
+}
+
+func TestEditFile_SearchReplace(t *testing.T) {
+	contentsDir := setupEditFile(t)
+
+	result, err := EditFile(contentsDir, "sheet.xml", EditOptions{
+		Blocks:  []EditBlock{{Search: "<c>Bob</c>", Replace: "<c>Robert</c>"}},
+		Context: 1,
+	}, nil)
+	if err != nil {
+		t.Fatalf("EditFile failed: %v", err)
+	}
+
+	if result.Replacements != 1 {
+		t.Errorf("expected 1 replacement, got %d", result.Replacements)
+	}
+
+	diff := result.Diff()
+	if !strings.Contains(diff, `-  <row r="3"><c>Bob</c></row>`) || !strings.Contains(diff, `+  <row r="3"><c>Robert</c></row>`) {
+		t.Errorf("expected changed line in diff, got:\n%s", diff)
+	}
+	if strings.Contains(diff, "Name") {
+		t.Errorf("expected only nearby context in diff, got:\n%s", diff)
+	}
+
+	info, err := os.Stat(filepath.Join(contentsDir, "sheet.xml"))
+	if err != nil {
+		t.Fatalf("failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected mode 0640 to be kept, got %v", info.Mode().Perm())
+	}
+
+	data, _ := os.ReadFile(filepath.Join(contentsDir, "sheet.xml"))
+	if !strings.Contains(string(data), "Robert") {
+		t.Errorf("expected file to be updated, got:\n%s", data)
+	}
+}
+
+func TestEditFile_Mismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    EditOptions
+		wantMsg string
+	}{
+		{
+			name:    "ambiguous search",
+			opts:    EditOptions{Blocks: []EditBlock{{Search: "<c>Alice</c>", Replace: "x"}}},
+			wantMsg: "matches 2 times (at lines 3, 5)",
+		},
+		{
+			name:    "missing search",
+			opts:    EditOptions{Blocks: []EditBlock{{Search: "<c>Carol</c>", Replace: "x"}}},
+			wantMsg: "search block 1 not found",
+		},
+		{
+			name: "later block fails",
+			opts: EditOptions{Blocks: []EditBlock{
+				{Search: "<c>Bob</c>", Replace: "<c>Robert</c>"},
+				{Search: "<c>Bob</c>", Replace: "<c>Bobby</c>"},
+			}},
+			wantMsg: "search block 2 not found",
+		},
+		{
+			name:    "patch context differs",
+			opts:    EditOptions{Patch: "@@ -3,1 +3,1 @@\n-  <row r=\"3\"><c>Carol</c></row>\n+  <row r=\"3\"><c>Dave</c></row>\n"},
+			wantMsg: "hunk 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentsDir := setupEditFile(t)
+
+			_, err := EditFile(contentsDir, "sheet.xml", tt.opts, nil)
+			if !errors.Is(err, errors.CodeEditMismatch) {
+				t.Fatalf("expected EDIT_MISMATCH, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("expected error to contain %q, got %v", tt.wantMsg, err)
+			}
+
+			// Nothing was written
+			data, _ := os.ReadFile(filepath.Join(contentsDir, "sheet.xml"))
+			if string(data) != editSample {
+				t.Errorf("expected file to be untouched, got:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestEditFile_ReplaceAll(t *testing.T) {
+	contentsDir := setupEditFile(t)
+
+	result, err := EditFile(contentsDir, "sheet.xml", EditOptions{
+		Blocks: []EditBlock{{Search: "Alice", Replace: "Alicia", ReplaceAll: true}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("EditFile failed: %v", err)
+	}
+	if result.Replacements != 2 {
+		t.Errorf("expected 2 replacements, got %d", result.Replacements)
+	}
+}
+
+func TestEditFile_Patch(t *testing.T) {
+	contentsDir := setupEditFile(t)
+
+	// Line numbers are off by one; the hunk is found by its context
+	patch := `--- a/sheet.xml
++++ b/sheet.xml
+@@ -3,3 +3,4 @@
+   <row r="3"><c>Bob</c></row>
+   <row r="4"><c>Alice</c></row>
++  <row r="5"><c>Carol</c></row>
+ </sheet>
+`
+
+	result, err := EditFile(contentsDir, "sheet.xml", EditOptions{Patch: patch, Context: DefaultEditContext}, nil)
+	if err != nil {
+		t.Fatalf("EditFile failed: %v", err)
+	}
+	if len(result.Hunks) != 1 {
+		t.Errorf("expected 1 hunk, got %d", len(result.Hunks))
+	}
+
+	data, _ := os.ReadFile(filepath.Join(contentsDir, "sheet.xml"))
+	want := strings.Replace(editSample, "</sheet>", "  <row r=\"5\"><c>Carol</c></row>\n</sheet>", 1)
+	if string(data) != want {
+		t.Errorf("unexpected content:\n%s", data)
+	}
+}
+
+func TestEditFile_Errors(t *testing.T) {
+	contentsDir := setupEditFile(t)
+
+	blocks := []EditBlock{{Search: "a", Replace: "b"}}
+
+	if _, err := EditFile(contentsDir, "missing.xml", EditOptions{Blocks: blocks}, nil); !errors.Is(err, errors.CodePathNotFound) {
+		t.Errorf("expected PATH_NOT_FOUND, got %v", err)
+	}
+	if _, err := EditFile(contentsDir, "../sheet.xml", EditOptions{Blocks: blocks}, nil); err == nil {
+		t.Error("expected traversal to fail")
+	}
+	if _, err := EditFile(contentsDir, "sheet.xml", EditOptions{}, nil); err == nil {
+		t.Error("expected error without blocks or patch")
+	}
+	if _, err := EditFile(contentsDir, "sheet.xml", EditOptions{Blocks: blocks, Patch: "@@ -1 +1 @@\n-a\n+b\n"}, nil); err == nil {
+		t.Error("expected error with both blocks and patch")
+	}
+}
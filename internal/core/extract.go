@@ -5,29 +5,55 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/Fuabioo/zipfs/internal/errors"
 	"github.com/Fuabioo/zipfs/internal/security"
 )
 
+// maxSymlinkTargetLen bounds how much of a symlink entry is read as its target.
+const maxSymlinkTargetLen = 4096
+
+// ExtractResult summarizes an extraction.
+type ExtractResult struct {
+	FileCount       int
+	TotalSize       uint64
+	SkippedSymlinks []string // symlink entries left out because AllowSymlinks is false
+}
+
 // Extract extracts a zip file to the destination directory.
 // Returns the number of files extracted and the total size in bytes.
 // Uses fail-closed security validation - any single invalid path aborts the entire extraction.
 func Extract(zipPath, destDir string, limits security.Limits) (int, uint64, error) {
+	result, err := ExtractArchive(zipPath, destDir, limits)
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.FileCount, result.TotalSize, nil
+}
+
+// ExtractArchive extracts a zip file to the destination directory.
+// Symlink entries are recreated as symlinks when limits.AllowSymlinks is set and
+// their targets stay inside destDir; otherwise they are skipped and reported.
+// Uses fail-closed security validation - any single invalid path aborts the entire extraction.
+func ExtractArchive(zipPath, destDir string, limits security.Limits) (*ExtractResult, error) {
 	// Pre-scan for zip bomb
 	bombCheck, err := security.CheckZipBomb(zipPath, limits)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to check for zip bomb: %w", err)
+		return nil, fmt.Errorf("failed to check for zip bomb: %w", err)
 	}
 	if !bombCheck.IsSafe {
-		return 0, 0, errors.ZipBombDetected(bombCheck.Reason)
+		return nil, errors.ZipBombDetected(bombCheck.Reason)
+	}
+	if len(bombCheck.UnsupportedMethods) > 0 {
+		return nil, errors.UnsupportedCompression(zipPath, bombCheck.UnsupportedMethods)
 	}
 
 	// Open the zip file
 	r, err := zip.OpenReader(zipPath)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to open zip file: %w", err)
+		return nil, fmt.Errorf("failed to open zip file: %w", err)
 	}
 	defer r.Close()
 
@@ -37,38 +63,144 @@ func Extract(zipPath, destDir string, limits security.Limits) (int, uint64, erro
 		paths = append(paths, f.Name)
 	}
 	if err := security.ValidateAllPaths(destDir, paths); err != nil {
-		return 0, 0, fmt.Errorf("path validation failed: %w", err)
+		return nil, fmt.Errorf("path validation failed: %w", err)
 	}
 
-	// Extract all files
-	var fileCount int
-	var totalSize uint64
+	// Validate all symlink targets before writing anything (fail-closed)
+	result := &ExtractResult{}
+	symlinkTargets := make(map[string]string)
+	for _, f := range r.File {
+		if f.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		if !limits.AllowSymlinks {
+			result.SkippedSymlinks = append(result.SkippedSymlinks, f.Name)
+			continue
+		}
 
+		target, err := readSymlinkTarget(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read symlink %q: %w", f.Name, err)
+		}
+		if err := security.ValidateSymlinkTarget(destDir, f.Name, target); err != nil {
+			return nil, errors.PathTraversal(f.Name + " -> " + target)
+		}
+		symlinkTargets[rootName(f.Name)] = target
+	}
+
+	// The target check above is lexical, so refuse entries placed beneath an
+	// extracted symlink; following it could lead anywhere
+	if len(symlinkTargets) > 0 {
+		for _, f := range r.File {
+			if link, ok := throughSymlink(rootName(f.Name), symlinkTargets); ok {
+				return nil, errors.PathTraversal(f.Name + " (through symlink " + link + ")")
+			}
+		}
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	// Every write goes through the root, so no entry can follow a symlink out of destDir
+	root, err := openContentsRoot(destDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	// Extract all files
 	for _, f := range r.File {
-		if err := extractFile(f, destDir, &fileCount, &totalSize); err != nil {
-			return fileCount, totalSize, fmt.Errorf("failed to extract %q: %w", f.Name, err)
+		if f.Mode()&os.ModeSymlink != 0 {
+			target, ok := symlinkTargets[rootName(f.Name)]
+			if !ok {
+				continue
+			}
+			if err := extractSymlink(root, f.Name, target); err != nil {
+				return result, fmt.Errorf("failed to extract %q: %w", f.Name, err)
+			}
+			result.FileCount++
+			continue
+		}
+
+		if err := extractFile(root, f, &result.FileCount, &result.TotalSize); err != nil {
+			return result, fmt.Errorf("failed to extract %q: %w", f.Name, err)
 		}
 	}
 
-	return fileCount, totalSize, nil
+	// Links whose targets pass through other links are only known to stay
+	// inside once all of them exist; a dangling target is allowed
+	for name := range symlinkTargets {
+		if _, err := root.Stat(name); err != nil && !os.IsNotExist(err) {
+			return result, errors.PathTraversal(name + " -> " + symlinkTargets[name])
+		}
+	}
+
+	return result, nil
+}
+
+// throughSymlink reports the first symlink among links that is a proper parent
+// of name.
+func throughSymlink(name string, links map[string]string) (string, bool) {
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, ok := links[dir]; ok {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// readSymlinkTarget reads the link target stored as a symlink entry's content.
+func readSymlinkTarget(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxSymlinkTargetLen+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxSymlinkTargetLen {
+		return "", fmt.Errorf("symlink target exceeds %d bytes", maxSymlinkTargetLen)
+	}
+
+	return filepath.FromSlash(string(data)), nil
+}
+
+// extractSymlink creates a symlink whose target has already been validated.
+func extractSymlink(root *os.Root, name, target string) error {
+	name = rootName(name)
+
+	// Ensure parent directory exists
+	if err := root.MkdirAll(path.Dir(name), 0755); err != nil {
+		return rootedError(err, name, "create parent directory")
+	}
+
+	if err := root.Symlink(target, name); err != nil {
+		return rootedError(err, name, "create symlink")
+	}
+
+	return nil
 }
 
 // extractFile extracts a single file from the zip archive.
-func extractFile(f *zip.File, destDir string, fileCount *int, totalSize *uint64) error {
+func extractFile(root *os.Root, f *zip.File, fileCount *int, totalSize *uint64) error {
 	// Construct the destination path
-	destPath := filepath.Join(destDir, f.Name)
+	name := rootName(f.Name)
 
 	// Handle directories
 	if f.FileInfo().IsDir() {
-		if err := os.MkdirAll(destPath, f.Mode()); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
+		if err := root.MkdirAll(name, f.Mode().Perm()); err != nil {
+			return rootedError(err, name, "create directory")
 		}
 		return nil
 	}
 
 	// Ensure parent directory exists
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return fmt.Errorf("failed to create parent directory: %w", err)
+	if err := root.MkdirAll(path.Dir(name), 0755); err != nil {
+		return rootedError(err, name, "create parent directory")
 	}
 
 	// Open the file in the archive
@@ -79,9 +211,9 @@ func extractFile(f *zip.File, destDir string, fileCount *int, totalSize *uint64)
 	defer rc.Close()
 
 	// Create the destination file
-	outFile, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	outFile, err := root.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode().Perm())
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return rootedError(err, name, "create output file")
 	}
 
 	// Copy the data and track size
@@ -98,7 +230,7 @@ func extractFile(f *zip.File, destDir string, fileCount *int, totalSize *uint64)
 
 	// Preserve modification time from the zip entry (guard against zero time)
 	if !f.Modified.IsZero() {
-		if err := os.Chtimes(destPath, f.Modified, f.Modified); err != nil {
+		if err := root.Chtimes(name, f.Modified, f.Modified); err != nil {
 			return fmt.Errorf("failed to set modification time: %w", err)
 		}
 	}
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// Change states accepted by FindOptions.Status.
+const (
+	FindStatusModified  = "modified"
+	FindStatusAdded     = "added"
+	FindStatusRenamed   = "renamed"
+	FindStatusUnchanged = "unchanged"
+)
+
+// FindOptions filters a FindFiles call. Zero values leave a filter off.
+type FindOptions struct {
+	Path           string    // glob matched against the workspace-relative path; see PathGlob
+	Name           string    // regular expression matched against the base name
+	Type           string    // "file", "dir" or "symlink"
+	MinSize        int64     // minimum size in bytes; directories never match a size filter
+	MaxSize        int64     // maximum size in bytes; 0 means no limit
+	ModifiedAfter  time.Time // only entries modified at or after this time
+	ModifiedBefore time.Time // only entries modified before this time
+	Status         []string  // change states compared with original.zip; see FindStatusModified
+	MaxResults     int       // 0 means unlimited
+}
+
+// FindResult lists the entries that passed every filter. Entry names are paths
+// relative to the workspace root. Total counts all matching entries, including
+// those left out by MaxResults.
+type FindResult struct {
+	Entries   []FileEntry `json:"entries"`
+	Total     int         `json:"total"`
+	Truncated bool        `json:"truncated"`
+}
+
+// FindFiles walks a session's workspace from relativePath and returns the
+// entries matching opts, in lexical order. Symlinks are reported, never followed.
+func FindFiles(session *Session, relativePath string, opts FindOptions) (*FindResult, error) {
+	if relativePath == "" {
+		relativePath = "."
+	}
+
+	// Validate relative path
+	if relativePath != "." {
+		if err := security.ValidateRelativePath(relativePath); err != nil {
+			return nil, fmt.Errorf("invalid path: %w", err)
+		}
+	}
+
+	var glob *PathGlob
+	if opts.Path != "" {
+		g, err := CompileGlob(opts.Path)
+		if err != nil {
+			return nil, err
+		}
+		glob = g
+	}
+
+	var nameRe *regexp.Regexp
+	if opts.Name != "" {
+		re, err := regexp.Compile(opts.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name pattern: %w", err)
+		}
+		nameRe = re
+	}
+
+	switch opts.Type {
+	case "", "file", "dir", "symlink":
+	default:
+		return nil, fmt.Errorf("invalid type %q: expected file, dir or symlink", opts.Type)
+	}
+
+	states, err := findStates(session, opts.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contents directory: %w", err)
+	}
+
+	// Validate the resolved path is within contents directory
+	if err := security.ValidatePath(contentsDir, relativePath); err != nil {
+		return nil, errors.PathTraversal(relativePath)
+	}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	targetName := rootName(relativePath)
+	if _, err := root.Lstat(targetName); err != nil {
+		return nil, rootedError(err, relativePath, "stat path")
+	}
+
+	result := &FindResult{Entries: []FileEntry{}}
+
+	// WalkDir never descends into symlinked directories
+	err = fs.WalkDir(root.FS(), targetName, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		entry := newFileEntry(root, name, name, info)
+
+		if opts.Type != "" && entry.Type != opts.Type {
+			return nil
+		}
+		if !glob.Match(name) {
+			return nil
+		}
+		if nameRe != nil && !nameRe.MatchString(path.Base(name)) {
+			return nil
+		}
+		if opts.MinSize > 0 || opts.MaxSize > 0 {
+			size := int64(entry.SizeBytes)
+			if entry.Type == "dir" || size < opts.MinSize || (opts.MaxSize > 0 && size > opts.MaxSize) {
+				return nil
+			}
+		}
+		if !opts.ModifiedAfter.IsZero() && info.ModTime().Before(opts.ModifiedAfter) {
+			return nil
+		}
+		if !opts.ModifiedBefore.IsZero() && !info.ModTime().Before(opts.ModifiedBefore) {
+			return nil
+		}
+		if states != nil && !states.matches(name, entry.Type == "dir") {
+			return nil
+		}
+
+		result.Total++
+		if opts.MaxResults > 0 && len(result.Entries) >= opts.MaxResults {
+			result.Truncated = true
+			return nil
+		}
+		result.Entries = append(result.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	return result, nil
+}
+
+// findStateFilter answers the status filter from one Status call.
+type findStateFilter struct {
+	want      map[string]bool
+	changed   map[string]string // path to modified, added or renamed
+	addedDirs map[string]bool
+}
+
+// findStates runs Status once if a status filter was requested.
+func findStates(session *Session, status []string) (*findStateFilter, error) {
+	if len(status) == 0 {
+		return nil, nil
+	}
+
+	f := &findStateFilter{
+		want:      make(map[string]bool),
+		changed:   make(map[string]string),
+		addedDirs: make(map[string]bool),
+	}
+	for _, s := range status {
+		switch s {
+		case FindStatusModified, FindStatusAdded, FindStatusRenamed, FindStatusUnchanged:
+			f.want[s] = true
+		default:
+			return nil, fmt.Errorf("invalid status %q: expected modified, added, renamed or unchanged", s)
+		}
+	}
+
+	result, err := Status(session)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range result.Modified {
+		f.changed[p] = FindStatusModified
+	}
+	for _, p := range result.Added {
+		f.changed[p] = FindStatusAdded
+	}
+	for _, r := range result.Renamed {
+		f.changed[r.To] = FindStatusRenamed
+	}
+	for _, d := range result.AddedDirs {
+		f.addedDirs[d] = true
+	}
+
+	return f, nil
+}
+
+// matches reports whether an entry is in one of the wanted states. Directories
+// are added or unchanged.
+func (f *findStateFilter) matches(name string, isDir bool) bool {
+	state := FindStatusUnchanged
+	if isDir {
+		if f.addedDirs[name] {
+			state = FindStatusAdded
+		}
+	} else if s, ok := f.changed[name]; ok {
+		state = s
+	}
+	return f.want[state]
+}
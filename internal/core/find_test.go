@@ -0,0 +1,123 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPathGlob_Match(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.xml", "a.xml", true},
+		{"*.xml", "dir/a.xml", true},    // no slash: matches at any depth
+		{"./*.xml", "dir/a.xml", false}, // "./" anchors at the root
+		{"./*.xml", "a.xml", true},
+		{"**/*.xml", "a.xml", true},
+		{"**/*.xml", "x/y/a.xml", true},
+		{"xl/**/*.xml", "xl/worksheets/sheet1.xml", true},
+		{"xl/**/*.xml", "xl/a.xml", true},
+		{"xl/**/*.xml", "ppt/a.xml", false},
+		{"xl/**", "xl/media/image1.png", true},
+		{"a/*/c", "a/b/c", true},
+		{"a/*/c", "a/b/x/c", false},
+	}
+
+	for _, tt := range tests {
+		matcher, err := CompileGlob(tt.pattern)
+		if err != nil {
+			t.Fatalf("CompileGlob(%q) failed: %v", tt.pattern, err)
+		}
+		if got := matcher.Match(tt.name); got != tt.want {
+			t.Errorf("CompileGlob(%q).Match(%q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindFiles(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"xl/workbook.xml":          "<workbook/>",
+		"xl/worksheets/sheet1.xml": strings.Repeat("<row/>", 100) + strings.Repeat("<c>1</c>", 200),
+		"xl/media/image1.png":      "png",
+		"docProps/app.xml":         "<app/>",
+	})
+
+	session, err := CreateSession(zipPath, "find", DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	os.WriteFile(filepath.Join(contentsDir, "xl", "workbook.xml"), []byte("<workbook changed/>"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "xl", "styles.xml"), []byte("<styles/>"), 0644)
+
+	names := func(opts FindOptions, relativePath string) string {
+		t.Helper()
+		result, err := FindFiles(session, relativePath, opts)
+		if err != nil {
+			t.Fatalf("FindFiles(%+v) failed: %v", opts, err)
+		}
+		var out []string
+		for _, e := range result.Entries {
+			out = append(out, e.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		name string
+		opts FindOptions
+		path string
+		want string
+	}{
+		{"glob", FindOptions{Path: "xl/**/*.xml"}, "", "xl/styles.xml,xl/workbook.xml,xl/worksheets/sheet1.xml"},
+		{"name regex", FindOptions{Name: `^image\d+\.png$`}, "", "xl/media/image1.png"},
+		{"type dir", FindOptions{Type: "dir"}, "xl", "xl,xl/media,xl/worksheets"},
+		{"size range", FindOptions{MinSize: 1024, MaxSize: 4096}, "", "xl/worksheets/sheet1.xml"},
+		{"newer", FindOptions{ModifiedAfter: time.Now().Add(-time.Hour), Type: "file"}, "", "xl/styles.xml,xl/workbook.xml"},
+		{"older", FindOptions{ModifiedBefore: time.Now().Add(-time.Hour), Path: "docProps/**"}, "", "docProps/app.xml"},
+		{"status", FindOptions{Status: []string{FindStatusModified, FindStatusAdded}}, "", "xl/styles.xml,xl/workbook.xml"},
+		{"unchanged files", FindOptions{Status: []string{FindStatusUnchanged}, Type: "file"}, "xl", "xl/media/image1.png,xl/worksheets/sheet1.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(tt.opts, tt.path); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	result, err := FindFiles(session, "", FindOptions{Type: "file", MaxResults: 2})
+	if err != nil {
+		t.Fatalf("FindFiles failed: %v", err)
+	}
+	if len(result.Entries) != 2 || result.Total != 5 || !result.Truncated {
+		t.Errorf("expected 2 of 5 entries, got %d of %d (truncated=%v)", len(result.Entries), result.Total, result.Truncated)
+	}
+
+	for _, opts := range []FindOptions{
+		{Path: "../**"},
+		{Name: "("},
+		{Type: "socket"},
+		{Status: []string{"deleted"}},
+	} {
+		if _, err := FindFiles(session, "", opts); err == nil {
+			t.Errorf("expected %+v to fail", opts)
+		}
+	}
+	if _, err := FindFiles(session, "../x", FindOptions{}); err == nil {
+		t.Error("expected path traversal to fail")
+	}
+}
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// maxGlobExpansions bounds the patterns one brace expression may expand to.
+const maxGlobExpansions = 1024
+
+// PathGlob matches slash-separated, workspace-relative paths against a set of
+// glob patterns. Every filter that accepts a glob goes through it, so patterns
+// behave the same in grep, ls, find, delete and the MCP tools:
+//
+//   - segments follow path.Match ("*", "?", "[a-z]", "\" escapes);
+//   - a "**" segment matches any number of directories, including none;
+//   - "{a,b}" matches either alternative and may be nested;
+//   - a leading "!" excludes paths instead of selecting them;
+//   - a pattern without "/" matches the base name at any depth, like
+//     .gitignore; prefix it with "./" to match at the root only.
+//
+// A path matches when it matches at least one selecting pattern (or there are
+// none) and no excluding pattern. A nil PathGlob matches everything.
+type PathGlob struct {
+	include [][]string
+	exclude [][]string
+}
+
+// CompileGlob validates and compiles glob patterns used as filters.
+func CompileGlob(patterns ...string) (*PathGlob, error) {
+	return compileGlob(patterns, false)
+}
+
+// CompilePathList compiles a list of paths or globs naming files and
+// directories: each entry also matches everything below what it names, and a
+// plain path is taken from the root. An empty list, or an entry naming the root
+// ("", "." or "/"), selects everything.
+func CompilePathList(paths ...string) (*PathGlob, error) {
+	for _, p := range paths {
+		if p == "" || p == "." || p == "/" {
+			return nil, nil
+		}
+	}
+	return compileGlob(paths, true)
+}
+
+func compileGlob(patterns []string, subtree bool) (*PathGlob, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	g := &PathGlob{}
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		body := strings.TrimPrefix(pattern, "!")
+		if subtree {
+			body = strings.TrimPrefix(body, "/")
+		}
+
+		if err := security.SanitizeGlobPattern(body); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern: %w", err)
+		}
+
+		expanded, err := expandBraces(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+
+		for _, p := range expanded {
+			segments, err := globSegments(p, subtree)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+			}
+			if negate {
+				g.exclude = append(g.exclude, segments)
+			} else {
+				g.include = append(g.include, segments)
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// globSegments splits a brace-free pattern into segments and checks each one.
+func globSegments(pattern string, subtree bool) ([]string, error) {
+	// A plain path in a path list names one entry, so it is anchored too
+	anchored := (subtree && !IsGlob(pattern)) || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	cleaned := path.Clean("/" + pattern)
+	if cleaned == "/" {
+		return nil, fmt.Errorf("pattern names the workspace root")
+	}
+	pattern = cleaned[1:]
+
+	segments := strings.Split(pattern, "/")
+	for _, s := range segments {
+		if s == "**" {
+			continue
+		}
+		if _, err := path.Match(s, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if !anchored {
+		segments = append([]string{"**"}, segments...)
+	}
+	if subtree && segments[len(segments)-1] != "**" {
+		segments = append(segments, "**")
+	}
+	return segments, nil
+}
+
+// Match reports whether a workspace-relative path is selected by the glob.
+func (g *PathGlob) Match(name string) bool {
+	if g == nil {
+		return true
+	}
+
+	parts := strings.Split(name, "/")
+	for _, p := range g.exclude {
+		if matchGlobParts(p, parts) {
+			return false
+		}
+	}
+	if len(g.include) == 0 {
+		return true
+	}
+	for _, p := range g.include {
+		if matchGlobParts(p, parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// namesRoot reports whether a selecting pattern consists only of "**", which
+// stands for the root itself as well as everything below it.
+func (g *PathGlob) namesRoot() bool {
+	for _, p := range g.include {
+		if !slices.ContainsFunc(p, func(s string) bool { return s != "**" }) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxDepth returns how many segments deep a path selected by the glob can be,
+// or -1 when there is no bound: a "**" segment or no selecting pattern.
+func (g *PathGlob) maxDepth() int {
+	if g == nil || len(g.include) == 0 {
+		return -1
+	}
+	depth := 0
+	for _, p := range g.include {
+		if slices.Contains(p, "**") {
+			return -1
+		}
+		depth = max(depth, len(p))
+	}
+	return depth
+}
+
+// IsGlob reports whether s uses any glob syntax, as opposed to naming one path.
+func IsGlob(s string) bool {
+	return strings.HasPrefix(s, "!") || strings.ContainsAny(s, "*?[{")
+}
+
+// matchGlobParts matches validated pattern segments against path segments.
+func matchGlobParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated ** and try every split point
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchGlobParts(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// expandBraces expands "{a,b}" alternations from left to right, leaving escaped
+// braces and bracket expressions alone.
+func expandBraces(pattern string) ([]string, error) {
+	lbrace, rbrace, commas := findBraces(pattern)
+	if lbrace < 0 {
+		return []string{pattern}, nil
+	}
+	if rbrace < 0 {
+		return nil, fmt.Errorf("unclosed brace")
+	}
+
+	prefix, suffix := pattern[:lbrace], pattern[rbrace+1:]
+	var results []string
+	start := lbrace + 1
+	for _, end := range append(commas, rbrace) {
+		tails, err := expandBraces(prefix + pattern[start:end] + suffix)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, tails...)
+		if len(results) > maxGlobExpansions {
+			return nil, fmt.Errorf("brace expansion exceeds %d patterns", maxGlobExpansions)
+		}
+		start = end + 1
+	}
+	return results, nil
+}
+
+// findBraces locates the first top-level brace group and its separating commas.
+// lbrace is -1 when there is none; rbrace is -1 when it is not closed.
+func findBraces(pattern string) (lbrace, rbrace int, commas []int) {
+	lbrace = -1
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case c == '[':
+			// Skip a bracket expression
+			j := i + 1
+			for j < len(pattern) && pattern[j] != ']' {
+				if pattern[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			i = j
+		case c == '{':
+			if depth == 0 {
+				lbrace = i
+			}
+			depth++
+		case c == ',' && depth == 1:
+			commas = append(commas, i)
+		case c == '}' && depth > 0:
+			depth--
+			if depth == 0 {
+				return lbrace, i, commas
+			}
+		}
+	}
+	return lbrace, -1, nil
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		name     string
+		want     bool
+	}{
+		{[]string{"*.xml"}, "a.xml", true},
+		{[]string{"*.xml"}, "xl/worksheets/a.xml", true},
+		{[]string{"./*.xml"}, "xl/a.xml", false},
+		{[]string{"./*.xml"}, "a.xml", true},
+		{[]string{"xl/*.xml"}, "xl/a.xml", true},
+		{[]string{"xl/*.xml"}, "xl/worksheets/a.xml", false},
+		{[]string{"xl/**/*.xml"}, "xl/a.xml", true},
+		{[]string{"xl/**/*.xml"}, "xl/worksheets/_rels/a.xml", true},
+		{[]string{"xl/**"}, "xl", true},
+		{[]string{"**/media/*"}, "ppt/media/image1.png", true},
+		{[]string{"media/"}, "ppt/media", true},
+		{[]string{"*.{png,jp*g}"}, "xl/media/photo.jpeg", true},
+		{[]string{"*.{png,jp*g}"}, "xl/media/photo.gif", false},
+		{[]string{"{xl,ppt/{slides,media}}/*"}, "ppt/media/a.png", true},
+		{[]string{"{xl,ppt/{slides,media}}/*"}, "ppt/notes/a.xml", false},
+		{[]string{"[{]x}"}, "{x}", true},
+		{[]string{`\{a,b\}`}, "{a,b}", true},
+		{[]string{"!*.png"}, "xl/a.xml", true},
+		{[]string{"!*.png"}, "xl/a.png", false},
+		{[]string{"xl/**", "!xl/media/**"}, "xl/workbook.xml", true},
+		{[]string{"xl/**", "!xl/media/**"}, "xl/media/a.png", false},
+		{[]string{"xl/**", "!xl/media/**"}, "ppt/a.xml", false},
+	}
+
+	for _, tt := range tests {
+		g, err := CompileGlob(tt.patterns...)
+		if err != nil {
+			t.Fatalf("CompileGlob(%q) failed: %v", tt.patterns, err)
+		}
+		if got := g.Match(tt.name); got != tt.want {
+			t.Errorf("CompileGlob(%q).Match(%q) = %v, want %v", tt.patterns, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompileGlob_Invalid(t *testing.T) {
+	for _, pattern := range []string{"", "!", "/abs/*.xml", "../*.xml", "a/../../b", "[a-", "{a,b", "."} {
+		if _, err := CompileGlob(pattern); err == nil {
+			t.Errorf("expected CompileGlob(%q) to fail", pattern)
+		}
+	}
+
+	if _, err := CompileGlob("{a,b}{c,d}{e,f}{g,h}{i,j}{k,l}{m,n}{o,p}{q,r}{s,t}{u,v}"); err == nil {
+		t.Error("expected an oversized brace expansion to fail")
+	}
+}
+
+func TestCompilePathList(t *testing.T) {
+	g, err := CompilePathList("keep", "/docs/*.md", "!keep/tmp")
+	if err != nil {
+		t.Fatalf("CompilePathList failed: %v", err)
+	}
+
+	for name, want := range map[string]bool{
+		"keep":           true,
+		"keep/a/b.txt":   true,
+		"keep/tmp/x.txt": false,
+		"keeper/a.txt":   false,
+		"docs/readme.md": true,
+		"a.md":           false,
+		"x/keep/a.txt":   false,
+	} {
+		if got := g.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	for _, all := range [][]string{nil, {"keep", "."}, {"/"}} {
+		g, err := CompilePathList(all...)
+		if err != nil || !g.Match("anything/at/all") {
+			t.Errorf("expected %q to select everything", all)
+		}
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+	got, err := expandBraces("a{b,c{d,e}}f")
+	if err != nil {
+		t.Fatalf("expandBraces failed: %v", err)
+	}
+	if want := []string{"abf", "acdf", "acef"}; !slices.Equal(got, want) {
+		t.Errorf("expandBraces = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,349 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Search index modes for Config.Defaults.SearchIndex.
+const (
+	SearchIndexOff  = "off"  // grep reads every file
+	SearchIndexLazy = "lazy" // the index is built by the first grep
+	SearchIndexOpen = "open" // the index is built when the session is opened
+)
+
+const (
+	searchIndexFile    = "search.idx"
+	searchIndexVersion = 1
+
+	// staleJournalSuffix names the list of paths written or deleted since the
+	// index was last saved. Claimed journals get a unique suffix after it.
+	staleJournalSuffix = ".stale"
+
+	// Files larger than this are not indexed and are always searched.
+	maxIndexFileBytes = 16 * 1024 * 1024
+)
+
+// searchIndex records the trigrams of every file in a workspace so grep can skip
+// files that cannot contain a match. Entries are keyed by slash-separated path
+// and trusted only while the file's size and mtime are unchanged; anything else
+// is re-read, so an out-of-date index costs time but never misses a match.
+type searchIndex struct {
+	Version int
+	Files   map[string]*indexedFile
+
+	dirty   bool
+	claimed []string // stale journals applied by loadSearchIndex, removed by save
+}
+
+// indexedFile is the trigram set of one file. Unindexed files are always searched.
+type indexedFile struct {
+	Size      int64
+	ModTime   int64    // UnixNano
+	Trigrams  []uint32 // sorted, ASCII case-folded
+	Unindexed bool
+}
+
+// searchIndexPath returns the index location for a contents directory. The
+// index lives in the workspace directory, next to contents/.
+func searchIndexPath(contentsDir string) string {
+	return filepath.Join(filepath.Dir(contentsDir), searchIndexFile)
+}
+
+// loadSearchIndex reads the index at indexPath and drops the entries named in
+// its stale journal, so they are re-read when next seen. A missing, unreadable
+// or outdated index yields an empty one; exists reports whether a file was there.
+func loadSearchIndex(indexPath string) (ix *searchIndex, exists bool) {
+	ix = &searchIndex{Version: searchIndexVersion, Files: make(map[string]*indexedFile)}
+
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return ix, false
+	}
+
+	var loaded searchIndex
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&loaded); err != nil || loaded.Version != searchIndexVersion || loaded.Files == nil {
+		ix.dirty = true
+		ix.claimStaleJournals(indexPath)
+		return ix, true
+	}
+
+	loaded.claimStaleJournals(indexPath)
+	return &loaded, true
+}
+
+// claimStaleJournals moves the stale journal aside, so writes made from now on
+// start a new one, and prunes every path listed in it and in journals claimed
+// earlier but never cleared by a save.
+func (ix *searchIndex) claimStaleJournals(indexPath string) {
+	journalPath := indexPath + staleJournalSuffix
+	_ = os.Rename(journalPath, fmt.Sprintf("%s-%d", journalPath, time.Now().UnixNano()))
+
+	claimed, _ := filepath.Glob(journalPath + "-*")
+	for _, p := range claimed {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		for _, name := range strings.Split(string(data), "\n") {
+			if name != "" {
+				ix.prune(name, nil)
+			}
+		}
+		ix.claimed = append(ix.claimed, p)
+		ix.dirty = true
+	}
+}
+
+// save writes the index atomically if it changed, then removes the stale
+// journals it has absorbed.
+func (ix *searchIndex) save(indexPath string) error {
+	if !ix.dirty {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ix); err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+	if err := writeFileAtomic(indexPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	for _, p := range ix.claimed {
+		os.Remove(p)
+	}
+	ix.claimed = nil
+	ix.dirty = false
+	return nil
+}
+
+// refresh returns the entry for a regular file, re-reading the file when its
+// size or mtime no longer match. A file that cannot be read is left unindexed.
+func (ix *searchIndex) refresh(root *os.Root, name string, info fs.FileInfo) *indexedFile {
+	entry := ix.Files[name]
+	if entry != nil && entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano() {
+		return entry
+	}
+
+	entry = &indexedFile{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Unindexed: true}
+	if info.Size() <= maxIndexFileBytes {
+		if data, err := root.ReadFile(name); err == nil && int64(len(data)) == info.Size() {
+			entry.Trigrams = fileTrigrams(data)
+			entry.Unindexed = false
+		}
+	}
+
+	ix.Files[name] = entry
+	ix.dirty = true
+	return entry
+}
+
+// prune drops entries under dir (a root name) that were not seen by a complete walk.
+func (ix *searchIndex) prune(dir string, seen map[string]bool) {
+	for name := range ix.Files {
+		if seen[name] {
+			continue
+		}
+		if dir == "." || name == dir || strings.HasPrefix(name, dir+"/") {
+			delete(ix.Files, name)
+			ix.dirty = true
+		}
+	}
+}
+
+// mayContain reports whether the file could hold every trigram in query.
+func (f *indexedFile) mayContain(query []uint32) bool {
+	if f.Unindexed {
+		return true
+	}
+	for _, t := range query {
+		if _, found := slices.BinarySearch(f.Trigrams, t); !found {
+			return false
+		}
+	}
+	return true
+}
+
+// BuildSearchIndex indexes every regular file in a workspace, replacing any
+// existing index. Files already indexed with the same size and mtime are kept.
+func BuildSearchIndex(contentsDir string) error {
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	indexPath := searchIndexPath(contentsDir)
+	ix, _ := loadSearchIndex(indexPath)
+	seen := make(map[string]bool)
+
+	err = fs.WalkDir(root.FS(), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		seen[name] = true
+		ix.refresh(root, name, info)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index files: %w", err)
+	}
+
+	ix.prune(".", seen)
+	ix.dirty = true
+	return ix.save(indexPath)
+}
+
+// markSearchIndexStale records that files or directories were written or
+// deleted, by appending their root names to the workspace's stale journal.
+// Writes stay O(1) however large the index is; the next search that loads the
+// index drops these entries and re-reads the files it meets. It does nothing
+// when the workspace has no index. Errors are ignored: a stale entry is also
+// caught by the size and mtime check.
+func markSearchIndexStale(contentsDir string, names ...string) {
+	indexPath := searchIndexPath(contentsDir)
+	if _, err := os.Stat(indexPath); err != nil || len(names) == 0 {
+		return
+	}
+
+	f, err := os.OpenFile(indexPath+staleJournalSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	_, _ = f.WriteString(strings.Join(names, "\n") + "\n")
+}
+
+// foldASCII lowercases an ASCII byte; the index and its queries are both folded
+// so one index serves case-sensitive and case-insensitive searches.
+func foldASCII(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+// trigram packs three folded bytes into a uint32.
+func trigram(a, b, c byte) uint32 {
+	return uint32(foldASCII(a))<<16 | uint32(foldASCII(b))<<8 | uint32(foldASCII(c))
+}
+
+// fileTrigrams returns the sorted set of trigrams in data.
+func fileTrigrams(data []byte) []uint32 {
+	set := make(map[uint32]struct{})
+	for i := 0; i+3 <= len(data); i++ {
+		set[trigram(data[i], data[i+1], data[i+2])] = struct{}{}
+	}
+
+	trigrams := make([]uint32, 0, len(set))
+	for t := range set {
+		trigrams = append(trigrams, t)
+	}
+	slices.Sort(trigrams)
+	return trigrams
+}
+
+// regexTrigrams returns trigrams that every line matching pattern must contain.
+// An empty result means the index cannot narrow the search.
+func regexTrigrams(pattern string) []uint32 {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+
+	set := make(map[uint32]struct{})
+	for _, lit := range requiredLiterals(re.Simplify()) {
+		for i := 0; i+3 <= len(lit); i++ {
+			set[trigram(lit[i], lit[i+1], lit[i+2])] = struct{}{}
+		}
+	}
+
+	trigrams := make([]uint32, 0, len(set))
+	for t := range set {
+		trigrams = append(trigrams, t)
+	}
+	slices.Sort(trigrams)
+	return trigrams
+}
+
+// requiredLiterals returns strings that every match of re contains. It only
+// looks through concatenations, groups and repeats of at least one; anything
+// else (alternations, classes, optional parts) contributes nothing.
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral, syntax.OpConcat:
+		var lits []string
+		var run strings.Builder
+		flush := func() {
+			if run.Len() >= 3 {
+				lits = append(lits, run.String())
+			}
+			run.Reset()
+		}
+
+		subs := re.Sub
+		if re.Op == syntax.OpLiteral {
+			subs = []*syntax.Regexp{re}
+		}
+		for _, sub := range subs {
+			if sub.Op != syntax.OpLiteral {
+				flush()
+				lits = append(lits, requiredLiterals(sub)...)
+				continue
+			}
+			for _, r := range sub.Rune {
+				if literalRuneIndexable(r, sub.Flags&syntax.FoldCase != 0) {
+					run.WriteRune(r)
+				} else {
+					flush()
+				}
+			}
+		}
+		flush()
+		return lits
+
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return requiredLiterals(re.Sub[0])
+		}
+	}
+
+	return nil
+}
+
+// literalRuneIndexable reports whether a literal rune maps to fixed folded bytes.
+// Under case folding only ASCII qualifies, minus k and s, which also match the
+// Kelvin sign and long s.
+func literalRuneIndexable(r rune, foldCase bool) bool {
+	if !foldCase {
+		return true
+	}
+	if r >= 0x80 {
+		return false
+	}
+	switch r {
+	case 'k', 'K', 's', 'S':
+		return false
+	}
+	return true
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegexTrigrams(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    int // number of distinct trigrams
+	}{
+		{"hello", 3},
+		{"HELLO", 3}, // folded like the index
+		{"ab", 0},
+		{"foo|bar", 0},
+		{"foo.*bar", 2},
+		{"(?:abcd)+x?", 2},
+		{"colou?r", 2},   // only "colo" is required
+		{"(?i)desk", 0},  // k and s also match non-ASCII runes
+		{"(?i)paper", 3}, // other ASCII folds safely
+		{"[invalid", 0},
+	}
+
+	for _, tt := range tests {
+		if got := regexTrigrams(tt.pattern); len(got) != tt.want {
+			t.Errorf("regexTrigrams(%q) = %d trigrams, want %d", tt.pattern, len(got), tt.want)
+		}
+	}
+}
+
+func TestGrepFiles_Index(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(filepath.Join(contentsDir, "sub"), 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, "a.txt"), []byte("the needle is here\n"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "b.txt"), []byte("nothing to see\n"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "sub", "c.txt"), []byte("more hay\n"), 0644)
+
+	opts := GrepOptions{Index: true}
+	indexPath := searchIndexPath(contentsDir)
+
+	// The first search builds the index
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "needle", opts)
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 1 || result.IndexSkipped != 2 {
+		t.Errorf("expected 1 match and 2 files ruled out, got %+v", result)
+	}
+	if _, err := os.Stat(indexPath); err != nil {
+		t.Fatalf("expected index at %s: %v", indexPath, err)
+	}
+
+	// Case-insensitive searches use the same index
+	result, err = GrepFiles(context.Background(), contentsDir, ".", "NEEDLE", GrepOptions{Index: true, IgnoreCase: true})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 1 {
+		t.Errorf("expected case-insensitive match, got %+v", result.Matches)
+	}
+
+	// WriteFile and DeleteFile only journal the paths; the index is untouched
+	before, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("failed to read index: %v", err)
+	}
+	if err := WriteFile(contentsDir, "b.txt", []byte("a needle appears\n"), false, nil); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := DeleteFile(contentsDir, "sub", true); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if after, _ := os.ReadFile(indexPath); string(after) != string(before) {
+		t.Error("expected writes not to rewrite the index")
+	}
+
+	// Loading the index drops the journaled entries
+	ix, _ := loadSearchIndex(indexPath)
+	if _, ok := ix.Files["sub/c.txt"]; ok {
+		t.Error("expected deleted file to leave the index")
+	}
+	if _, ok := ix.Files["b.txt"]; ok {
+		t.Error("expected written file to be dropped for re-reading")
+	}
+	if err := ix.save(indexPath); err != nil {
+		t.Fatalf("failed to save index: %v", err)
+	}
+	if journals, _ := filepath.Glob(indexPath + staleJournalSuffix + "*"); len(journals) != 0 {
+		t.Errorf("expected saved index to clear the journal, got %v", journals)
+	}
+
+	// External edits are picked up through the mtime
+	os.WriteFile(filepath.Join(contentsDir, "a.txt"), []byte("no match now\n"), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(contentsDir, "a.txt"), later, later)
+	os.WriteFile(filepath.Join(contentsDir, "new.txt"), []byte("needle\n"), 0644)
+
+	result, err = GrepFiles(context.Background(), contentsDir, ".", "needle", opts)
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+
+	var files []string
+	for _, m := range result.Matches {
+		files = append(files, m.File)
+	}
+	if len(files) != 2 || files[0] != "b.txt" || files[1] != "new.txt" {
+		t.Errorf("expected matches in b.txt and new.txt, got %v", files)
+	}
+}
+
+func TestGrepFiles_IndexSameSizeAndMtime(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+	os.WriteFile(filepath.Join(contentsDir, "a.txt"), []byte("haystack\n"), 0644)
+
+	opts := GrepOptions{Index: true}
+	if _, err := GrepFiles(context.Background(), contentsDir, ".", "needle", opts); err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(contentsDir, "a.txt"))
+	if err != nil {
+		t.Fatalf("failed to stat: %v", err)
+	}
+
+	// A rewrite within the same mtime tick and of the same size still counts
+	if err := WriteFile(contentsDir, "a.txt", []byte("needle!!\n"), false, nil); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	os.Chtimes(filepath.Join(contentsDir, "a.txt"), info.ModTime(), info.ModTime())
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "needle", opts)
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 1 {
+		t.Errorf("expected the rewritten file to match, got %+v", result)
+	}
+}
+
+func TestGrepFiles_IndexEditAndCopy(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+	os.WriteFile(filepath.Join(contentsDir, "a.txt"), []byte("haystack\n"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "b.txt"), []byte("more hay\n"), 0644)
+
+	indexPath := searchIndexPath(contentsDir)
+	if _, err := GrepFiles(context.Background(), contentsDir, ".", "needle", GrepOptions{Index: true}); err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+
+	// Edits and copies into the workspace journal the paths they write
+	blocks := []EditBlock{{Search: "haystack", Replace: "needle"}}
+	if _, err := EditFile(contentsDir, "a.txt", EditOptions{Blocks: blocks}, nil); err != nil {
+		t.Fatalf("failed to edit: %v", err)
+	}
+	hostFile := filepath.Join(tempDir, "host.txt")
+	os.WriteFile(hostFile, []byte("a needle\n"), 0644)
+	if _, err := CopyFiles(CopyLocation{Path: hostFile}, CopyLocation{ContentsDir: contentsDir, Path: "b.txt"}, CopyOptions{Overwrite: true}, nil); err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+
+	ix, _ := loadSearchIndex(indexPath)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, ok := ix.Files[name]; ok {
+			t.Errorf("expected %s to be dropped for re-reading", name)
+		}
+	}
+}
+
+func TestGrepFiles_IndexCorrupt(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+	os.WriteFile(filepath.Join(contentsDir, "a.txt"), []byte("needle\n"), 0644)
+	os.WriteFile(searchIndexPath(contentsDir), []byte("garbage"), 0644)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "needle", GrepOptions{Index: true})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 1 {
+		t.Errorf("expected the corrupt index to be rebuilt, got %+v", result)
+	}
+
+	ix, exists := loadSearchIndex(searchIndexPath(contentsDir))
+	if !exists || ix.Files["a.txt"] == nil {
+		t.Error("expected a rebuilt index")
+	}
+}
+
+func TestBuildSearchIndex_OnOpen(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"doc.txt": "indexed text\n"})
+
+	cfg := DefaultConfig()
+	cfg.Defaults.SearchIndex = SearchIndexOpen
+
+	session, err := CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	ix, exists := loadSearchIndex(searchIndexPath(contentsDir))
+	if !exists || ix.Files["doc.txt"] == nil {
+		t.Fatal("expected index built on open")
+	}
+
+	if !cfg.GrepOptions().Index {
+		t.Error("expected grep to use the index")
+	}
+	if DefaultConfig().GrepOptions().Index {
+		t.Error("expected the index to be off by default")
+	}
+}
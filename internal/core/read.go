@@ -0,0 +1,241 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// DefaultReadMaxBytes caps the content returned by a line-range read.
+const DefaultReadMaxBytes = 256 * 1024
+
+// ReadLinesOptions selects a range of lines. Lines are numbered from 1.
+type ReadLinesOptions struct {
+	StartLine int // first line to return; 0 means 1
+	EndLine   int // last line to return, inclusive; 0 means through the end of file
+	MaxBytes  int // cap on returned text; 0 means DefaultReadMaxBytes
+}
+
+// Line is one numbered line without its line terminator.
+type Line struct {
+	Number    int    `json:"number"`
+	Text      string `json:"text"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// ReadLinesResult is a window of lines plus what is needed to read the next one.
+// NextLine is the StartLine for a follow-up read, or 0 when the requested range
+// was returned in full.
+type ReadLinesResult struct {
+	Lines      []Line `json:"lines"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	TotalLines int    `json:"total_lines"`
+	NextLine   int    `json:"next_line,omitempty"`
+	Truncated  bool   `json:"truncated"`
+}
+
+// ReadLines streams a workspace file and returns a range of lines, so large files
+// are never loaded whole. The whole file is scanned to count its lines. Once the
+// returned text, without line terminators, would pass MaxBytes, reading stops
+// and NextLine points at the first line left out; a single line longer than
+// MaxBytes is cut at a UTF-8 boundary and marked truncated.
+func ReadLines(contentsDir, relativePath string, opts ReadLinesOptions) (*ReadLinesResult, error) {
+	start := max(opts.StartLine, 1)
+	end := opts.EndLine
+	if end > 0 && end < start {
+		return nil, fmt.Errorf("end line %d is before start line %d", end, start)
+	}
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultReadMaxBytes
+	}
+
+	file, err := openWorkspaceFile(contentsDir, relativePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := &ReadLinesResult{Lines: []Line{}, StartLine: start}
+	reader := bufio.NewReaderSize(file, 64*1024)
+
+	budget := maxBytes
+	collecting := true
+	lineNo := 0
+
+	for {
+		// Read one line in chunks; only the part that fits the budget is kept,
+		// plus room for a "\r\n" terminator, which does not count
+		var kept []byte
+		cut := false
+		lineLen := 0
+		for {
+			chunk, err := reader.ReadSlice('\n')
+			lineLen += len(chunk)
+
+			inRange := collecting && lineNo+1 >= start && (end == 0 || lineNo+1 <= end)
+			if inRange && !cut {
+				if room := budget + 2 - len(kept); len(chunk) <= room {
+					kept = append(kept, chunk...)
+				} else {
+					kept = append(kept, chunk[:room]...)
+					cut = true
+				}
+			}
+
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err != nil && err != io.EOF {
+				return nil, rootedError(err, relativePath, "read file")
+			}
+			break
+		}
+
+		if lineLen == 0 {
+			break
+		}
+		lineNo++
+
+		if !collecting || lineNo < start || (end > 0 && lineNo > end) {
+			continue
+		}
+
+		text := kept
+		if !cut {
+			text = bytes.TrimSuffix(text, []byte("\n"))
+			text = bytes.TrimSuffix(text, []byte("\r"))
+		}
+		overflow := len(text) > budget
+
+		// A line that does not fit ends the window, unless it is the first one
+		if overflow && len(result.Lines) > 0 {
+			result.NextLine = lineNo
+			result.Truncated = true
+			collecting = false
+			continue
+		}
+
+		line := Line{Number: lineNo}
+		if overflow {
+			text = trimToRuneBoundary(text[:budget])
+			line.Truncated = true
+			result.Truncated = true
+		}
+		line.Text = string(text)
+
+		result.Lines = append(result.Lines, line)
+		result.EndLine = lineNo
+		budget -= len(text)
+
+		if overflow {
+			result.NextLine = lineNo + 1
+			collecting = false
+		}
+	}
+
+	result.TotalLines = lineNo
+
+	// The cursor only points at lines that exist and were asked for
+	if result.NextLine > result.TotalLines || (end > 0 && result.NextLine > end) {
+		result.NextLine = 0
+	}
+	if len(result.Lines) == 0 {
+		result.EndLine = 0
+	}
+
+	return result, nil
+}
+
+// trimToRuneBoundary drops a partial UTF-8 sequence from the end of b.
+func trimToRuneBoundary(b []byte) []byte {
+	for i := 0; i < utf8.UTFMax && len(b) > 0; i++ {
+		r, size := utf8.DecodeLastRune(b)
+		if r != utf8.RuneError || size > 1 {
+			return b
+		}
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// ReadFileRange reads up to limit bytes starting at offset without loading the
+// rest of the file. A limit of 0 reads to the end. It also returns the file size.
+func ReadFileRange(contentsDir, relativePath string, offset, limit int64) ([]byte, int64, error) {
+	if offset < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("offset and limit must not be negative")
+	}
+
+	file, err := openWorkspaceFile(contentsDir, relativePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, rootedError(err, relativePath, "stat file")
+	}
+	size := info.Size()
+
+	if offset >= size {
+		return []byte{}, size, nil
+	}
+
+	n := size - offset
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	data := make([]byte, n)
+	read, err := file.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, 0, rootedError(err, relativePath, "read file")
+	}
+
+	return data[:read], size, nil
+}
+
+// openWorkspaceFile validates a path and opens the file through the contents root.
+func openWorkspaceFile(contentsDir, relativePath string) (*os.File, error) {
+	// Validate relative path
+	if err := security.ValidateRelativePath(relativePath); err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	// Validate the resolved path is within contents directory
+	if err := security.ValidatePath(contentsDir, relativePath); err != nil {
+		return nil, errors.PathTraversal(relativePath)
+	}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	// Symlinks may not lead outside the contents directory
+	file, err := root.Open(rootName(relativePath))
+	if err != nil {
+		return nil, rootedError(err, relativePath, "open file")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, rootedError(err, relativePath, "stat file")
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fmt.Errorf("path is a directory: %s", relativePath)
+	}
+
+	return file, nil
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadLines(t *testing.T) {
+	contentsDir := t.TempDir()
+	os.WriteFile(filepath.Join(contentsDir, "lines.txt"), []byte("one\ntwo\r\nthree\nfour\nfive"), 0644)
+
+	result, err := ReadLines(contentsDir, "lines.txt", ReadLinesOptions{StartLine: 2, EndLine: 4})
+	if err != nil {
+		t.Fatalf("ReadLines failed: %v", err)
+	}
+
+	var texts []string
+	for _, line := range result.Lines {
+		texts = append(texts, line.Text)
+	}
+	if strings.Join(texts, ",") != "two,three,four" {
+		t.Errorf("unexpected lines: %v", texts)
+	}
+	if result.Lines[0].Number != 2 || result.EndLine != 4 || result.TotalLines != 5 {
+		t.Errorf("unexpected numbering: %+v", result)
+	}
+	if result.NextLine != 0 || result.Truncated {
+		t.Errorf("expected complete range, got %+v", result)
+	}
+
+	// The budget stops the window and leaves a cursor
+	result, err = ReadLines(contentsDir, "lines.txt", ReadLinesOptions{MaxBytes: 10})
+	if err != nil {
+		t.Fatalf("ReadLines failed: %v", err)
+	}
+	if len(result.Lines) != 2 || result.NextLine != 3 || !result.Truncated {
+		t.Errorf("expected two lines and a cursor at 3, got %+v", result)
+	}
+
+	result, err = ReadLines(contentsDir, "lines.txt", ReadLinesOptions{StartLine: result.NextLine, MaxBytes: 100})
+	if err != nil {
+		t.Fatalf("ReadLines failed: %v", err)
+	}
+	if len(result.Lines) != 3 || result.Lines[2].Text != "five" || result.NextLine != 0 {
+		t.Errorf("expected the rest of the file, got %+v", result)
+	}
+
+	// Past the end returns nothing but still counts lines
+	result, err = ReadLines(contentsDir, "lines.txt", ReadLinesOptions{StartLine: 10})
+	if err != nil {
+		t.Fatalf("ReadLines failed: %v", err)
+	}
+	if len(result.Lines) != 0 || result.TotalLines != 5 || result.EndLine != 0 {
+		t.Errorf("expected empty window, got %+v", result)
+	}
+
+	if _, err := ReadLines(contentsDir, "lines.txt", ReadLinesOptions{StartLine: 3, EndLine: 2}); err == nil {
+		t.Error("expected inverted range to fail")
+	}
+	if _, err := ReadLines(contentsDir, "../lines.txt", ReadLinesOptions{}); err == nil {
+		t.Error("expected path traversal to fail")
+	}
+}
+
+func TestReadLines_TerminatorsOutsideBudget(t *testing.T) {
+	contentsDir := t.TempDir()
+	os.WriteFile(filepath.Join(contentsDir, "crlf.txt"), []byte("12345\r\nabc\r\n"), 0644)
+
+	// A line that fits exactly is returned whole, whatever its terminator
+	result, err := ReadLines(contentsDir, "crlf.txt", ReadLinesOptions{MaxBytes: 5})
+	if err != nil {
+		t.Fatalf("ReadLines failed: %v", err)
+	}
+	if len(result.Lines) != 1 || result.Lines[0].Text != "12345" || result.Lines[0].Truncated {
+		t.Errorf("expected the first line in full, got %+v", result)
+	}
+	if result.NextLine != 2 || !result.Truncated {
+		t.Errorf("expected a cursor at 2, got %+v", result)
+	}
+
+	result, err = ReadLines(contentsDir, "crlf.txt", ReadLinesOptions{MaxBytes: 8})
+	if err != nil {
+		t.Fatalf("ReadLines failed: %v", err)
+	}
+	if len(result.Lines) != 2 || result.Truncated || result.NextLine != 0 {
+		t.Errorf("expected both lines within 8 bytes, got %+v", result)
+	}
+}
+
+func TestReadLines_LongLine(t *testing.T) {
+	contentsDir := t.TempDir()
+
+	// A single line far larger than the reader buffer
+	long := strings.Repeat("é", 100*1024)
+	os.WriteFile(filepath.Join(contentsDir, "long.txt"), []byte("short\n"+long+"\nafter\n"), 0644)
+
+	result, err := ReadLines(contentsDir, "long.txt", ReadLinesOptions{StartLine: 2, MaxBytes: 1001})
+	if err != nil {
+		t.Fatalf("ReadLines failed: %v", err)
+	}
+
+	if len(result.Lines) != 1 || !result.Lines[0].Truncated {
+		t.Fatalf("expected one truncated line, got %+v", result)
+	}
+	if text := result.Lines[0].Text; len(text) != 1000 || !strings.HasPrefix(long, text) {
+		t.Errorf("expected line cut at a rune boundary, got %d bytes", len(text))
+	}
+	if result.NextLine != 3 || result.TotalLines != 3 {
+		t.Errorf("expected cursor 3 of 3 lines, got %+v", result)
+	}
+}
+
+func TestReadFileRange(t *testing.T) {
+	contentsDir := t.TempDir()
+	os.WriteFile(filepath.Join(contentsDir, "data.bin"), []byte("0123456789"), 0644)
+
+	tests := []struct {
+		offset, limit int64
+		want          string
+	}{
+		{0, 0, "0123456789"},
+		{3, 4, "3456"},
+		{8, 10, "89"},
+		{20, 0, ""},
+	}
+
+	for _, tt := range tests {
+		data, size, err := ReadFileRange(contentsDir, "data.bin", tt.offset, tt.limit)
+		if err != nil {
+			t.Fatalf("ReadFileRange(%d, %d) failed: %v", tt.offset, tt.limit, err)
+		}
+		if string(data) != tt.want || size != 10 {
+			t.Errorf("ReadFileRange(%d, %d) = %q, %d; want %q, 10", tt.offset, tt.limit, data, size, tt.want)
+		}
+	}
+
+	os.Mkdir(filepath.Join(contentsDir, "dir"), 0755)
+	if _, _, err := ReadFileRange(contentsDir, "dir", 0, 0); err == nil {
+		t.Error("expected reading a directory to fail")
+	}
+}
@@ -6,20 +6,47 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Fuabioo/zipfs/internal/security"
 )
 
+// RepackOptions controls how entries are compressed when repacking.
+type RepackOptions struct {
+	// Methods maps zip entry names to the compression method they originally used.
+	Methods map[string]uint16
+	// Fallback is used for new entries and for original methods zipfs cannot encode.
+	Fallback uint16
+	// Symlinks maps entry names to link targets for symlink entries that are not in the
+	// contents directory (e.g. skipped at extraction) but must be kept in the archive.
+	// Entries that exist in the contents directory take precedence.
+	Symlinks map[string]string
+}
+
 // Repack creates a zip file from the contents of a directory.
-// Does NOT follow symlinks for security.
+// Does NOT follow symlinks for security: symlinks whose targets stay inside the
+// directory are stored as symlink entries, others are skipped.
 func Repack(contentsDir, destZipPath string) error {
+	return RepackWithOptions(contentsDir, destZipPath, RepackOptions{Fallback: zip.Deflate})
+}
+
+// RepackWithOptions creates a zip file from the contents of a directory, preserving
+// each entry's original compression method where possible.
+// Does NOT follow symlinks for security.
+func RepackWithOptions(contentsDir, destZipPath string, opts RepackOptions) error {
+	if !canCompress(opts.Fallback) {
+		return fmt.Errorf("fallback compression method %s cannot be written", CompressionMethodName(opts.Fallback))
+	}
+
 	// Create the destination zip file
 	zipFile, err := os.Create(destZipPath)
 	if err != nil {
 		return fmt.Errorf("failed to create zip file: %w", err)
 	}
-	defer zipFile.Close()
 
 	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	written := make(map[string]bool)
 
 	// Walk the contents directory and add all files
 	err = filepath.Walk(contentsDir, func(path string, info os.FileInfo, err error) error {
@@ -38,9 +65,21 @@ func Repack(contentsDir, destZipPath string) error {
 			return nil
 		}
 
-		// Skip symlinks (security requirement)
+		// Store symlinks as symlink entries, never following them
 		if info.Mode()&os.ModeSymlink != 0 {
-			return nil
+			target, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink: %w", err)
+			}
+
+			// Skip symlinks that escape the contents directory (security requirement)
+			if err := security.ValidateSymlinkTarget(contentsDir, relPath, target); err != nil {
+				return nil
+			}
+
+			name := filepath.ToSlash(relPath)
+			written[name] = true
+			return writeSymlinkEntry(zipWriter, name, target, info.ModTime())
 		}
 
 		// Create header from file info
@@ -51,13 +90,20 @@ func Repack(contentsDir, destZipPath string) error {
 
 		// Use forward slashes for zip paths (cross-platform compatibility)
 		header.Name = filepath.ToSlash(relPath)
+		written[header.Name] = true
 
 		// Handle directories
 		if info.IsDir() {
 			header.Name += "/"
 			header.Method = zip.Store
 		} else {
-			header.Method = zip.Deflate
+			header.Method = opts.Fallback
+			if method, ok := opts.Methods[header.Name]; ok && canCompress(method) {
+				header.Method = method
+			}
+			if header.Method == security.MethodLZMA {
+				header.Flags |= lzmaFlagEOS
+			}
 		}
 
 		// Write header
@@ -86,8 +132,112 @@ func Repack(contentsDir, destZipPath string) error {
 	})
 
 	if err != nil {
+		zipWriter.Close()
+		zipFile.Close()
 		return fmt.Errorf("failed to walk contents directory: %w", err)
 	}
 
+	// Carry over symlink entries that are not present in the contents directory
+	names := make([]string, 0, len(opts.Symlinks))
+	for name := range opts.Symlinks {
+		if !written[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeSymlinkEntry(zipWriter, name, opts.Symlinks[name], time.Now()); err != nil {
+			zipWriter.Close()
+			zipFile.Close()
+			return err
+		}
+	}
+
+	// Finish the central directory and make the archive durable before callers rename it
+	if err := zipWriter.Close(); err != nil {
+		zipFile.Close()
+		return fmt.Errorf("failed to finalize zip file: %w", err)
+	}
+	if err := zipFile.Sync(); err != nil {
+		zipFile.Close()
+		return fmt.Errorf("failed to sync zip file: %w", err)
+	}
+	if err := zipFile.Close(); err != nil {
+		return fmt.Errorf("failed to close zip file: %w", err)
+	}
+
+	return nil
+}
+
+// writeSymlinkEntry writes a symlink entry whose content is the link target.
+func writeSymlinkEntry(zipWriter *zip.Writer, name, target string, modified time.Time) error {
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: modified,
+	}
+	header.SetMode(os.ModeSymlink | 0777)
+
+	writer, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to create zip entry: %w", err)
+	}
+
+	if _, err := io.WriteString(writer, filepath.ToSlash(target)); err != nil {
+		return fmt.Errorf("failed to write symlink to zip: %w", err)
+	}
+
 	return nil
 }
+
+// ZipSymlinks returns the targets of the named symlink entries in a zip archive.
+// Names that are missing or are not symlinks are ignored.
+func ZipSymlinks(zipPath string, names []string) (map[string]string, error) {
+	links := make(map[string]string, len(names))
+	if len(names) == 0 {
+		return links, nil
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer r.Close()
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	for _, f := range r.File {
+		if !wanted[f.Name] || f.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		target, err := readSymlinkTarget(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read symlink %q: %w", f.Name, err)
+		}
+		links[f.Name] = target
+	}
+
+	return links, nil
+}
+
+// ZipMethods returns the compression method of every file entry in a zip archive.
+func ZipMethods(zipPath string) (map[string]uint16, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer r.Close()
+
+	methods := make(map[string]uint16, len(r.File))
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() {
+			methods[f.Name] = f.Method
+		}
+	}
+
+	return methods, nil
+}
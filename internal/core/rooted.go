@@ -0,0 +1,50 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+)
+
+// Workspace file operations go through an os.Root opened on contents/. The root
+// resolves every path component with openat-style calls and refuses any lookup,
+// including one via a symlink or "..", that would leave the directory. Lexical
+// validation with security.ValidatePath still runs first to reject bad input early.
+
+// openContentsRoot opens the contents directory for rooted access.
+func openContentsRoot(contentsDir string) (*os.Root, error) {
+	root, err := os.OpenRoot(contentsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open contents directory: %w", err)
+	}
+	return root, nil
+}
+
+// rootName converts a validated relative path into a name for os.Root and root.FS().
+func rootName(relativePath string) string {
+	if relativePath == "" {
+		return "."
+	}
+	return filepath.ToSlash(filepath.Clean(relativePath))
+}
+
+// isPathEscape reports whether err came from os.Root refusing a path that escapes it.
+// os does not export the sentinel, so the message is matched instead.
+func isPathEscape(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "path escapes from parent")
+}
+
+// rootedError maps an error from rooted file access onto zipfs error codes.
+func rootedError(err error, relativePath, action string) error {
+	switch {
+	case isPathEscape(err):
+		return errors.PathTraversal(relativePath)
+	case os.IsNotExist(err):
+		return errors.PathNotFound(relativePath)
+	default:
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+}
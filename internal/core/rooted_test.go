@@ -0,0 +1,216 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+)
+
+// setupEscapingSymlinks creates a contents directory with symlinks pointing at a
+// secret file and directory outside of it, using absolute and ".." targets.
+func setupEscapingSymlinks(t *testing.T) (contentsDir, secretDir string) {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	contentsDir = filepath.Join(tempDir, "contents")
+	secretDir = filepath.Join(tempDir, "secret")
+
+	if err := os.MkdirAll(contentsDir, 0755); err != nil {
+		t.Fatalf("failed to create contents dir: %v", err)
+	}
+	if err := os.MkdirAll(secretDir, 0755); err != nil {
+		t.Fatalf("failed to create secret dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(secretDir, "id_rsa"), []byte("SECRET KEY"), 0600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(contentsDir, "safe.txt"), []byte("safe"), 0644); err != nil {
+		t.Fatalf("failed to write safe file: %v", err)
+	}
+
+	links := map[string]string{
+		"abs-file": filepath.Join(secretDir, "id_rsa"),
+		"abs-dir":  secretDir,
+		"rel-file": "../secret/id_rsa",
+		"rel-dir":  "../secret",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(contentsDir, name)); err != nil {
+			t.Fatalf("failed to create symlink %s: %v", name, err)
+		}
+	}
+
+	return contentsDir, secretDir
+}
+
+func TestRooted_ReadFileRefusesEscapingSymlinks(t *testing.T) {
+	contentsDir, _ := setupEscapingSymlinks(t)
+
+	for _, name := range []string{"abs-file", "rel-file", "abs-dir/id_rsa", "rel-dir/id_rsa"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := ReadFile(contentsDir, name)
+			if err == nil {
+				t.Fatalf("expected read through %s to fail, got %q", name, data)
+			}
+			if !errors.Is(err, errors.CodePathTraversal) {
+				t.Errorf("expected PATH_TRAVERSAL, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRooted_ReadFileFollowsInternalSymlinks(t *testing.T) {
+	contentsDir, _ := setupEscapingSymlinks(t)
+	os.Symlink("safe.txt", filepath.Join(contentsDir, "inside"))
+
+	data, err := ReadFile(contentsDir, "inside")
+	if err != nil {
+		t.Fatalf("expected read through internal symlink to succeed: %v", err)
+	}
+	if string(data) != "safe" {
+		t.Errorf("expected \"safe\", got %q", data)
+	}
+}
+
+func TestRooted_WriteFileRefusesEscapingSymlinks(t *testing.T) {
+	contentsDir, secretDir := setupEscapingSymlinks(t)
+
+	for _, name := range []string{"abs-file", "rel-file", "abs-dir/planted", "rel-dir/planted"} {
+		t.Run(name, func(t *testing.T) {
+			err := WriteFile(contentsDir, name, []byte("owned"), true, nil)
+			if err == nil {
+				t.Fatalf("expected write through %s to fail", name)
+			}
+			if !errors.Is(err, errors.CodePathTraversal) {
+				t.Errorf("expected PATH_TRAVERSAL, got %v", err)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(filepath.Join(secretDir, "id_rsa"))
+	if err != nil || string(data) != "SECRET KEY" {
+		t.Errorf("expected secret to be untouched, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(secretDir, "planted")); !os.IsNotExist(err) {
+		t.Error("expected no file to be planted outside the workspace")
+	}
+}
+
+func TestRooted_DeleteFileRemovesLinkNotTarget(t *testing.T) {
+	contentsDir, secretDir := setupEscapingSymlinks(t)
+
+	// Deleting the link itself is allowed and leaves the target alone
+	if err := DeleteFile(contentsDir, "abs-file", false); err != nil {
+		t.Fatalf("failed to delete symlink: %v", err)
+	}
+	if err := DeleteFile(contentsDir, "rel-dir", true); err != nil {
+		t.Fatalf("failed to delete directory symlink: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(secretDir, "id_rsa")); err != nil {
+		t.Errorf("expected secret to survive, got %v", err)
+	}
+
+	// Deleting through an escaping directory symlink is refused
+	err := DeleteFile(contentsDir, "abs-dir/id_rsa", false)
+	if !errors.Is(err, errors.CodePathTraversal) {
+		t.Errorf("expected PATH_TRAVERSAL, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(secretDir, "id_rsa")); err != nil {
+		t.Errorf("expected secret to survive, got %v", err)
+	}
+}
+
+func TestRooted_GrepFilesSkipsEscapingSymlinks(t *testing.T) {
+	contentsDir, _ := setupEscapingSymlinks(t)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "SECRET", GrepOptions{})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 0 {
+		t.Errorf("expected no matches from outside the workspace, got %v", result.Matches)
+	}
+
+	// Searching from inside an escaping directory symlink is refused
+	_, err = GrepFiles(context.Background(), contentsDir, "rel-dir", "SECRET", GrepOptions{})
+	if !errors.Is(err, errors.CodePathTraversal) {
+		t.Errorf("expected PATH_TRAVERSAL, got %v", err)
+	}
+}
+
+func TestRooted_ListFilesDoesNotFollowSymlinks(t *testing.T) {
+	contentsDir, _ := setupEscapingSymlinks(t)
+
+	entries, err := ListFiles(contentsDir, ".", true)
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.Name == filepath.Join("abs-dir", "id_rsa") || entry.Name == filepath.Join("rel-dir", "id_rsa") {
+			t.Errorf("expected listing not to descend into %s", filepath.Dir(entry.Name))
+		}
+	}
+
+	// Listing a symlinked directory shows the link, not the outside directory
+	entries, err = ListFiles(contentsDir, "abs-dir", false)
+	if err != nil {
+		t.Fatalf("failed to list symlink: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Type != "symlink" {
+		t.Errorf("expected a single symlink entry, got %+v", entries)
+	}
+
+	// Listing through it is refused
+	_, err = ListFiles(contentsDir, "rel-dir/id_rsa", false)
+	if !errors.Is(err, errors.CodePathTraversal) {
+		t.Errorf("expected PATH_TRAVERSAL, got %v", err)
+	}
+}
+
+func TestRooted_StatusDoesNotFollowSymlinks(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"keys/id_rsa": "SECRET KEY"})
+
+	secretDir := filepath.Join(tempDir, "secret")
+	if err := os.MkdirAll(secretDir, 0755); err != nil {
+		t.Fatalf("failed to create secret dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(secretDir, "id_rsa"), []byte("SECRET KEY"), 0600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+
+	session, err := CreateSession(zipPath, "status-links", DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	// Replace the directory with a link to an outside copy of its contents
+	if err := os.RemoveAll(filepath.Join(contentsDir, "keys")); err != nil {
+		t.Fatalf("failed to remove keys: %v", err)
+	}
+	if err := os.Symlink(secretDir, filepath.Join(contentsDir, "keys")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	status, err := Status(session)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Renamed) != 0 || len(status.Deleted) != 1 || status.Deleted[0] != "keys/id_rsa" {
+		t.Errorf("expected keys/id_rsa to be deleted, got %+v", status)
+	}
+	if len(status.Added) != 1 || status.Added[0] != "keys" {
+		t.Errorf("expected only the link to be added, got %+v", status.Added)
+	}
+}
@@ -3,13 +3,21 @@ package core
 import (
 	"archive/zip"
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Fuabioo/zipfs/internal/errors"
 	"github.com/Fuabioo/zipfs/internal/security"
@@ -18,28 +26,110 @@ import (
 // FileEntry represents a file or directory entry.
 type FileEntry struct {
 	Name       string `json:"name"`
-	Type       string `json:"type"` // "file" or "dir"
+	Type       string `json:"type"` // "file", "dir" or "symlink"
 	SizeBytes  uint64 `json:"size_bytes"`
-	ModifiedAt int64  `json:"modified_at"` // Unix timestamp
+	ModifiedAt int64  `json:"modified_at"`      // Unix timestamp
+	Target     string `json:"target,omitempty"` // link target, for symlinks only
 }
 
-// GrepMatch represents a grep search result.
+// newFileEntry builds a FileEntry from Lstat-style info without following symlinks.
+// name is the display name and path the entry's name within root.
+func newFileEntry(root *os.Root, name, path string, info fs.FileInfo) FileEntry {
+	entry := FileEntry{
+		Name:       name,
+		Type:       "file",
+		SizeBytes:  uint64(info.Size()),
+		ModifiedAt: info.ModTime().Unix(),
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		entry.Type = "symlink"
+		if target, err := root.Readlink(path); err == nil {
+			entry.Target = filepath.ToSlash(target)
+		}
+	case info.IsDir():
+		entry.Type = "dir"
+	}
+
+	return entry
+}
+
+// GrepMatch represents a grep search result. Column is the 1-based byte offset
+// of the first match in the line. Lines longer than GrepExcerptBytes are cut to
+// an excerpt around the match and marked LineTruncated. Matches inside Office
+// documents have no line number; Location names the cell or paragraph instead,
+// as in report.xlsx!Sheet1!B7 or notes.docx!paragraph 12.
 type GrepMatch struct {
-	File        string `json:"file"`
-	LineContent string `json:"line_content"`
-	LineNumber  int    `json:"line_number"`
+	File          string     `json:"file"`
+	LineContent   string     `json:"line_content"`
+	LineNumber    int        `json:"line_number"`
+	Column        int        `json:"column"`
+	LineTruncated bool       `json:"line_truncated,omitempty"`
+	Location      string     `json:"location,omitempty"`
+	Before        []GrepLine `json:"before,omitempty"`
+	After         []GrepLine `json:"after,omitempty"`
+}
+
+// GrepLine is a context line around a match.
+type GrepLine struct {
+	LineNumber    int    `json:"line_number"`
+	LineContent   string `json:"line_content"`
+	LineTruncated bool   `json:"line_truncated,omitempty"`
+}
+
+// GrepFileCount is a file with matches. Count is the number of matching lines;
+// it is left out in files-with-matches mode, which stops at the first match.
+type GrepFileCount struct {
+	File  string `json:"file"`
+	Count int    `json:"count,omitempty"`
+}
+
+// RenamedPath is a file that moved to a new path with its content unchanged.
+type RenamedPath struct {
+	From string `json:"from"`
+	To   string `json:"to"`
 }
 
 // StatusResult represents the result of a status check.
 type StatusResult struct {
-	Modified       []string `json:"modified"`
-	Added          []string `json:"added"`
-	Deleted        []string `json:"deleted"`
-	UnchangedCount int      `json:"unchanged_count"`
+	Modified       []string      `json:"modified"`
+	Added          []string      `json:"added"`
+	Deleted        []string      `json:"deleted"`
+	Renamed        []RenamedPath `json:"renamed"`
+	AddedDirs      []string      `json:"added_dirs"`
+	DeletedDirs    []string      `json:"deleted_dirs"`
+	UnchangedCount int           `json:"unchanged_count"`
+}
+
+// ChangeCount returns the number of changed files and directories.
+func (r *StatusResult) ChangeCount() int {
+	return len(r.Modified) + len(r.Added) + len(r.Deleted) + len(r.Renamed) +
+		len(r.AddedDirs) + len(r.DeletedDirs)
+}
+
+// HasChanges reports whether repacking the workspace would produce a different archive.
+func (r *StatusResult) HasChanges() bool {
+	return r.ChangeCount() > 0
 }
 
 // ListFiles lists files and directories in the workspace.
 func ListFiles(contentsDir, relativePath string, recursive bool) ([]FileEntry, error) {
+	return ListFilesGlob(contentsDir, relativePath, recursive, "")
+}
+
+// ListFilesGlob lists files and directories in the workspace whose
+// workspace-relative paths match glob (see PathGlob). An empty glob lists all.
+func ListFilesGlob(contentsDir, relativePath string, recursive bool, glob string) ([]FileEntry, error) {
+	var matcher *PathGlob
+	if glob != "" {
+		g, err := CompileGlob(glob)
+		if err != nil {
+			return nil, err
+		}
+		matcher = g
+	}
+
 	// Validate relative path
 	if relativePath != "" && relativePath != "." {
 		if err := security.ValidateRelativePath(relativePath); err != nil {
@@ -47,21 +137,23 @@ func ListFiles(contentsDir, relativePath string, recursive bool) ([]FileEntry, e
 		}
 	}
 
-	// Construct absolute path
-	targetPath := filepath.Join(contentsDir, relativePath)
-
 	// Validate the resolved path is within contents directory
 	if err := security.ValidatePath(contentsDir, relativePath); err != nil {
 		return nil, errors.PathTraversal(relativePath)
 	}
 
-	// Check if path exists
-	info, err := os.Stat(targetPath)
+	root, err := openContentsRoot(contentsDir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.PathNotFound(relativePath)
-		}
-		return nil, fmt.Errorf("failed to stat path: %w", err)
+		return nil, err
+	}
+	defer root.Close()
+
+	targetName := rootName(relativePath)
+
+	// Check if path exists, without following a final symlink
+	info, err := root.Lstat(targetName)
+	if err != nil {
+		return nil, rootedError(err, relativePath, "stat path")
 	}
 
 	var entries []FileEntry
@@ -69,70 +161,59 @@ func ListFiles(contentsDir, relativePath string, recursive bool) ([]FileEntry, e
 	if !recursive {
 		// List only immediate children
 		if !info.IsDir() {
-			// If it's a file, return just that file
-			return []FileEntry{
-				{
-					Name:       filepath.Base(targetPath),
-					Type:       "file",
-					SizeBytes:  uint64(info.Size()),
-					ModifiedAt: info.ModTime().Unix(),
-				},
-			}, nil
+			// If it's a file or symlink, return just that entry
+			if !matcher.Match(targetName) {
+				return nil, nil
+			}
+			return []FileEntry{newFileEntry(root, path.Base(targetName), targetName, info)}, nil
 		}
 
 		// List directory contents
-		dirEntries, err := os.ReadDir(targetPath)
+		dirEntries, err := fs.ReadDir(root.FS(), targetName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read directory: %w", err)
+			return nil, rootedError(err, relativePath, "read directory")
 		}
 
 		for _, entry := range dirEntries {
-			entryInfo, err := entry.Info()
-			if err != nil {
+			if !matcher.Match(path.Join(targetName, entry.Name())) {
 				continue
 			}
 
-			entryType := "file"
-			if entry.IsDir() {
-				entryType = "dir"
+			entryInfo, err := entry.Info()
+			if err != nil {
+				continue
 			}
 
-			entries = append(entries, FileEntry{
-				Name:       entry.Name(),
-				Type:       entryType,
-				SizeBytes:  uint64(entryInfo.Size()),
-				ModifiedAt: entryInfo.ModTime().Unix(),
-			})
+			entries = append(entries, newFileEntry(root, entry.Name(), path.Join(targetName, entry.Name()), entryInfo))
 		}
 	} else {
-		// Recursive listing
-		err := filepath.Walk(targetPath, func(path string, info fs.FileInfo, err error) error {
+		// Recursive listing; WalkDir never descends into symlinked directories
+		err := fs.WalkDir(root.FS(), targetName, func(name string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
 
 			// Skip the root directory itself
-			if path == targetPath {
+			if name == targetName {
 				return nil
 			}
 
-			// Get relative path from target
-			relPath, err := filepath.Rel(targetPath, path)
+			if !matcher.Match(name) {
+				return nil
+			}
+
+			info, err := d.Info()
 			if err != nil {
 				return err
 			}
 
-			entryType := "file"
-			if info.IsDir() {
-				entryType = "dir"
+			// Get relative path from target
+			relPath, err := filepath.Rel(filepath.FromSlash(targetName), filepath.FromSlash(name))
+			if err != nil {
+				return err
 			}
 
-			entries = append(entries, FileEntry{
-				Name:       relPath,
-				Type:       entryType,
-				SizeBytes:  uint64(info.Size()),
-				ModifiedAt: info.ModTime().Unix(),
-			})
+			entries = append(entries, newFileEntry(root, relPath, name, info))
 
 			return nil
 		})
@@ -154,26 +235,28 @@ func TreeView(contentsDir, relativePath string, maxDepth int) (string, int, int,
 		}
 	}
 
-	// Construct absolute path
-	targetPath := filepath.Join(contentsDir, relativePath)
-
 	// Validate the resolved path is within contents directory
 	if err := security.ValidatePath(contentsDir, relativePath); err != nil {
 		return "", 0, 0, errors.PathTraversal(relativePath)
 	}
 
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	defer root.Close()
+
+	targetName := rootName(relativePath)
+
 	// Check if path exists
-	if _, err := os.Stat(targetPath); err != nil {
-		if os.IsNotExist(err) {
-			return "", 0, 0, errors.PathNotFound(relativePath)
-		}
-		return "", 0, 0, fmt.Errorf("failed to stat path: %w", err)
+	if _, err := root.Stat(targetName); err != nil {
+		return "", 0, 0, rootedError(err, relativePath, "stat path")
 	}
 
 	var sb strings.Builder
 	var fileCount, dirCount int
 
-	err := buildTree(&sb, targetPath, "", 0, maxDepth, &fileCount, &dirCount)
+	err = buildTree(&sb, root.FS(), targetName, "", 0, maxDepth, &fileCount, &dirCount)
 	if err != nil {
 		return "", 0, 0, fmt.Errorf("failed to build tree: %w", err)
 	}
@@ -182,12 +265,12 @@ func TreeView(contentsDir, relativePath string, maxDepth int) (string, int, int,
 }
 
 // buildTree recursively builds the tree structure.
-func buildTree(sb *strings.Builder, path, prefix string, depth, maxDepth int, fileCount, dirCount *int) error {
+func buildTree(sb *strings.Builder, fsys fs.FS, dir, prefix string, depth, maxDepth int, fileCount, dirCount *int) error {
 	if maxDepth > 0 && depth >= maxDepth {
 		return nil
 	}
 
-	entries, err := os.ReadDir(path)
+	entries, err := fs.ReadDir(fsys, dir)
 	if err != nil {
 		return err
 	}
@@ -221,8 +304,8 @@ func buildTree(sb *strings.Builder, path, prefix string, depth, maxDepth int, fi
 
 		// Recurse into directories
 		if entry.IsDir() {
-			childPath := filepath.Join(path, entry.Name())
-			if err := buildTree(sb, childPath, childPrefix, depth+1, maxDepth, fileCount, dirCount); err != nil {
+			childDir := path.Join(dir, entry.Name())
+			if err := buildTree(sb, fsys, childDir, childPrefix, depth+1, maxDepth, fileCount, dirCount); err != nil {
 				return err
 			}
 		}
@@ -243,23 +326,25 @@ func ReadFile(contentsDir, relativePath string) ([]byte, error) {
 		return nil, errors.PathTraversal(relativePath)
 	}
 
-	// Construct absolute path
-	targetPath := filepath.Join(contentsDir, relativePath)
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
 
-	// Read the file
-	data, err := os.ReadFile(targetPath)
+	// Read the file; symlinks may not lead outside the contents directory
+	data, err := root.ReadFile(rootName(relativePath))
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.PathNotFound(relativePath)
-		}
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, rootedError(err, relativePath, "read file")
 	}
 
 	return data, nil
 }
 
-// WriteFile writes data to a file in the workspace.
-func WriteFile(contentsDir, relativePath string, content []byte, createDirs bool) error {
+// WriteFile writes data to a file in the workspace, or stages the write while a
+// transaction is open (see Transaction).
+// When cfg is non-nil, growth is checked against the global disk budget first.
+func WriteFile(contentsDir, relativePath string, content []byte, createDirs bool, cfg *Config) error {
 	// Validate relative path
 	if err := security.ValidateRelativePath(relativePath); err != nil {
 		return fmt.Errorf("invalid path: %w", err)
@@ -270,26 +355,43 @@ func WriteFile(contentsDir, relativePath string, content []byte, createDirs bool
 		return errors.PathTraversal(relativePath)
 	}
 
-	// Construct absolute path
-	targetPath := filepath.Join(contentsDir, relativePath)
+	// Inside a transaction the write is staged instead
+	if staged, err := stageWrite(contentsDir, relativePath, content, createDirs, cfg); staged {
+		return err
+	}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	name := rootName(relativePath)
+
+	// Refuse writes that would push all workspaces past MaxTotalDiskBytes
+	if err := ensureWriteBudget(cfg, root, name, int64(len(content))); err != nil {
+		return err
+	}
 
 	// Create parent directories if requested
 	if createDirs {
-		parentDir := filepath.Dir(targetPath)
-		if err := os.MkdirAll(parentDir, 0755); err != nil {
-			return fmt.Errorf("failed to create parent directories: %w", err)
+		if err := root.MkdirAll(path.Dir(name), 0755); err != nil {
+			return rootedError(err, relativePath, "create parent directories")
 		}
 	}
 
-	// Write the file
-	if err := os.WriteFile(targetPath, content, 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	// Write the file; symlinks may not lead outside the contents directory
+	if err := root.WriteFile(name, content, 0644); err != nil {
+		return rootedError(err, relativePath, "write file")
 	}
 
+	markSearchIndexStale(contentsDir, name)
+
 	return nil
 }
 
-// DeleteFile deletes a file or directory from the workspace.
+// DeleteFile deletes a file or directory from the workspace, or stages the
+// delete while a transaction is open (see Transaction).
 func DeleteFile(contentsDir, relativePath string, recursive bool) error {
 	// Validate relative path
 	if err := security.ValidateRelativePath(relativePath); err != nil {
@@ -301,16 +403,23 @@ func DeleteFile(contentsDir, relativePath string, recursive bool) error {
 		return errors.PathTraversal(relativePath)
 	}
 
-	// Construct absolute path
-	targetPath := filepath.Join(contentsDir, relativePath)
+	// Inside a transaction the delete is staged instead
+	if staged, err := stageDeletes(contentsDir, []string{relativePath}, recursive); staged {
+		return err
+	}
 
-	// Check if path exists
-	info, err := os.Stat(targetPath)
+	root, err := openContentsRoot(contentsDir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return errors.PathNotFound(relativePath)
-		}
-		return fmt.Errorf("failed to stat path: %w", err)
+		return err
+	}
+	defer root.Close()
+
+	name := rootName(relativePath)
+
+	// Check if path exists; a symlink is removed itself, never its target
+	info, err := root.Lstat(name)
+	if err != nil {
+		return rootedError(err, relativePath, "stat path")
 	}
 
 	// If it's a directory and recursive is not set, error
@@ -320,105 +429,405 @@ func DeleteFile(contentsDir, relativePath string, recursive bool) error {
 
 	// Delete the file or directory
 	if recursive {
-		if err := os.RemoveAll(targetPath); err != nil {
-			return fmt.Errorf("failed to remove path: %w", err)
+		if err := root.RemoveAll(name); err != nil {
+			return rootedError(err, relativePath, "remove path")
 		}
 	} else {
-		if err := os.Remove(targetPath); err != nil {
-			return fmt.Errorf("failed to remove file: %w", err)
+		if err := root.Remove(name); err != nil {
+			return rootedError(err, relativePath, "remove file")
 		}
 	}
 
+	markSearchIndexStale(contentsDir, name)
+
 	return nil
 }
 
+// DeleteGlob deletes every workspace entry whose path matches glob (see
+// PathGlob) and returns the deleted paths in lexical order. A matching directory
+// is removed with its contents, which are not listed separately; without
+// recursive it is an error. Patterns that name the workspace root itself, such
+// as "**" or ".", are refused. With dryRun nothing is removed. On error the
+// paths already deleted are returned along with it. While a transaction is open
+// the matches, taken from the committed contents, are staged as deletes.
+func DeleteGlob(contentsDir, glob string, recursive, dryRun bool) ([]string, error) {
+	matcher, err := CompileGlob(glob)
+	if err != nil {
+		return nil, err
+	}
+	if matcher.namesRoot() {
+		return nil, fmt.Errorf("glob %q matches the workspace root", glob)
+	}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	// Collect first so the walk never sees its own deletions; WalkDir never
+	// descends into symlinked directories
+	matches := []string{}
+	err = fs.WalkDir(root.FS(), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." || !matcher.Match(name) {
+			return nil
+		}
+
+		if d.IsDir() {
+			if !recursive {
+				return fmt.Errorf("%s is a directory, use recursive=true to delete", name)
+			}
+			matches = append(matches, name)
+			return fs.SkipDir
+		}
+		matches = append(matches, name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	if dryRun {
+		return matches, nil
+	}
+
+	// Inside a transaction the matches are staged instead
+	if staged, err := stageDeletes(contentsDir, matches, recursive); staged {
+		if err != nil {
+			return nil, err
+		}
+		return matches, nil
+	}
+
+	deleted := make([]string, 0, len(matches))
+	defer func() {
+		// One journal append for the whole batch
+		markSearchIndexStale(contentsDir, deleted...)
+	}()
+
+	for _, name := range matches {
+		if err := root.RemoveAll(name); err != nil {
+			return deleted, rootedError(err, name, "remove path")
+		}
+		deleted = append(deleted, name)
+	}
+
+	return deleted, nil
+}
+
+// MoveFile moves or renames a file or directory within the workspace.
+// Missing parent directories of dst are created. An existing dst is replaced only
+// when overwrite is set; otherwise a PATH_EXISTS error is returned.
+func MoveFile(contentsDir, src, dst string, overwrite bool) error {
+	// Validate both paths
+	for _, p := range []string{src, dst} {
+		if err := security.ValidateRelativePath(p); err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+		if err := security.ValidatePath(contentsDir, p); err != nil {
+			return errors.PathTraversal(p)
+		}
+	}
+
+	srcName := rootName(src)
+	dstName := rootName(dst)
+
+	if srcName == "." || dstName == "." {
+		return fmt.Errorf("cannot move the workspace root")
+	}
+	if err := EnsureNoTx(contentsDir); err != nil {
+		return err
+	}
+	if srcName == dstName {
+		return nil
+	}
+	if strings.HasPrefix(dstName, srcName+"/") {
+		return fmt.Errorf("cannot move %q into itself", src)
+	}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	// Check the source exists; a symlink is moved itself, never its target
+	srcInfo, err := root.Lstat(srcName)
+	if err != nil {
+		return rootedError(err, src, "stat source")
+	}
+
+	// Protect an existing destination unless overwrite is requested. Rename
+	// replaces a file atomically, so dst is only removed up front when rename
+	// cannot replace it: when either side is a directory.
+	if dstInfo, err := root.Lstat(dstName); err == nil {
+		if !overwrite {
+			return errors.PathExists(dst)
+		}
+		if srcInfo.IsDir() || dstInfo.IsDir() {
+			if err := root.RemoveAll(dstName); err != nil {
+				return rootedError(err, dst, "remove destination")
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return rootedError(err, dst, "stat destination")
+	}
+
+	// Create parent directories of the destination
+	if err := root.MkdirAll(path.Dir(dstName), 0755); err != nil {
+		return rootedError(err, dst, "create parent directories")
+	}
+
+	// Rename keeps mode and modification time
+	if err := root.Rename(srcName, dstName); err != nil {
+		return rootedError(err, dst, "move path")
+	}
+	markSearchIndexStale(contentsDir, srcName, dstName)
+
+	return nil
+}
+
+// Reasons a grep stopped before searching every candidate file.
+const (
+	GrepTruncatedTimeout   = "timeout"   // RegexTimeoutMS elapsed
+	GrepTruncatedCancelled = "cancelled" // the caller's context was cancelled
+	GrepTruncatedMaxBytes  = "max_bytes" // MaxGrepBytes were scanned
+)
+
+// GrepExcerptBytes is the longest line content returned for a match or context line.
+const GrepExcerptBytes = 512
+
+// GrepOptions controls a GrepFiles call.
+type GrepOptions struct {
+	Glob       string // path glob over workspace-relative paths; see PathGlob
+	IgnoreCase bool
+	MaxResults int           // 0 means unlimited; counts files in the Files modes
+	Timeout    time.Duration // 0 means no timeout beyond the caller's context
+	MaxBytes   uint64        // total bytes scanned across all files; 0 means unlimited
+
+	BeforeContext    int  // lines of context before each match
+	AfterContext     int  // lines of context after each match
+	FilesWithMatches bool // list matching files instead of matches
+	Count            bool // count matching lines per file instead of listing matches
+	IncludeBinary    bool // search files with a NUL byte near the start, skipped by default
+	Documents        bool // search the text of Office documents (OOXML and ODF)
+	Index            bool // use, and build if missing, the workspace's trigram index
+}
+
+// GrepResult holds the matches found by GrepFiles. In the FilesWithMatches and
+// Count modes, Files is filled in instead of Matches.
+// When TruncatedReason is set, the results are partial results found before stopping.
+type GrepResult struct {
+	Matches         []GrepMatch     `json:"matches"`
+	Files           []GrepFileCount `json:"files,omitempty"`
+	TotalMatches    int             `json:"total_matches"`
+	BytesScanned    uint64          `json:"bytes_scanned"`
+	SkippedBinary   int             `json:"skipped_binary,omitempty"`
+	IndexSkipped    int             `json:"index_skipped,omitempty"` // files ruled out by the search index
+	TruncatedReason string          `json:"truncated_reason,omitempty"`
+}
+
+// GrepOptions returns grep options carrying the configured timeout and byte limit.
+func (c *Config) GrepOptions() GrepOptions {
+	return GrepOptions{
+		Timeout:  time.Duration(c.Security.RegexTimeoutMS) * time.Millisecond,
+		MaxBytes: c.Security.MaxGrepBytes,
+		Index:    c.Defaults.SearchIndex == SearchIndexLazy || c.Defaults.SearchIndex == SearchIndexOpen,
+	}
+}
+
+// grepScan tracks the time and byte budget shared by every file in one GrepFiles call.
+type grepScan struct {
+	ctx          context.Context
+	maxBytes     uint64
+	bytesScanned uint64
+	stopReason   string
+}
+
+// stopped reports whether the scan must end, recording the reason the first time.
+func (s *grepScan) stopped() bool {
+	if s.stopReason != "" {
+		return true
+	}
+
+	switch s.ctx.Err() {
+	case nil:
+	case context.Canceled:
+		s.stopReason = GrepTruncatedCancelled
+		return true
+	default:
+		s.stopReason = GrepTruncatedTimeout
+		return true
+	}
+
+	if s.maxBytes > 0 && s.bytesScanned > s.maxBytes {
+		s.stopReason = GrepTruncatedMaxBytes
+		return true
+	}
+
+	return false
+}
+
 // GrepFiles searches for a pattern in files within the workspace.
-func GrepFiles(contentsDir, relativePath, pattern, glob string, ignoreCase bool, maxResults int) ([]GrepMatch, int, error) {
+// It stops early, returning partial results, when ctx is cancelled, opts.Timeout
+// elapses or opts.MaxBytes have been scanned.
+func GrepFiles(ctx context.Context, contentsDir, relativePath, pattern string, opts GrepOptions) (*GrepResult, error) {
 	// Validate relative path
 	if relativePath != "" && relativePath != "." {
 		if err := security.ValidateRelativePath(relativePath); err != nil {
-			return nil, 0, fmt.Errorf("invalid path: %w", err)
+			return nil, fmt.Errorf("invalid path: %w", err)
 		}
 	}
 
 	// Validate glob pattern
-	if glob != "" {
-		if err := security.SanitizeGlobPattern(glob); err != nil {
-			return nil, 0, fmt.Errorf("invalid glob pattern: %w", err)
+	var glob *PathGlob
+	if opts.Glob != "" {
+		g, err := CompileGlob(opts.Glob)
+		if err != nil {
+			return nil, err
 		}
+		glob = g
 	}
 
-	// Construct absolute path
-	targetPath := filepath.Join(contentsDir, relativePath)
-
 	// Validate the resolved path is within contents directory
 	if err := security.ValidatePath(contentsDir, relativePath); err != nil {
-		return nil, 0, errors.PathTraversal(relativePath)
+		return nil, errors.PathTraversal(relativePath)
 	}
 
 	// Compile regex pattern
 	var re *regexp.Regexp
 	var err error
-	if ignoreCase {
+	if opts.IgnoreCase {
 		re, err = regexp.Compile("(?i)" + pattern)
 	} else {
 		re, err = regexp.Compile(pattern)
 	}
 	if err != nil {
-		return nil, 0, fmt.Errorf("invalid regex pattern: %w", err)
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
 	}
 
+	// Bound the whole search by the configured timeout
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	targetName := rootName(relativePath)
+	if _, err := root.Stat(targetName); err != nil {
+		return nil, rootedError(err, relativePath, "stat path")
+	}
+
+	scan := &grepScan{ctx: ctx, maxBytes: opts.MaxBytes}
+	maxResults := opts.MaxResults
+	filesMode := opts.FilesWithMatches || opts.Count
+
 	var matches []GrepMatch
-	var totalMatches int
+	var files []GrepFileCount
+	var totalMatches, skippedBinary, indexSkipped int
+
+	// The index only helps when the pattern requires some literal text
+	var index *searchIndex
+	var query []uint32
+	seen := make(map[string]bool)
+	if opts.Index {
+		if query = regexTrigrams(re.String()); len(query) > 0 {
+			index, _ = loadSearchIndex(searchIndexPath(contentsDir))
+		}
+	}
 
-	// Walk the directory tree
-	err = filepath.Walk(targetPath, func(path string, info fs.FileInfo, err error) error {
+	// Walk the directory tree; WalkDir never descends into symlinked directories
+	err = fs.WalkDir(root.FS(), targetName, func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
+		if scan.stopped() {
+			return fs.SkipAll
+		}
+
 		// Skip directories
-		if info.IsDir() {
+		if d.IsDir() {
 			return nil
 		}
+		seen[name] = true
 
 		// Apply glob filter if specified
-		if glob != "" {
-			matched, err := filepath.Match(glob, filepath.Base(path))
-			if err != nil {
-				return fmt.Errorf("glob match error: %w", err)
-			}
-			if !matched {
+		if !glob.Match(name) {
+			return nil
+		}
+
+		// Search the file; symlinks escaping the root fail to open and are skipped
+		// Rule out regular files whose indexed trigrams cannot match
+		kind := documentKind(name)
+		if index != nil && d.Type().IsRegular() && !(opts.Documents && kind != "") {
+			if info, err := d.Info(); err == nil && !index.refresh(root, name, info).mayContain(query) {
+				indexSkipped++
 				return nil
 			}
 		}
 
-		// Get relative path from contents directory
-		relPath, err := filepath.Rel(contentsDir, path)
-		if err != nil {
-			return err
+		var fileMatches []GrepMatch
+		var count int
+		if opts.Documents && kind != "" {
+			fileMatches, count, err = grepDocument(scan, root, name, filepath.FromSlash(name), kind, re, opts, maxResults-len(matches))
+		} else {
+			fileMatches, count, err = grepFile(scan, root, name, filepath.FromSlash(name), re, opts, maxResults-len(matches))
+		}
+		if err == errBinaryFile {
+			skippedBinary++
+			return nil
 		}
-
-		// Search the file
-		fileMatches, err := grepFile(path, relPath, re, maxResults-len(matches))
 		if err != nil {
 			// Skip files that can't be read
 			return nil
 		}
 
-		totalMatches += len(fileMatches)
+		totalMatches += count
+
+		if filesMode {
+			if count > 0 {
+				entry := GrepFileCount{File: filepath.FromSlash(name)}
+				if opts.Count {
+					entry.Count = count
+				}
+				files = append(files, entry)
+			}
+			if maxResults > 0 && len(files) >= maxResults {
+				return fs.SkipAll
+			}
+			return nil
+		}
+
 		matches = append(matches, fileMatches...)
 
 		// Stop if we've reached max results
 		if maxResults > 0 && len(matches) >= maxResults {
-			return filepath.SkipAll
+			return fs.SkipAll
 		}
 
 		return nil
 	})
 
-	if err != nil && err != filepath.SkipAll {
-		return nil, 0, fmt.Errorf("failed to search files: %w", err)
+	if err != nil && err != fs.SkipAll {
+		return nil, fmt.Errorf("failed to search files: %w", err)
+	}
+
+	// Only a walk that saw every file can tell which entries are gone
+	if index != nil {
+		if err == nil && scan.stopReason == "" {
+			index.prune(targetName, seen)
+		}
+		_ = index.save(searchIndexPath(contentsDir))
 	}
 
 	// Trim matches to max results
@@ -426,43 +835,163 @@ func GrepFiles(contentsDir, relativePath, pattern, glob string, ignoreCase bool,
 		matches = matches[:maxResults]
 	}
 
-	return matches, totalMatches, nil
+	return &GrepResult{
+		Matches:         matches,
+		Files:           files,
+		TotalMatches:    totalMatches,
+		BytesScanned:    scan.bytesScanned,
+		SkippedBinary:   skippedBinary,
+		IndexSkipped:    indexSkipped,
+		TruncatedReason: scan.stopReason,
+	}, nil
 }
 
-// grepFile searches for a pattern in a single file.
-func grepFile(path, relPath string, re *regexp.Regexp, maxMatches int) ([]GrepMatch, error) {
-	file, err := os.Open(path)
+// errBinaryFile reports a file skipped by grepFile because it looks binary.
+var errBinaryFile = fmt.Errorf("binary file")
+
+// grepFile searches for a pattern in a single file and returns the matches along
+// with the number of matching lines. Lines are read whole however long they are,
+// unless they run past the scan's byte budget.
+// In the Files modes no matches are built; FilesWithMatches stops at the first one.
+// Matches found before the scan budget runs out are returned without error.
+func grepFile(scan *grepScan, root *os.Root, name, relPath string, re *regexp.Regexp, opts GrepOptions, maxMatches int) ([]GrepMatch, int, error) {
+	file, err := root.Open(name)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer file.Close()
 
+	reader := bufio.NewReaderSize(file, 64*1024)
+
+	if !opts.IncludeBinary {
+		head, err := reader.Peek(binarySniffBytes)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, 0, err
+		}
+		if isBinary(head) {
+			return nil, 0, errBinaryFile
+		}
+	}
+
+	filesMode := opts.FilesWithMatches || opts.Count
 	var matches []GrepMatch
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
+	var before []GrepLine
+	count, afterLeft, lineNum := 0, 0, 0
+	var line []byte
+
+	for {
+		// Lines past the rest of the byte budget are not read whole
+		var limit uint64
+		if scan.maxBytes > 0 {
+			limit = scan.maxBytes - min(scan.bytesScanned, scan.maxBytes) + 1
+		}
 
-	for scanner.Scan() {
+		line, err = readGrepLine(reader, line[:0], limit)
+		if len(line) == 0 && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
 		lineNum++
-		line := scanner.Text()
 
-		if re.MatchString(line) {
-			matches = append(matches, GrepMatch{
-				File:        relPath,
-				LineNumber:  lineNum,
-				LineContent: line,
-			})
+		// Count the line and its newline against the byte budget before matching
+		scan.bytesScanned += uint64(len(line)) + 1
+		if scan.stopped() {
+			return matches, count, nil
+		}
+
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		full := maxMatches > 0 && len(matches) >= maxMatches
+
+		if !full {
+			if loc := re.FindIndex(line); loc != nil {
+				count++
+				if opts.FilesWithMatches {
+					break
+				}
+				if !filesMode {
+					content, truncated := grepExcerpt(line, loc[0])
+					matches = append(matches, GrepMatch{
+						File:          relPath,
+						LineNumber:    lineNum,
+						LineContent:   content,
+						Column:        loc[0] + 1,
+						LineTruncated: truncated,
+						Before:        before,
+					})
+					before = nil
+					afterLeft = opts.AfterContext
+				}
+				continue
+			}
+		}
+
+		if filesMode {
+			continue
+		}
 
-			if maxMatches > 0 && len(matches) >= maxMatches {
-				break
+		// Context lines belong to one match only, so none is reported twice
+		if afterLeft > 0 {
+			content, truncated := grepExcerpt(line, 0)
+			last := &matches[len(matches)-1]
+			last.After = append(last.After, GrepLine{LineNumber: lineNum, LineContent: content, LineTruncated: truncated})
+			afterLeft--
+			continue
+		}
+		if full {
+			break
+		}
+		if opts.BeforeContext > 0 {
+			content, truncated := grepExcerpt(line, 0)
+			if len(before) == opts.BeforeContext {
+				before = append(before[:0:0], before[1:]...)
 			}
+			before = append(before, GrepLine{LineNumber: lineNum, LineContent: content, LineTruncated: truncated})
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	return matches, count, nil
+}
+
+// readGrepLine appends the next line, without its newline, to buf. Unlike
+// bufio.Scanner there is no limit on line length, except that a positive limit
+// stops reading once the line has grown past it, leaving the rest unread: the
+// scan budget is spent by then, so the line would never be matched anyway.
+func readGrepLine(reader *bufio.Reader, buf []byte, limit uint64) ([]byte, error) {
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		buf = append(buf, chunk...)
+		if err == bufio.ErrBufferFull {
+			if limit > 0 && uint64(len(buf)) > limit {
+				return buf, nil
+			}
+			continue
+		}
+		return bytes.TrimSuffix(buf, []byte("\n")), err
 	}
+}
 
-	return matches, nil
+// grepExcerpt returns the line as a string, or for lines longer than
+// GrepExcerptBytes a window that starts shortly before offset.
+func grepExcerpt(line []byte, offset int) (string, bool) {
+	if len(line) <= GrepExcerptBytes {
+		return string(line), false
+	}
+
+	start := max(offset-GrepExcerptBytes/4, 0)
+	end := min(start+GrepExcerptBytes, len(line))
+	start = max(end-GrepExcerptBytes, 0)
+
+	// Move both ends onto rune boundaries
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start++
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end--
+	}
+
+	return string(line[start:end]), true
 }
 
 // Status compares the current workspace contents with the original zip.
@@ -486,33 +1015,54 @@ func Status(session *Session) (*StatusResult, error) {
 	}
 	defer zipReader.Close()
 
-	// Build map of original files
+	// Symlinks skipped at extraction are carried over by sync, so they are not deleted
+	skipped := make(map[string]bool, len(session.SkippedSymlinks))
+	for _, name := range session.SkippedSymlinks {
+		skipped[name] = true
+	}
+
+	// Build maps of original files and directories. Repack writes an entry for every
+	// directory, so directories implied by entry paths count as well.
 	originalFiles := make(map[string]*zip.File)
+	originalDirs := make(map[string]bool)
 	for _, f := range zipReader.File {
-		if !f.FileInfo().IsDir() {
+		name := strings.TrimSuffix(f.Name, "/")
+		if f.FileInfo().IsDir() {
+			originalDirs[name] = true
+		} else {
 			originalFiles[f.Name] = f
+			if skipped[f.Name] {
+				continue
+			}
+		}
+		for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			originalDirs[dir] = true
 		}
 	}
 
-	// Build map of current files
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	// Build maps of current files and directories; WalkDir never descends into
+	// symlinked directories
 	currentFiles := make(map[string]bool)
-	err = filepath.Walk(contentsDir, func(path string, info fs.FileInfo, err error) error {
+	currentDirs := make(map[string]bool)
+	err = fs.WalkDir(root.FS(), ".", func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if info.IsDir() {
+		if d.IsDir() {
+			if name != "." {
+				currentDirs[name] = true
+			}
 			return nil
 		}
 
-		relPath, err := filepath.Rel(contentsDir, path)
-		if err != nil {
-			return err
-		}
-
-		// Normalize to forward slashes
-		relPath = filepath.ToSlash(relPath)
-		currentFiles[relPath] = true
+		currentFiles[name] = true
 
 		return nil
 	})
@@ -521,21 +1071,33 @@ func Status(session *Session) (*StatusResult, error) {
 	}
 
 	result := &StatusResult{
-		Modified: []string{},
-		Added:    []string{},
-		Deleted:  []string{},
+		Modified:    []string{},
+		Added:       []string{},
+		Deleted:     []string{},
+		Renamed:     []RenamedPath{},
+		AddedDirs:   []string{},
+		DeletedDirs: []string{},
 	}
 
 	// Find modified and added files
 	for currentPath := range currentFiles {
 		if originalFile, exists := originalFiles[currentPath]; exists {
 			// File exists in both - check if modified
-			currentFullPath := filepath.Join(contentsDir, filepath.FromSlash(currentPath))
-			currentInfo, err := os.Stat(currentFullPath)
+			currentInfo, err := root.Lstat(currentPath)
 			if err != nil {
 				continue
 			}
 
+			// Symlinks are compared by target; mtimes of links are not preserved
+			if currentInfo.Mode()&os.ModeSymlink != 0 || originalFile.Mode()&os.ModeSymlink != 0 {
+				if symlinkChanged(root, currentPath, currentInfo, originalFile) {
+					result.Modified = append(result.Modified, currentPath)
+				} else {
+					result.UnchangedCount++
+				}
+				continue
+			}
+
 			// Compare size and modification time (truncate to second for filesystem compatibility)
 			if uint64(currentInfo.Size()) != originalFile.UncompressedSize64 ||
 				!currentInfo.ModTime().Truncate(time.Second).Equal(originalFile.Modified.Truncate(time.Second)) {
@@ -551,10 +1113,165 @@ func Status(session *Session) (*StatusResult, error) {
 
 	// Find deleted files
 	for originalPath := range originalFiles {
-		if !currentFiles[originalPath] {
+		if !currentFiles[originalPath] && !skipped[originalPath] {
 			result.Deleted = append(result.Deleted, originalPath)
 		}
 	}
 
+	// Pair deleted and added files with identical content as renames
+	detectRenames(root, originalFiles, result)
+
+	// Find added and deleted directories
+	for dir := range currentDirs {
+		if !originalDirs[dir] {
+			result.AddedDirs = append(result.AddedDirs, dir)
+		}
+	}
+	for dir := range originalDirs {
+		if !currentDirs[dir] {
+			result.DeletedDirs = append(result.DeletedDirs, dir)
+		}
+	}
+	sort.Strings(result.AddedDirs)
+	sort.Strings(result.DeletedDirs)
+
 	return result, nil
 }
+
+// symlinkChanged reports whether a workspace entry differs from an original entry
+// when either of them is a symlink.
+func symlinkChanged(root *os.Root, currentPath string, currentInfo fs.FileInfo, original *zip.File) bool {
+	if currentInfo.Mode()&os.ModeSymlink == 0 || original.Mode()&os.ModeSymlink == 0 {
+		return true
+	}
+
+	currentTarget, err := root.Readlink(currentPath)
+	if err != nil {
+		return true
+	}
+
+	originalTarget, err := readSymlinkTarget(original)
+	if err != nil {
+		return true
+	}
+
+	return currentTarget != originalTarget
+}
+
+// detectRenames moves pairs of deleted and added files with identical content from
+// result.Deleted and result.Added into result.Renamed. Candidates are narrowed by
+// size and CRC-32 before their SHA-256 hashes are compared.
+func detectRenames(root *os.Root, originalFiles map[string]*zip.File, result *StatusResult) {
+	if len(result.Deleted) == 0 || len(result.Added) == 0 {
+		return
+	}
+
+	sort.Strings(result.Deleted)
+	sort.Strings(result.Added)
+
+	// Index deleted regular files by size
+	bySize := make(map[uint64][]string)
+	for _, name := range result.Deleted {
+		f := originalFiles[name]
+		if f == nil || !f.Mode().IsRegular() {
+			continue
+		}
+		bySize[f.UncompressedSize64] = append(bySize[f.UncompressedSize64], name)
+	}
+
+	matched := make(map[string]bool)
+	var added []string
+
+	for _, name := range result.Added {
+		from := findRenameSource(root, name, originalFiles, bySize, matched)
+		if from == "" {
+			added = append(added, name)
+			continue
+		}
+		matched[from] = true
+		result.Renamed = append(result.Renamed, RenamedPath{From: from, To: name})
+	}
+
+	if len(result.Renamed) == 0 {
+		return
+	}
+
+	var deleted []string
+	for _, name := range result.Deleted {
+		if !matched[name] {
+			deleted = append(deleted, name)
+		}
+	}
+
+	result.Added = append([]string{}, added...)
+	result.Deleted = append([]string{}, deleted...)
+}
+
+// findRenameSource returns the unmatched deleted file whose content equals the
+// workspace file at name, or "" if there is none.
+func findRenameSource(root *os.Root, name string, originalFiles map[string]*zip.File, bySize map[uint64][]string, matched map[string]bool) string {
+	info, err := root.Lstat(name)
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+
+	candidates := bySize[uint64(info.Size())]
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	file, err := root.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	crcHash := crc32.NewIEEE()
+	shaHash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(crcHash, shaHash), file); err != nil {
+		return ""
+	}
+	currentCRC := crcHash.Sum32()
+	var currentHash [sha256.Size]byte
+	copy(currentHash[:], shaHash.Sum(nil))
+
+	for _, candidate := range candidates {
+		if matched[candidate] {
+			continue
+		}
+
+		original := originalFiles[candidate]
+		if original.CRC32 != currentCRC {
+			continue
+		}
+
+		originalHash, err := zipEntryHash(original)
+		if err != nil {
+			continue
+		}
+		if originalHash == currentHash {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// zipEntryHash returns the SHA-256 of a zip entry's uncompressed content.
+func zipEntryHash(f *zip.File) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	rc, err := f.Open()
+	if err != nil {
+		return sum, err
+	}
+	defer rc.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, rc); err != nil {
+		return sum, err
+	}
+
+	copy(sum[:], hash.Sum(nil))
+	return sum, nil
+}
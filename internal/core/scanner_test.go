@@ -1,12 +1,16 @@
 package core
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Fuabioo/zipfs/internal/errors"
 )
@@ -52,6 +56,45 @@ func TestListFiles_Recursive(t *testing.T) {
 	}
 }
 
+func TestListFilesGlob(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(filepath.Join(contentsDir, "xl", "media"), 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, "xl", "workbook.xml"), []byte("c1"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "xl", "media", "image1.png"), []byte("c2"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "xl", "media", "image2.jpg"), []byte("c3"), 0644)
+
+	names := func(entries []FileEntry) string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	entries, err := ListFilesGlob(contentsDir, ".", true, "xl/**/*.{png,xml}")
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+	if got := names(entries); got != "xl/media/image1.png,xl/workbook.xml" {
+		t.Errorf("unexpected recursive glob listing: %s", got)
+	}
+
+	// Non-recursive listings match the workspace-relative path of each entry
+	entries, err = ListFilesGlob(contentsDir, "xl/media", false, "!*.jpg")
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+	if got := names(entries); got != "image1.png" {
+		t.Errorf("unexpected negated glob listing: %s", got)
+	}
+
+	if _, err := ListFilesGlob(contentsDir, ".", false, "../*"); err == nil {
+		t.Error("expected an escaping glob to fail")
+	}
+}
+
 func TestListFiles_PathTraversal(t *testing.T) {
 	tempDir := t.TempDir()
 	contentsDir := filepath.Join(tempDir, "contents")
@@ -186,7 +229,7 @@ func TestWriteFile_Basic(t *testing.T) {
 	os.MkdirAll(contentsDir, 0755)
 
 	content := []byte("new content")
-	err := WriteFile(contentsDir, "newfile.txt", content, false)
+	err := WriteFile(contentsDir, "newfile.txt", content, false, nil)
 	if err != nil {
 		t.Fatalf("failed to write file: %v", err)
 	}
@@ -208,7 +251,7 @@ func TestWriteFile_CreateDirs(t *testing.T) {
 	os.MkdirAll(contentsDir, 0755)
 
 	content := []byte("nested content")
-	err := WriteFile(contentsDir, "a/b/c/file.txt", content, true)
+	err := WriteFile(contentsDir, "a/b/c/file.txt", content, true, nil)
 	if err != nil {
 		t.Fatalf("failed to write file: %v", err)
 	}
@@ -229,7 +272,7 @@ func TestWriteFile_PathTraversal(t *testing.T) {
 	contentsDir := filepath.Join(tempDir, "contents")
 	os.MkdirAll(contentsDir, 0755)
 
-	err := WriteFile(contentsDir, "../../../tmp/malicious.txt", []byte("bad"), false)
+	err := WriteFile(contentsDir, "../../../tmp/malicious.txt", []byte("bad"), false, nil)
 	if err == nil {
 		t.Fatal("expected error for path traversal")
 	}
@@ -287,6 +330,90 @@ func TestDeleteFile_DirectoryWithoutRecursive(t *testing.T) {
 	}
 }
 
+func TestDeleteGlob(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(filepath.Join(contentsDir, "__MACOSX", "xl"), 0755)
+	os.MkdirAll(filepath.Join(contentsDir, "xl", "__MACOSX"), 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, "__MACOSX", "xl", "._a.xml"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "xl", "__MACOSX", "._b.xml"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "xl", "a.xml"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "xl", "a.tmp"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "b.tmp"), []byte("x"), 0644)
+
+	// Directories need recursive
+	if _, err := DeleteGlob(contentsDir, "__MACOSX", false, false); err == nil {
+		t.Error("expected matching directories to need recursive")
+	}
+
+	// A dry run lists without deleting
+	paths, err := DeleteGlob(contentsDir, "{__MACOSX,*.tmp}", true, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	want := "__MACOSX,b.tmp,xl/__MACOSX,xl/a.tmp"
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("dry run listed %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(contentsDir, "b.tmp")); err != nil {
+		t.Errorf("dry run deleted a file: %v", err)
+	}
+
+	paths, err = DeleteGlob(contentsDir, "{__MACOSX,*.tmp}", true, false)
+	if err != nil {
+		t.Fatalf("DeleteGlob failed: %v", err)
+	}
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("deleted %q, want %q", got, want)
+	}
+
+	entries, err := ListFiles(contentsDir, ".", true)
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "xl" || entries[1].Name != "xl/a.xml" {
+		t.Errorf("unexpected remaining entries: %+v", entries)
+	}
+
+	// Patterns naming the workspace root are refused
+	for _, glob := range []string{"**", "**/**", "{**,*.tmp}", ".", "", "/", "..", "../*"} {
+		if _, err := DeleteGlob(contentsDir, glob, true, true); err == nil {
+			t.Errorf("expected glob %q to be refused", glob)
+		}
+	}
+}
+
+func TestDeleteGlob_Exclusion(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, ".keep"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "a.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "b.txt"), []byte("x"), 0644)
+
+	paths, err := DeleteGlob(contentsDir, "!*.keep", false, false)
+	if err != nil {
+		t.Fatalf("DeleteGlob failed: %v", err)
+	}
+	if got := strings.Join(paths, ","); got != "a.txt,b.txt" {
+		t.Errorf("deleted %q, want %q", got, "a.txt,b.txt")
+	}
+	if _, err := os.Stat(filepath.Join(contentsDir, ".keep")); err != nil {
+		t.Errorf("expected .keep to remain: %v", err)
+	}
+
+	// A precise pattern may match everything left at the top level
+	paths, err = DeleteGlob(contentsDir, "*.keep", false, false)
+	if err != nil {
+		t.Fatalf("DeleteGlob failed: %v", err)
+	}
+	if got := strings.Join(paths, ","); got != ".keep" {
+		t.Errorf("deleted %q, want %q", got, ".keep")
+	}
+}
+
 func TestDeleteFile_PathTraversal(t *testing.T) {
 	tempDir := t.TempDir()
 	contentsDir := filepath.Join(tempDir, "contents")
@@ -303,6 +430,149 @@ func TestDeleteFile_PathTraversal(t *testing.T) {
 	}
 }
 
+func TestMoveFile_RenamesFile(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	srcPath := filepath.Join(contentsDir, "old.txt")
+	os.WriteFile(srcPath, []byte("content"), 0600)
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	os.Chtimes(srcPath, mtime, mtime)
+
+	if err := MoveFile(contentsDir, "old.txt", "sub/dir/new.txt", false); err != nil {
+		t.Fatalf("failed to move file: %v", err)
+	}
+
+	if _, err := os.Stat(srcPath); !os.IsNotExist(err) {
+		t.Error("expected source to be gone")
+	}
+
+	info, err := os.Stat(filepath.Join(contentsDir, "sub", "dir", "new.txt"))
+	if err != nil {
+		t.Fatalf("expected destination to exist: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600 to be kept, got %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("expected mtime %v to be kept, got %v", mtime, info.ModTime())
+	}
+}
+
+func TestMoveFile_Directory(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(filepath.Join(contentsDir, "dir"), 0755)
+	os.WriteFile(filepath.Join(contentsDir, "dir", "file.txt"), []byte("content"), 0644)
+
+	if err := MoveFile(contentsDir, "dir", "renamed", false); err != nil {
+		t.Fatalf("failed to move directory: %v", err)
+	}
+
+	data, err := ReadFile(contentsDir, "renamed/file.txt")
+	if err != nil || string(data) != "content" {
+		t.Errorf("expected moved file content, got %q (%v)", data, err)
+	}
+
+	// A directory cannot be moved into itself
+	if err := MoveFile(contentsDir, "renamed", "renamed/inner", false); err == nil {
+		t.Error("expected moving a directory into itself to fail")
+	}
+}
+
+func TestMoveFile_OverwriteProtection(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+	os.WriteFile(filepath.Join(contentsDir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "b.txt"), []byte("b"), 0644)
+
+	err := MoveFile(contentsDir, "a.txt", "b.txt", false)
+	if !errors.Is(err, errors.CodePathExists) {
+		t.Fatalf("expected PATH_EXISTS, got %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(contentsDir, "b.txt"))
+	if string(data) != "b" {
+		t.Errorf("expected destination to be untouched, got %q", data)
+	}
+
+	if err := MoveFile(contentsDir, "a.txt", "b.txt", true); err != nil {
+		t.Fatalf("failed to move with overwrite: %v", err)
+	}
+
+	data, _ = os.ReadFile(filepath.Join(contentsDir, "b.txt"))
+	if string(data) != "a" {
+		t.Errorf("expected destination to be replaced, got %q", data)
+	}
+}
+
+func TestMoveFile_OverwriteKinds(t *testing.T) {
+	tests := []struct {
+		name     string
+		src, dst string
+		want     string // content of dst/f.txt for directories, of dst otherwise
+	}{
+		{"file over file", "a.txt", "b.txt", "a"},
+		{"file over link", "a.txt", "link", "a"},
+		{"file over directory", "a.txt", "dir2", "a"},
+		{"directory over file", "dir1", "b.txt", "one"},
+		{"directory over directory", "dir1", "dir2", "one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentsDir := filepath.Join(t.TempDir(), "contents")
+			os.MkdirAll(filepath.Join(contentsDir, "dir1"), 0755)
+			os.MkdirAll(filepath.Join(contentsDir, "dir2"), 0755)
+			os.WriteFile(filepath.Join(contentsDir, "a.txt"), []byte("a"), 0644)
+			os.WriteFile(filepath.Join(contentsDir, "b.txt"), []byte("b"), 0644)
+			os.WriteFile(filepath.Join(contentsDir, "dir1", "f.txt"), []byte("one"), 0644)
+			os.WriteFile(filepath.Join(contentsDir, "dir2", "f.txt"), []byte("two"), 0644)
+			os.Symlink("b.txt", filepath.Join(contentsDir, "link"))
+
+			if err := MoveFile(contentsDir, tt.src, tt.dst, true); err != nil {
+				t.Fatalf("MoveFile failed: %v", err)
+			}
+
+			check := filepath.Join(contentsDir, tt.dst)
+			if strings.HasPrefix(tt.src, "dir") {
+				check = filepath.Join(check, "f.txt")
+			}
+			if data, err := os.ReadFile(check); err != nil || string(data) != tt.want {
+				t.Errorf("destination = %q, %v; want %q", data, err, tt.want)
+			}
+			if _, err := os.Lstat(filepath.Join(contentsDir, tt.src)); !os.IsNotExist(err) {
+				t.Errorf("expected source to be gone, got %v", err)
+			}
+			// Overwriting a link replaces the link, never its target
+			if data, _ := os.ReadFile(filepath.Join(contentsDir, "b.txt")); tt.dst == "link" && string(data) != "b" {
+				t.Errorf("link target changed to %q", data)
+			}
+		})
+	}
+}
+
+func TestMoveFile_Errors(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte("content"), 0644)
+
+	if err := MoveFile(contentsDir, "missing.txt", "new.txt", false); !errors.Is(err, errors.CodePathNotFound) {
+		t.Errorf("expected PATH_NOT_FOUND, got %v", err)
+	}
+
+	if err := MoveFile(contentsDir, "file.txt", "../outside.txt", false); err == nil {
+		t.Error("expected traversal in destination to fail")
+	}
+
+	if err := MoveFile(contentsDir, ".", "new", false); err == nil {
+		t.Error("expected moving the workspace root to fail")
+	}
+}
+
 func TestGrepFiles_Basic(t *testing.T) {
 	tempDir := t.TempDir()
 	contentsDir := filepath.Join(tempDir, "contents")
@@ -312,11 +582,13 @@ func TestGrepFiles_Basic(t *testing.T) {
 	os.WriteFile(filepath.Join(contentsDir, "file1.txt"), []byte("hello world\nfoo bar\n"), 0644)
 	os.WriteFile(filepath.Join(contentsDir, "file2.txt"), []byte("hello again\nbaz\n"), 0644)
 
-	matches, total, err := GrepFiles(contentsDir, ".", "hello", "", false, 0)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "hello", GrepOptions{})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	matches, total := result.Matches, result.TotalMatches
+
 	if total != 2 {
 		t.Errorf("expected 2 matches, got %d", total)
 	}
@@ -333,11 +605,13 @@ func TestGrepFiles_CaseInsensitive(t *testing.T) {
 
 	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte("HELLO\nhello\nHeLLo\n"), 0644)
 
-	_, total, err := GrepFiles(contentsDir, ".", "hello", "", true, 0)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "hello", GrepOptions{IgnoreCase: true})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	total := result.TotalMatches
+
 	if total != 3 {
 		t.Errorf("expected 3 matches, got %d", total)
 	}
@@ -352,17 +626,56 @@ func TestGrepFiles_WithGlob(t *testing.T) {
 	os.WriteFile(filepath.Join(contentsDir, "file.log"), []byte("match\n"), 0644)
 	os.WriteFile(filepath.Join(contentsDir, "file.md"), []byte("match\n"), 0644)
 
-	matches, _, err := GrepFiles(contentsDir, ".", "match", "*.txt", false, 0)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{Glob: "*.txt"})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	matches := result.Matches
+
 	// Should only match .txt files
 	if len(matches) != 1 {
 		t.Errorf("expected 1 match (*.txt only), got %d", len(matches))
 	}
 }
 
+func TestGrepFiles_WithPathGlob(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(filepath.Join(contentsDir, "xl", "worksheets"), 0755)
+	os.MkdirAll(filepath.Join(contentsDir, "xl", "theme"), 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, "xl", "workbook.xml"), []byte("match\n"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "xl", "worksheets", "sheet1.xml"), []byte("match\n"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "xl", "theme", "theme1.xml"), []byte("match\n"), 0644)
+
+	tests := []struct {
+		glob string
+		want string
+	}{
+		{"xl/worksheets/*.xml", "xl/worksheets/sheet1.xml"},
+		{"xl/**/*.xml", "xl/theme/theme1.xml,xl/workbook.xml,xl/worksheets/sheet1.xml"},
+		{"xl/{theme,worksheets}/*", "xl/theme/theme1.xml,xl/worksheets/sheet1.xml"},
+		{"!xl/theme/**", "xl/workbook.xml,xl/worksheets/sheet1.xml"},
+		{"*.xml", "xl/theme/theme1.xml,xl/workbook.xml,xl/worksheets/sheet1.xml"},
+		{"./*.xml", ""},
+	}
+
+	for _, tt := range tests {
+		result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{Glob: tt.glob})
+		if err != nil {
+			t.Fatalf("grep with glob %q failed: %v", tt.glob, err)
+		}
+		var files []string
+		for _, m := range result.Matches {
+			files = append(files, m.File)
+		}
+		if got := strings.Join(files, ","); got != tt.want {
+			t.Errorf("glob %q matched %q, want %q", tt.glob, got, tt.want)
+		}
+	}
+}
+
 func TestGrepFiles_MaxResults(t *testing.T) {
 	tempDir := t.TempDir()
 	contentsDir := filepath.Join(tempDir, "contents")
@@ -372,11 +685,13 @@ func TestGrepFiles_MaxResults(t *testing.T) {
 	content := strings.Repeat("match\n", 100)
 	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte(content), 0644)
 
-	matches, total, err := GrepFiles(contentsDir, ".", "match", "", false, 10)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{MaxResults: 10})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	matches, total := result.Matches, result.TotalMatches
+
 	if len(matches) != 10 {
 		t.Errorf("expected 10 matches (max results), got %d", len(matches))
 	}
@@ -386,6 +701,112 @@ func TestGrepFiles_MaxResults(t *testing.T) {
 	}
 }
 
+func TestGrepFiles_Cancelled(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte("match\n"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := GrepFiles(ctx, contentsDir, ".", "match", GrepOptions{})
+	if err != nil {
+		t.Fatalf("expected partial results, got error: %v", err)
+	}
+
+	if result.TruncatedReason != GrepTruncatedCancelled {
+		t.Errorf("expected truncated_reason %q, got %q", GrepTruncatedCancelled, result.TruncatedReason)
+	}
+
+	if len(result.Matches) != 0 {
+		t.Errorf("expected no matches after cancellation, got %d", len(result.Matches))
+	}
+}
+
+func TestGrepFiles_MaxBytesLongLine(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	// One line far larger than the budget, with the match at its end
+	long := strings.Repeat("a", 4*1024*1024) + "match\n"
+	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte(long), 0644)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{MaxBytes: 1024})
+	if err != nil {
+		t.Fatalf("expected partial results, got error: %v", err)
+	}
+	if result.TruncatedReason != GrepTruncatedMaxBytes || len(result.Matches) != 0 {
+		t.Errorf("expected no matches and truncated_reason %q, got %+v", GrepTruncatedMaxBytes, result)
+	}
+
+	// The line is read only until it passes the limit
+	reader := bufio.NewReaderSize(strings.NewReader(long), 4096)
+	line, err := readGrepLine(reader, nil, 1024)
+	if err != nil {
+		t.Fatalf("readGrepLine failed: %v", err)
+	}
+	if len(line) > 4096 {
+		t.Errorf("expected reading to stop after one buffer, got %d bytes", len(line))
+	}
+}
+
+func TestGrepFiles_Timeout(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte("match\n"), 0644)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{Timeout: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("expected partial results, got error: %v", err)
+	}
+
+	if result.TruncatedReason != GrepTruncatedTimeout {
+		t.Errorf("expected truncated_reason %q, got %q", GrepTruncatedTimeout, result.TruncatedReason)
+	}
+}
+
+func TestGrepFiles_MaxBytes(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	// 100 lines of 6 bytes each
+	content := strings.Repeat("match\n", 100)
+	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte(content), 0644)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{MaxBytes: 60})
+	if err != nil {
+		t.Fatalf("expected partial results, got error: %v", err)
+	}
+
+	if result.TruncatedReason != GrepTruncatedMaxBytes {
+		t.Errorf("expected truncated_reason %q, got %q", GrepTruncatedMaxBytes, result.TruncatedReason)
+	}
+
+	if len(result.Matches) != 10 {
+		t.Errorf("expected 10 matches within the byte budget, got %d", len(result.Matches))
+	}
+
+	// A search that fits the budget is not truncated
+	result, err = GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{MaxBytes: uint64(len(content))})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+
+	if result.TruncatedReason != "" {
+		t.Errorf("expected no truncation, got %q", result.TruncatedReason)
+	}
+
+	if result.BytesScanned != uint64(len(content)) {
+		t.Errorf("expected %d bytes scanned, got %d", len(content), result.BytesScanned)
+	}
+}
+
 func TestStatus_NoChanges(t *testing.T) {
 	setupTestEnvironment(t)
 	tempDir := t.TempDir()
@@ -428,6 +849,145 @@ func TestStatus_NoChanges(t *testing.T) {
 	}
 }
 
+func TestStatus_DirectoryChanges(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// "nested" is only implied by its file; "empty" has no explicit entry either
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"nested/deep/file.txt": "content",
+		"old/file.txt":         "content",
+	})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "dir-status", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	// Implied directories are not reported as added
+	result, err := Status(session)
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if len(result.AddedDirs) != 0 || len(result.DeletedDirs) != 0 {
+		t.Fatalf("expected no directory changes, got added=%v deleted=%v", result.AddedDirs, result.DeletedDirs)
+	}
+
+	// Create an empty directory and remove another one entirely
+	if err := os.MkdirAll(filepath.Join(contentsDir, "empty", "child"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(contentsDir, "old")); err != nil {
+		t.Fatalf("failed to remove directory: %v", err)
+	}
+
+	result, err = Status(session)
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+
+	if strings.Join(result.AddedDirs, ",") != "empty,empty/child" {
+		t.Errorf("expected added dirs [empty empty/child], got %v", result.AddedDirs)
+	}
+	if strings.Join(result.DeletedDirs, ",") != "old" {
+		t.Errorf("expected deleted dirs [old], got %v", result.DeletedDirs)
+	}
+	if len(result.Deleted) != 1 {
+		t.Errorf("expected 1 deleted file, got %v", result.Deleted)
+	}
+	if !result.HasChanges() {
+		t.Error("expected HasChanges to be true")
+	}
+}
+
+func TestStatus_EmptyDirectoryOnly(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "content"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "empty-dir", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	if err := os.Mkdir(filepath.Join(contentsDir, "new"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	// A new empty directory alone is a change that sync must report
+	result, err := Sync(session, false, cfg)
+	if err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	if result.DirsAdded != 1 {
+		t.Errorf("expected 1 added directory, got %d", result.DirsAdded)
+	}
+	if result.DirsDeleted != 0 {
+		t.Errorf("expected 0 deleted directories, got %d", result.DirsDeleted)
+	}
+}
+
+func TestStatus_DetectsRenames(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"report.txt": "quarterly numbers",
+		"notes.txt":  "same size text!!!",
+		"gone.txt":   "removed",
+	})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "renames", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	// A pure rename, a same-size replacement and a plain delete
+	if err := MoveFile(contentsDir, "report.txt", "archive/report.txt", false); err != nil {
+		t.Fatalf("failed to move: %v", err)
+	}
+	os.Remove(filepath.Join(contentsDir, "notes.txt"))
+	os.WriteFile(filepath.Join(contentsDir, "other.txt"), []byte("different text!!!"), 0644)
+	os.Remove(filepath.Join(contentsDir, "gone.txt"))
+
+	result, err := Status(session)
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+
+	if len(result.Renamed) != 1 || result.Renamed[0] != (RenamedPath{From: "report.txt", To: "archive/report.txt"}) {
+		t.Errorf("expected report.txt -> archive/report.txt rename, got %v", result.Renamed)
+	}
+	if strings.Join(result.Added, ",") != "other.txt" {
+		t.Errorf("expected only other.txt added, got %v", result.Added)
+	}
+	if strings.Join(result.Deleted, ",") != "gone.txt,notes.txt" {
+		t.Errorf("expected gone.txt and notes.txt deleted, got %v", result.Deleted)
+	}
+}
+
 func TestStatus_WithModifications(t *testing.T) {
 	setupTestEnvironment(t)
 	tempDir := t.TempDir()
@@ -541,7 +1101,7 @@ func TestWriteFile_WithoutCreateDirs(t *testing.T) {
 	os.MkdirAll(contentsDir, 0755)
 
 	// Try to write to a nested path without creating directories
-	err := WriteFile(contentsDir, "a/b/c/file.txt", []byte("content"), false)
+	err := WriteFile(contentsDir, "a/b/c/file.txt", []byte("content"), false, nil)
 	if err == nil {
 		t.Fatal("expected error when parent directories don't exist")
 	}
@@ -552,7 +1112,7 @@ func TestGrepFiles_InvalidRegex(t *testing.T) {
 	contentsDir := filepath.Join(tempDir, "contents")
 	os.MkdirAll(contentsDir, 0755)
 
-	_, _, err := GrepFiles(contentsDir, ".", "[invalid(regex", "", false, 0)
+	_, err := GrepFiles(context.Background(), contentsDir, ".", "[invalid(regex", GrepOptions{})
 	if err == nil {
 		t.Fatal("expected error for invalid regex")
 	}
@@ -564,7 +1124,7 @@ func TestGrepFiles_InvalidGlob(t *testing.T) {
 	os.MkdirAll(contentsDir, 0755)
 
 	// Use an absolute path as glob pattern (invalid)
-	_, _, err := GrepFiles(contentsDir, ".", "pattern", "/absolute/path", false, 0)
+	_, err := GrepFiles(context.Background(), contentsDir, ".", "pattern", GrepOptions{Glob: "/absolute/path"})
 	if err == nil {
 		t.Fatal("expected error for invalid glob pattern")
 	}
@@ -575,7 +1135,7 @@ func TestGrepFiles_PathTraversal(t *testing.T) {
 	contentsDir := filepath.Join(tempDir, "contents")
 	os.MkdirAll(contentsDir, 0755)
 
-	_, _, err := GrepFiles(contentsDir, "../../../etc", "pattern", "", false, 0)
+	_, err := GrepFiles(context.Background(), contentsDir, "../../../etc", "pattern", GrepOptions{})
 	if err == nil {
 		t.Fatal("expected error for path traversal")
 	}
@@ -588,11 +1148,13 @@ func TestGrepFiles_EmptyResults(t *testing.T) {
 
 	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte("no match here"), 0644)
 
-	matches, total, err := GrepFiles(contentsDir, ".", "NOTFOUND", "", false, 0)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "NOTFOUND", GrepOptions{})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	matches, total := result.Matches, result.TotalMatches
+
 	if len(matches) != 0 || total != 0 {
 		t.Errorf("expected no matches, got %d matches, %d total", len(matches), total)
 	}
@@ -698,17 +1260,151 @@ func TestGrepFiles_BinaryFile(t *testing.T) {
 	os.WriteFile(filepath.Join(contentsDir, "binary.bin"), binaryData, 0644)
 
 	// Grep should handle binary files gracefully
-	matches, _, err := GrepFiles(contentsDir, ".", "pattern", "", false, 0)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "pattern", GrepOptions{})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	matches := result.Matches
+
 	// Binary files shouldn't match text patterns
 	if len(matches) > 0 {
 		t.Error("expected no matches in binary file")
 	}
 }
 
+func TestGrepFiles_SkipsBinary(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, "image.bin"), []byte("pattern\x00\x01"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "text.txt"), []byte("pattern\n"), 0644)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "pattern", GrepOptions{})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 1 || result.Matches[0].File != "text.txt" || result.SkippedBinary != 1 {
+		t.Errorf("expected binary file skipped, got %+v", result)
+	}
+
+	result, err = GrepFiles(context.Background(), contentsDir, ".", "pattern", GrepOptions{IncludeBinary: true})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 2 || result.SkippedBinary != 0 {
+		t.Errorf("expected binary file searched, got %+v", result)
+	}
+}
+
+func TestGrepFiles_LongLine(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	// One line well past bufio.Scanner's 64 KB token limit
+	prefix := strings.Repeat("x", 200*1024)
+	content := "first\n" + prefix + "NEEDLE" + strings.Repeat("y", 1024) + "\nlast\n"
+	os.WriteFile(filepath.Join(contentsDir, "min.json"), []byte(content), 0644)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "NEEDLE", GrepOptions{})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+
+	if len(result.Matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(result.Matches))
+	}
+	match := result.Matches[0]
+	if match.LineNumber != 2 || match.Column != len(prefix)+1 {
+		t.Errorf("expected line 2 column %d, got line %d column %d", len(prefix)+1, match.LineNumber, match.Column)
+	}
+	if !match.LineTruncated || len(match.LineContent) > GrepExcerptBytes || !strings.Contains(match.LineContent, "NEEDLE") {
+		t.Errorf("expected excerpt around the match, got %d bytes truncated=%v", len(match.LineContent), match.LineTruncated)
+	}
+	if result.BytesScanned != uint64(len(content)) {
+		t.Errorf("expected %d bytes scanned, got %d", len(content), result.BytesScanned)
+	}
+}
+
+func TestGrepFiles_Context(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	content := "a\nb\nmatch 1\nc\nmatch 2\nd\ne\nf\n"
+	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte(content), 0644)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{BeforeContext: 2, AfterContext: 2})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+
+	if len(result.Matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(result.Matches))
+	}
+
+	lineNumbers := func(lines []GrepLine) []int {
+		var numbers []int
+		for _, l := range lines {
+			numbers = append(numbers, l.LineNumber)
+		}
+		return numbers
+	}
+
+	// Line 4 sits between the matches and is reported once
+	first, second := result.Matches[0], result.Matches[1]
+	if got := lineNumbers(first.Before); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("expected first match before context [1 2], got %v", got)
+	}
+	if got := lineNumbers(first.After); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("expected first match after context [4], got %v", got)
+	}
+	if len(second.Before) != 0 {
+		t.Errorf("expected no before context for second match, got %v", lineNumbers(second.Before))
+	}
+	if got := lineNumbers(second.After); !reflect.DeepEqual(got, []int{6, 7}) {
+		t.Errorf("expected second match after context [6 7], got %v", got)
+	}
+
+	// After context is still filled in when max results is reached
+	result, err = GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{AfterContext: 1, MaxResults: 1})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if len(result.Matches) != 1 || len(result.Matches[0].After) != 1 {
+		t.Errorf("expected one match with after context, got %+v", result.Matches)
+	}
+}
+
+func TestGrepFiles_FilesModes(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, "a.txt"), []byte("match\nmatch\nmatch\n"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "b.txt"), []byte("match\n"), 0644)
+	os.WriteFile(filepath.Join(contentsDir, "c.txt"), []byte("nothing\n"), 0644)
+
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{Count: true})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	want := []GrepFileCount{{File: "a.txt", Count: 3}, {File: "b.txt", Count: 1}}
+	if !reflect.DeepEqual(result.Files, want) || len(result.Matches) != 0 || result.TotalMatches != 4 {
+		t.Errorf("unexpected count result: %+v", result)
+	}
+
+	result, err = GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{FilesWithMatches: true, MaxResults: 1})
+	if err != nil {
+		t.Fatalf("failed to grep: %v", err)
+	}
+	if !reflect.DeepEqual(result.Files, []GrepFileCount{{File: "a.txt"}}) {
+		t.Errorf("expected only a.txt, got %+v", result.Files)
+	}
+}
+
 func TestGrepFiles_NestedDirectories(t *testing.T) {
 	tempDir := t.TempDir()
 	contentsDir := filepath.Join(tempDir, "contents")
@@ -719,11 +1415,13 @@ func TestGrepFiles_NestedDirectories(t *testing.T) {
 	os.WriteFile(filepath.Join(contentsDir, "a", "mid.txt"), []byte("match here\n"), 0644)
 	os.WriteFile(filepath.Join(contentsDir, "a", "b", "c", "deep.txt"), []byte("match here\n"), 0644)
 
-	matches, total, err := GrepFiles(contentsDir, ".", "match", "", false, 0)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	matches, total := result.Matches, result.TotalMatches
+
 	if total != 3 {
 		t.Errorf("expected 3 total matches in nested files, got %d", total)
 	}
@@ -772,13 +1470,13 @@ func TestWriteFile_Overwrite(t *testing.T) {
 	os.MkdirAll(contentsDir, 0755)
 
 	// Write initial content
-	err := WriteFile(contentsDir, "file.txt", []byte("initial"), false)
+	err := WriteFile(contentsDir, "file.txt", []byte("initial"), false, nil)
 	if err != nil {
 		t.Fatalf("failed to write initial file: %v", err)
 	}
 
 	// Overwrite with new content
-	err = WriteFile(contentsDir, "file.txt", []byte("overwritten"), false)
+	err = WriteFile(contentsDir, "file.txt", []byte("overwritten"), false, nil)
 	if err != nil {
 		t.Fatalf("failed to overwrite file: %v", err)
 	}
@@ -834,11 +1532,13 @@ line 5 with pattern`
 
 	os.WriteFile(filepath.Join(contentsDir, "file.txt"), []byte(content), 0644)
 
-	matches, total, err := GrepFiles(contentsDir, ".", "pattern", "", false, 0)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "pattern", GrepOptions{})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	matches, total := result.Matches, result.TotalMatches
+
 	if total != 3 {
 		t.Errorf("expected 3 matches, got %d", total)
 	}
@@ -907,11 +1607,13 @@ func TestGrepFiles_MaxResultsExactly(t *testing.T) {
 	}
 
 	// Set max results to exactly match total available
-	matches, total, err := GrepFiles(contentsDir, ".", "match", "", false, 5)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "match", GrepOptions{MaxResults: 5})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	matches, total := result.Matches, result.TotalMatches
+
 	if len(matches) != 5 {
 		t.Errorf("expected exactly 5 matches (max results), got %d", len(matches))
 	}
@@ -947,7 +1649,7 @@ func TestWriteFile_LargeFile(t *testing.T) {
 	// Create large content (1MB)
 	largeContent := bytes.Repeat([]byte("x"), 1024*1024)
 
-	err := WriteFile(contentsDir, "large.txt", largeContent, false)
+	err := WriteFile(contentsDir, "large.txt", largeContent, false, nil)
 	if err != nil {
 		t.Fatalf("failed to write large file: %v", err)
 	}
@@ -1014,11 +1716,13 @@ func TestGrepFiles_UnreadableFile(t *testing.T) {
 	defer os.Chmod(restrictedFile, 0644)
 
 	// Grep should handle the error gracefully by skipping the file
-	matches, _, err := GrepFiles(contentsDir, ".", "content", "", false, 0)
+	result, err := GrepFiles(context.Background(), contentsDir, ".", "content", GrepOptions{})
 	if err != nil {
 		t.Fatalf("failed to grep: %v", err)
 	}
 
+	matches := result.Matches
+
 	// Should have skipped the unreadable file
 	if len(matches) > 0 {
 		t.Error("expected no matches from unreadable file")
@@ -1052,7 +1756,7 @@ func TestWriteFile_NestedPathWithoutCreateDirs(t *testing.T) {
 	os.MkdirAll(contentsDir, 0755)
 
 	// Try to write nested path without createDirs
-	err := WriteFile(contentsDir, "dir/subdir/file.txt", []byte("content"), false)
+	err := WriteFile(contentsDir, "dir/subdir/file.txt", []byte("content"), false, nil)
 	if err == nil {
 		t.Fatal("expected error when writing to non-existent nested path without createDirs")
 	}
@@ -1074,7 +1778,7 @@ func TestWriteFile_InvalidRelativePath(t *testing.T) {
 	contentsDir := filepath.Join(tempDir, "contents")
 	os.MkdirAll(contentsDir, 0755)
 
-	err := WriteFile(contentsDir, "/absolute/path", []byte("content"), false)
+	err := WriteFile(contentsDir, "/absolute/path", []byte("content"), false, nil)
 	if err == nil {
 		t.Fatal("expected error for absolute path")
 	}
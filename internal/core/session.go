@@ -17,16 +17,18 @@ import (
 
 // Session represents a zipfs session with metadata.
 type Session struct {
-	ID                 string     `json:"id"`
-	Name               string     `json:"name"`
-	SourcePath         string     `json:"source_path"`
-	CreatedAt          time.Time  `json:"created_at"`
-	LastSyncedAt       *time.Time `json:"last_synced_at"`
-	LastAccessedAt     time.Time  `json:"last_accessed_at"`
-	State              string     `json:"state"` // "open", "syncing"
-	ZipHashSHA256      string     `json:"zip_hash_sha256"`
-	ExtractedSizeBytes uint64     `json:"extracted_size_bytes"`
-	FileCount          int        `json:"file_count"`
+	ID                 string       `json:"id"`
+	Name               string       `json:"name"`
+	SourcePath         string       `json:"source_path"`
+	CreatedAt          time.Time    `json:"created_at"`
+	LastSyncedAt       *time.Time   `json:"last_synced_at"`
+	LastAccessedAt     time.Time    `json:"last_accessed_at"`
+	State              string       `json:"state"` // "open", "syncing"
+	ZipHashSHA256      string       `json:"zip_hash_sha256"`
+	ExtractedSizeBytes uint64       `json:"extracted_size_bytes"`
+	FileCount          int          `json:"file_count"`
+	SyncJournal        *SyncJournal `json:"sync_journal,omitempty"`
+	SkippedSymlinks    []string     `json:"skipped_symlinks,omitempty"` // symlink entries not extracted
 }
 
 // DirName returns the directory name used for this session's workspace.
@@ -90,6 +92,19 @@ func CreateSession(sourcePath, name string, cfg *Config) (*Session, error) {
 	if !bombCheck.IsSafe {
 		return nil, errors.ZipBombDetected(bombCheck.Reason)
 	}
+	if len(bombCheck.UnsupportedMethods) > 0 {
+		return nil, errors.UnsupportedCompression(absSourcePath, bombCheck.UnsupportedMethods)
+	}
+
+	// The workspace holds a copy of the zip plus its extracted contents
+	sourceInfo, err := os.Stat(absSourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat source zip: %w", err)
+	}
+	projectedBytes := uint64(sourceInfo.Size()) + bombCheck.TotalUncompressedSize
+	if err := EnsureDiskBudget(cfg, projectedBytes); err != nil {
+		return nil, err
+	}
 
 	// Generate session ID
 	sessionID := uuid.New().String()
@@ -137,14 +152,20 @@ func CreateSession(sourcePath, name string, cfg *Config) (*Session, error) {
 		return nil, fmt.Errorf("failed to get contents directory: %w", err)
 	}
 
-	fileCount, totalSize, err := Extract(absSourcePath, contentsDir, cfg.ToSecurityLimits())
+	extracted, err := ExtractArchive(absSourcePath, contentsDir, cfg.ToSecurityLimits())
 	if err != nil {
 		_ = RemoveWorkspace(session, dirName)
 		return nil, fmt.Errorf("failed to extract zip: %w", err)
 	}
 
-	session.FileCount = fileCount
-	session.ExtractedSizeBytes = totalSize
+	session.FileCount = extracted.FileCount
+	session.ExtractedSizeBytes = extracted.TotalSize
+	session.SkippedSymlinks = extracted.SkippedSymlinks
+
+	// The search index is an optimization; a failed build is retried by grep
+	if cfg.Defaults.SearchIndex == SearchIndexOpen {
+		_ = BuildSearchIndex(contentsDir)
+	}
 
 	// Write metadata
 	if err := UpdateSession(session, dirName); err != nil {
@@ -308,7 +329,8 @@ func UpdateSession(session *Session, dirName string) error {
 		return fmt.Errorf("failed to marshal session: %w", err)
 	}
 
-	if err := os.WriteFile(metadataPath, data, 0600); err != nil {
+	// Metadata carries the sync journal, so it must be replaced atomically and durably
+	if err := writeFileAtomic(metadataPath, data, 0600); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
 
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"archive/zip"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// File states reported by StatFile besides the FindStatus values.
+const (
+	FileStateDeleted = "deleted" // in original.zip but no longer in the workspace
+	FileStateSkipped = "skipped" // a symlink left out at extraction and kept by sync
+)
+
+// ArchiveEntry is the central directory header of an original.zip entry.
+type ArchiveEntry struct {
+	Name             string    `json:"name"`
+	Method           uint16    `json:"method"`
+	MethodName       string    `json:"method_name"`
+	CRC32            string    `json:"crc32"` // 8 hex digits
+	CompressedSize   uint64    `json:"compressed_size"`
+	UncompressedSize uint64    `json:"uncompressed_size"`
+	Modified         time.Time `json:"modified"`
+	Mode             string    `json:"mode"`
+	Comment          string    `json:"comment,omitempty"`
+}
+
+// FileStat describes one workspace path: what is on disk, the matching
+// original.zip entry and how the two compare. Exists is false for entries that
+// were deleted from the workspace; Original is nil for added paths and for
+// directories the archive only implies.
+type FileStat struct {
+	Path        string        `json:"path"`
+	Exists      bool          `json:"exists"`
+	Type        string        `json:"type,omitempty"` // "file", "dir" or "symlink"
+	SizeBytes   uint64        `json:"size_bytes"`
+	Mode        string        `json:"mode,omitempty"`
+	ModifiedAt  time.Time     `json:"modified_at,omitzero"`
+	Target      string        `json:"target,omitempty"` // link target, for symlinks only
+	State       string        `json:"state"`            // FindStatus value, FileStateDeleted or FileStateSkipped
+	RenamedFrom string        `json:"renamed_from,omitempty"`
+	RenamedTo   string        `json:"renamed_to,omitempty"`
+	Original    *ArchiveEntry `json:"original,omitempty"`
+}
+
+// StatFile reports on a single path in a session. The change state comes from
+// Status, so it follows the same size and mtime comparison and rename pairing.
+func StatFile(session *Session, relativePath string) (*FileStat, error) {
+	if err := security.ValidateRelativePath(relativePath); err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	dirName := session.DirName()
+	contentsDir, err := ContentsDir(dirName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contents directory: %w", err)
+	}
+
+	// Validate the resolved path is within contents directory
+	if err := security.ValidatePath(contentsDir, relativePath); err != nil {
+		return nil, errors.PathTraversal(relativePath)
+	}
+
+	name := rootName(relativePath)
+	if name == "." {
+		return nil, fmt.Errorf("cannot stat the workspace root")
+	}
+
+	stat := &FileStat{Path: name}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	// Describe the workspace side without following a final symlink
+	info, err := root.Lstat(name)
+	if err == nil {
+		entry := newFileEntry(root, name, name, info)
+		stat.Exists = true
+		stat.Type = entry.Type
+		stat.SizeBytes = entry.SizeBytes
+		stat.Mode = info.Mode().String()
+		stat.ModifiedAt = info.ModTime()
+		stat.Target = entry.Target
+	} else if rerr := rootedError(err, relativePath, "stat path"); !errors.Is(rerr, errors.CodePathNotFound) {
+		return nil, rerr
+	}
+
+	status, err := Status(session)
+	if err != nil {
+		return nil, err
+	}
+	stat.State = FindStatusUnchanged
+	originalName := name
+	switch {
+	case slices.Contains(status.Modified, name):
+		stat.State = FindStatusModified
+	case slices.Contains(status.Added, name), slices.Contains(status.AddedDirs, name):
+		stat.State = FindStatusAdded
+	case slices.Contains(status.Deleted, name), slices.Contains(status.DeletedDirs, name):
+		stat.State = FileStateDeleted
+	case slices.Contains(session.SkippedSymlinks, name):
+		stat.State = FileStateSkipped
+	}
+	for _, r := range status.Renamed {
+		switch name {
+		case r.To:
+			stat.State, stat.RenamedFrom = FindStatusRenamed, r.From
+			originalName = r.From
+		case r.From:
+			stat.State, stat.RenamedTo = FindStatusRenamed, r.To
+		}
+	}
+
+	// Describe the original side from the central directory
+	originalZipPath, err := OriginalZipPath(dirName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get original zip path: %w", err)
+	}
+	zipReader, err := zip.OpenReader(originalZipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open original zip: %w", err)
+	}
+	defer zipReader.Close()
+
+	impliedDir := false
+	for _, f := range zipReader.File {
+		if strings.TrimSuffix(f.Name, "/") == originalName {
+			stat.Original = newArchiveEntry(f)
+			break
+		}
+		if strings.HasPrefix(f.Name, originalName+"/") {
+			impliedDir = true
+		}
+	}
+
+	if !stat.Exists && stat.Original == nil && !impliedDir {
+		return nil, errors.PathNotFound(relativePath)
+	}
+	if !stat.Exists && stat.Type == "" {
+		stat.Type = "file"
+		if impliedDir || (stat.Original != nil && strings.HasSuffix(stat.Original.Name, "/")) {
+			stat.Type = "dir"
+		} else if stat.Original != nil && stat.Original.Mode[0] == 'L' {
+			stat.Type = "symlink"
+		}
+	}
+
+	return stat, nil
+}
+
+// newArchiveEntry copies the header fields of a zip entry.
+func newArchiveEntry(f *zip.File) *ArchiveEntry {
+	return &ArchiveEntry{
+		Name:             f.Name,
+		Method:           f.Method,
+		MethodName:       CompressionMethodName(f.Method),
+		CRC32:            fmt.Sprintf("%08x", f.CRC32),
+		CompressedSize:   f.CompressedSize64,
+		UncompressedSize: f.UncompressedSize64,
+		Modified:         f.Modified,
+		Mode:             f.Mode().String(),
+		Comment:          f.Comment,
+	}
+}
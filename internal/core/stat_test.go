@@ -0,0 +1,131 @@
+package core
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+)
+
+func TestStatFile(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// Build the archive by hand to control the method and comment
+	zipPath := filepath.Join(tempDir, "test.zip")
+	zf, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	w := zip.NewWriter(zf)
+	entries := []struct {
+		name, content, comment string
+		method                 uint16
+	}{
+		{"data/notes.txt", "quarterly notes\n", "reviewed", zip.Deflate},
+		{"data/stored.bin", "raw bytes", "", zip.Store},
+		{"gone.txt", "soon deleted\n", "", zip.Deflate},
+		{"old.txt", "moved content\n", "", zip.Deflate},
+	}
+	for _, e := range entries {
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: e.name, Method: e.method, Comment: e.comment})
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", e.name, err)
+		}
+		fw.Write([]byte(e.content))
+	}
+	w.Close()
+	zf.Close()
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "stat", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	if err := WriteFile(contentsDir, "data/notes.txt", []byte("rewritten notes\n"), false, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := WriteFile(contentsDir, "new.txt", []byte("brand new\n"), false, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := DeleteFile(contentsDir, "gone.txt", false); err != nil {
+		t.Fatalf("failed to delete file: %v", err)
+	}
+	if err := MoveFile(contentsDir, "old.txt", "new/place.txt", false); err != nil {
+		t.Fatalf("failed to move file: %v", err)
+	}
+
+	stat, err := StatFile(session, "data/notes.txt")
+	if err != nil {
+		t.Fatalf("StatFile failed: %v", err)
+	}
+	if !stat.Exists || stat.Type != "file" || stat.State != FindStatusModified || stat.SizeBytes != 16 {
+		t.Errorf("unexpected stat: %+v", stat)
+	}
+	o := stat.Original
+	if o == nil {
+		t.Fatal("expected original entry")
+	}
+	if o.MethodName != "deflate" || o.Comment != "reviewed" || o.UncompressedSize != 16 || o.CompressedSize == 0 {
+		t.Errorf("unexpected original entry: %+v", o)
+	}
+	if want := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("quarterly notes\n"))); o.CRC32 != want {
+		t.Errorf("expected CRC32 %s, got %s", want, o.CRC32)
+	}
+
+	stat, err = StatFile(session, "data/stored.bin")
+	if err != nil {
+		t.Fatalf("StatFile failed: %v", err)
+	}
+	if stat.State != FindStatusUnchanged || stat.Original.MethodName != "store" || stat.Original.CompressedSize != 9 {
+		t.Errorf("unexpected unchanged stat: %+v (original %+v)", stat, stat.Original)
+	}
+
+	stat, err = StatFile(session, "new.txt")
+	if err != nil {
+		t.Fatalf("StatFile failed: %v", err)
+	}
+	if stat.State != FindStatusAdded || stat.Original != nil {
+		t.Errorf("unexpected added stat: %+v", stat)
+	}
+
+	stat, err = StatFile(session, "gone.txt")
+	if err != nil {
+		t.Fatalf("StatFile failed: %v", err)
+	}
+	if stat.Exists || stat.State != FileStateDeleted || stat.Type != "file" || stat.Original == nil {
+		t.Errorf("unexpected deleted stat: %+v", stat)
+	}
+
+	stat, err = StatFile(session, "new/place.txt")
+	if err != nil {
+		t.Fatalf("StatFile failed: %v", err)
+	}
+	if stat.State != FindStatusRenamed || stat.RenamedFrom != "old.txt" || stat.Original == nil || stat.Original.Name != "old.txt" {
+		t.Errorf("unexpected renamed stat: %+v", stat)
+	}
+
+	stat, err = StatFile(session, "data")
+	if err != nil {
+		t.Fatalf("StatFile failed: %v", err)
+	}
+	if stat.Type != "dir" || stat.State != FindStatusUnchanged || stat.Original != nil {
+		t.Errorf("unexpected implied directory stat: %+v", stat)
+	}
+
+	if _, err := StatFile(session, "missing.txt"); !errors.Is(err, errors.CodePathNotFound) {
+		t.Errorf("expected PATH_NOT_FOUND, got %v", err)
+	}
+	if _, err := StatFile(session, "../escape"); err == nil {
+		t.Error("expected traversal to fail")
+	}
+}
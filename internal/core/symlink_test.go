@@ -0,0 +1,327 @@
+package core
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+func TestExtractArchive_SkipsSymlinksByDefault(t *testing.T) {
+	tempDir := t.TempDir()
+	zipPath := filepath.Join(tempDir, "test.zip")
+	destDir := filepath.Join(tempDir, "extracted")
+	os.MkdirAll(destDir, 0755)
+
+	createSymlinkZip(t, zipPath,
+		map[string]string{"target.txt": "content"},
+		map[string]string{"link.txt": "target.txt"})
+
+	result, err := ExtractArchive(zipPath, destDir, security.DefaultLimits())
+	if err != nil {
+		t.Fatalf("failed to extract: %v", err)
+	}
+
+	if result.FileCount != 1 {
+		t.Errorf("expected 1 file, got %d", result.FileCount)
+	}
+
+	if len(result.SkippedSymlinks) != 1 || result.SkippedSymlinks[0] != "link.txt" {
+		t.Errorf("expected link.txt to be skipped, got %v", result.SkippedSymlinks)
+	}
+
+	if _, err := os.Lstat(filepath.Join(destDir, "link.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected skipped symlink not to exist, got %v", err)
+	}
+}
+
+func TestExtractArchive_RecreatesSymlinks(t *testing.T) {
+	tempDir := t.TempDir()
+	zipPath := filepath.Join(tempDir, "test.zip")
+	destDir := filepath.Join(tempDir, "extracted")
+	os.MkdirAll(destDir, 0755)
+
+	createSymlinkZip(t, zipPath,
+		map[string]string{"dir/target.txt": "content"},
+		map[string]string{"link.txt": "dir/target.txt", "dir/up": ".."})
+
+	limits := security.DefaultLimits()
+	limits.AllowSymlinks = true
+
+	result, err := ExtractArchive(zipPath, destDir, limits)
+	if err != nil {
+		t.Fatalf("failed to extract: %v", err)
+	}
+
+	if len(result.SkippedSymlinks) != 0 {
+		t.Errorf("expected no skipped symlinks, got %v", result.SkippedSymlinks)
+	}
+
+	target, err := os.Readlink(filepath.Join(destDir, "link.txt"))
+	if err != nil {
+		t.Fatalf("expected link.txt to be a symlink: %v", err)
+	}
+	if target != "dir/target.txt" {
+		t.Errorf("expected target dir/target.txt, got %q", target)
+	}
+
+	data, err := os.ReadFile(filepath.Join(destDir, "link.txt"))
+	if err != nil {
+		t.Fatalf("failed to read through symlink: %v", err)
+	}
+	if string(data) != "content" {
+		t.Errorf("expected content, got %q", data)
+	}
+}
+
+func TestExtractArchive_RejectsEscapingSymlinks(t *testing.T) {
+	tests := map[string]string{
+		"absolute": "/etc/passwd",
+		"parent":   "../../outside",
+	}
+
+	for name, target := range tests {
+		t.Run(name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			zipPath := filepath.Join(tempDir, "test.zip")
+			destDir := filepath.Join(tempDir, "extracted")
+			os.MkdirAll(destDir, 0755)
+
+			createSymlinkZip(t, zipPath,
+				map[string]string{"file.txt": "content"},
+				map[string]string{"link": target})
+
+			limits := security.DefaultLimits()
+			limits.AllowSymlinks = true
+
+			_, err := ExtractArchive(zipPath, destDir, limits)
+			if err == nil {
+				t.Fatal("expected escaping symlink to be rejected")
+			}
+			if !errors.Is(err, errors.CodePathTraversal) {
+				t.Errorf("expected PATH_TRAVERSAL, got %v", err)
+			}
+
+			// Fail-closed: nothing is extracted
+			if _, err := os.Stat(filepath.Join(destDir, "file.txt")); !os.IsNotExist(err) {
+				t.Error("expected no files to be extracted")
+			}
+		})
+	}
+}
+
+// writeOrderedZip writes entries in the given order; a target marks a symlink.
+func writeOrderedZip(t *testing.T, zipPath string, entries []struct{ name, content, target string }) {
+	t.Helper()
+
+	out, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("failed to create zip file: %v", err)
+	}
+	w := zip.NewWriter(out)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Store}
+		data := e.content
+		if e.target != "" {
+			header.SetMode(os.ModeSymlink | 0777)
+			data = e.target
+		} else {
+			header.SetMode(0644)
+		}
+		fw, err := w.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", e.name, err)
+		}
+		if _, err := fw.Write([]byte(data)); err != nil {
+			t.Fatalf("failed to write %s: %v", e.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to finalize zip: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+}
+
+func TestExtractArchive_RejectsSymlinkChains(t *testing.T) {
+	type entry = struct{ name, content, target string }
+	tests := map[string][]entry{
+		// d -> . makes d/l the top-level l, whose target leaves destDir
+		"entry through link": {
+			{name: "d", target: "."},
+			{name: "d/l", target: "../outside"},
+			{name: "d/l/pwned.txt", content: "pwned"},
+		},
+		// Lexically x, but d/.. is the parent of destDir
+		"target through link": {
+			{name: "d", target: "."},
+			{name: "l", target: "d/../outside"},
+		},
+	}
+
+	for name, entries := range tests {
+		t.Run(name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			zipPath := filepath.Join(tempDir, "test.zip")
+			destDir := filepath.Join(tempDir, "extracted")
+			os.MkdirAll(destDir, 0755)
+			os.MkdirAll(filepath.Join(tempDir, "outside"), 0755)
+
+			writeOrderedZip(t, zipPath, entries)
+
+			limits := security.DefaultLimits()
+			limits.AllowSymlinks = true
+
+			_, err := ExtractArchive(zipPath, destDir, limits)
+			if !errors.Is(err, errors.CodePathTraversal) {
+				t.Errorf("expected PATH_TRAVERSAL, got %v", err)
+			}
+			if _, err := os.Lstat(filepath.Join(tempDir, "outside", "pwned.txt")); !os.IsNotExist(err) {
+				t.Errorf("expected nothing written outside destDir, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRepack_StoresSymlinkEntries(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	os.MkdirAll(sourceDir, 0755)
+
+	os.WriteFile(filepath.Join(sourceDir, "target.txt"), []byte("content"), 0644)
+	os.Symlink("target.txt", filepath.Join(sourceDir, "inside"))
+	os.Symlink("/etc/passwd", filepath.Join(sourceDir, "outside"))
+
+	zipPath := filepath.Join(tempDir, "output.zip")
+	opts := RepackOptions{
+		Fallback: zip.Deflate,
+		Symlinks: map[string]string{"carried": "target.txt", "inside": "ignored"},
+	}
+	if err := RepackWithOptions(sourceDir, zipPath, opts); err != nil {
+		t.Fatalf("failed to repack: %v", err)
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("failed to open zip: %v", err)
+	}
+	defer r.Close()
+
+	entries := make(map[string]*zip.File)
+	for _, f := range r.File {
+		entries[f.Name] = f
+	}
+
+	if _, ok := entries["outside"]; ok {
+		t.Error("expected symlink escaping the contents directory to be skipped")
+	}
+
+	for name, want := range map[string]string{"inside": "target.txt", "carried": "target.txt"} {
+		f, ok := entries[name]
+		if !ok {
+			t.Fatalf("expected %s entry in zip", name)
+		}
+		if f.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("expected %s to be a symlink entry, got mode %v", name, f.Mode())
+		}
+		target, err := readSymlinkTarget(f)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if target != want {
+			t.Errorf("expected %s -> %s, got %q", name, want, target)
+		}
+	}
+}
+
+func TestSync_PreservesSymlinks(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		name := "skipped"
+		if allow {
+			name = "allowed"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			setupTestEnvironment(t)
+			tempDir := t.TempDir()
+
+			zipPath := filepath.Join(tempDir, "test.zip")
+			createSymlinkZip(t, zipPath,
+				map[string]string{"target.txt": "content"},
+				map[string]string{"link.txt": "target.txt"})
+
+			cfg := DefaultConfig()
+			cfg.Security.AllowSymlinks = allow
+			session, err := CreateSession(zipPath, "links", cfg)
+			if err != nil {
+				t.Fatalf("failed to create session: %v", err)
+			}
+
+			// An unmodified symlink is neither deleted nor modified
+			status, err := Status(session)
+			if err != nil {
+				t.Fatalf("failed to get status: %v", err)
+			}
+			if len(status.Deleted) != 0 || len(status.Modified) != 0 {
+				t.Errorf("expected no changes, got deleted=%v modified=%v", status.Deleted, status.Modified)
+			}
+
+			if _, err := Sync(session, false, cfg); err != nil {
+				t.Fatalf("failed to sync: %v", err)
+			}
+
+			r, err := zip.OpenReader(zipPath)
+			if err != nil {
+				t.Fatalf("failed to open synced zip: %v", err)
+			}
+			defer r.Close()
+
+			found := false
+			for _, f := range r.File {
+				if f.Name != "link.txt" {
+					continue
+				}
+				found = true
+				if f.Mode()&os.ModeSymlink == 0 {
+					t.Errorf("expected link.txt to remain a symlink, got mode %v", f.Mode())
+				}
+			}
+			if !found {
+				t.Error("expected link.txt to survive sync")
+			}
+		})
+	}
+}
+
+func TestListFiles_SymlinkType(t *testing.T) {
+	tempDir := t.TempDir()
+	contentsDir := filepath.Join(tempDir, "contents")
+	os.MkdirAll(contentsDir, 0755)
+
+	os.WriteFile(filepath.Join(contentsDir, "target.txt"), []byte("content"), 0644)
+	os.Symlink("target.txt", filepath.Join(contentsDir, "link.txt"))
+
+	entries, err := ListFiles(contentsDir, ".", false)
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.Name != "link.txt" {
+			continue
+		}
+		if entry.Type != "symlink" {
+			t.Errorf("expected type symlink, got %q", entry.Type)
+		}
+		if entry.Target != "target.txt" {
+			t.Errorf("expected target target.txt, got %q", entry.Target)
+		}
+		return
+	}
+
+	t.Error("expected link.txt in listing")
+}
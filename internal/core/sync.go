@@ -12,13 +12,47 @@ import (
 // SyncResult contains the results of a sync operation.
 type SyncResult struct {
 	StatusError     error
+	Recovery        *SyncRecovery
 	BackupPath      string
 	FilesModified   int
 	FilesAdded      int
 	FilesDeleted    int
+	FilesRenamed    int
+	DirsAdded       int
+	DirsDeleted     int
 	NewZipSizeBytes uint64
 }
 
+// Sync journal steps, recorded in metadata before the step starts.
+const (
+	SyncStepRepack        = "repack"         // building the temp zip; source untouched
+	SyncStepRotateBackups = "rotate_backups" // temp zip is durable; source may be moving to BackupPath
+	SyncStepReplace       = "replace"        // backups rotated; temp zip being renamed over source
+)
+
+// Sync recovery actions.
+const (
+	SyncRecoveryRolledBack = "rolled_back"
+	SyncRecoveryCompleted  = "completed"
+)
+
+// SyncJournal is the write-ahead marker stored in session metadata while Sync is
+// touching the source zip. After a crash it tells recovery exactly which step was
+// in flight and which files to inspect.
+type SyncJournal struct {
+	StartedAt  time.Time `json:"started_at"`
+	Step       string    `json:"step"`
+	TempPath   string    `json:"temp_path"`
+	TempHash   string    `json:"temp_hash_sha256,omitempty"`
+	BackupPath string    `json:"backup_path,omitempty"`
+}
+
+// SyncRecovery describes how an interrupted sync was resolved.
+type SyncRecovery struct {
+	Step   string `json:"step"`
+	Action string `json:"action"`
+}
+
 // Sync synchronizes the workspace contents back to the source zip file.
 // This implements the sync workflow from ADR-004.
 func Sync(session *Session, force bool, cfg *Config) (*SyncResult, error) {
@@ -36,23 +70,48 @@ func Sync(session *Session, force bool, cfg *Config) (*SyncResult, error) {
 	}
 	defer func() { _ = lock.Release() }()
 
-	// 2. Verify session state is "open"
+	// 2. Verify session state is "open". Holding the exclusive lock, a "syncing"
+	// state can only be left behind by a sync that crashed, so finish or undo it first.
+	var recovery *SyncRecovery
+	if session.State == "syncing" {
+		recovery, err = recoverSync(session)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover interrupted sync: %w", err)
+		}
+	}
 	if session.State != "open" {
 		return nil, fmt.Errorf("session state is %q, expected \"open\"", session.State)
 	}
 
+	// Never pack a workspace with staged changes pending, or one left
+	// half-changed by a commit or atomic batch that crashed
+	contentsDir, err := ContentsDir(dirName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contents directory: %w", err)
+	}
+	if err := EnsureNoTx(contentsDir); err != nil {
+		return nil, err
+	}
+	if err := RecoverUndoJournals(contentsDir); err != nil {
+		return nil, err
+	}
+
 	// 3. Set state to "syncing"
 	session.State = "syncing"
 	if err := UpdateSession(session, dirName); err != nil {
 		return nil, fmt.Errorf("failed to update session state: %w", err)
 	}
 
-	// Defer restoring state to "open" on error
+	// Defer undoing any half-finished step and restoring state to "open" on
+	// error. A failed Sync never rolls forward, so the source and metadata are
+	// left as they were before it started.
 	restoreState := true
 	defer func() {
 		if restoreState {
-			session.State = "open"
-			_ = UpdateSession(session, dirName)
+			if err := rollbackSync(session); err != nil {
+				session.State = "open"
+				_ = UpdateSession(session, dirName)
+			}
 		}
 	}()
 
@@ -77,10 +136,19 @@ func Sync(session *Session, force bool, cfg *Config) (*SyncResult, error) {
 		return nil, errors.ConflictDetected(session.SourcePath)
 	}
 
-	// 7. Build new zip from contents into temp file
-	contentsDir, err := ContentsDir(dirName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get contents directory: %w", err)
+	// 7. Build new zip from contents into temp file. The temp zip is written
+	// beside the source zip, outside the workspaces, but until it replaces the
+	// source it is extra disk held by zipfs, so its projected size must fit
+	// MaxTotalDiskBytes before anything is written. The uncompressed contents
+	// bound it, apart from the zip headers
+	if cfg.Security.MaxTotalDiskBytes > 0 {
+		projected, err := dirDiskUsage(contentsDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure workspace contents: %w", err)
+		}
+		if err := ensureDiskBudget(cfg, projected, false); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create temp file in the same directory as source (for atomic rename)
@@ -91,19 +159,29 @@ func Sync(session *Session, force bool, cfg *Config) (*SyncResult, error) {
 	tempPath := tempFile.Name()
 	tempFile.Close()
 
-	// Ensure temp file is cleaned up on error
-	cleanupTemp := true
-	defer func() {
-		if cleanupTemp {
-			os.Remove(tempPath)
-		}
-	}()
+	// Journal the temp path before writing it, so a crash never leaks the file
+	journal := &SyncJournal{
+		StartedAt: time.Now(),
+		Step:      SyncStepRepack,
+		TempPath:  tempPath,
+	}
+	if err := writeSyncJournal(session, dirName, journal); err != nil {
+		os.Remove(tempPath)
+		return nil, err
+	}
 
 	// Capture status before repack to compute file changes
 	statusResult, statusErr := Status(session)
 
-	// Repack the contents
-	if err := Repack(contentsDir, tempPath); err != nil {
+	// Repack the contents, keeping each entry's original compression method
+	repackOpts, err := syncRepackOptions(session, cfg)
+	if err != nil {
+		return nil, errors.SyncFailed(err)
+	}
+	if err := RepackWithOptions(contentsDir, tempPath, repackOpts); err != nil {
+		return nil, errors.SyncFailed(err)
+	}
+	if err := syncDir(sourceDir); err != nil {
 		return nil, errors.SyncFailed(err)
 	}
 
@@ -113,30 +191,44 @@ func Sync(session *Session, force bool, cfg *Config) (*SyncResult, error) {
 		return nil, fmt.Errorf("failed to stat temp file: %w", err)
 	}
 
+	// The hash lets recovery tell a complete temp zip (or replaced source) apart
+	tempHash, err := ComputeZipHash(tempPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute temp hash: %w", err)
+	}
+
 	// 8-9. Rotate existing backups
+	journal.Step = SyncStepRotateBackups
+	journal.TempHash = tempHash
+	journal.BackupPath = BackupPath(session.SourcePath)
+	if err := writeSyncJournal(session, dirName, journal); err != nil {
+		return nil, err
+	}
+
 	backupPath, err := RotateBackups(session.SourcePath, cfg.Defaults.BackupRotationDepth)
 	if err != nil {
 		return nil, fmt.Errorf("failed to rotate backups: %w", err)
 	}
 
 	// 10. Rename temp file to source.zip
-	if err := os.Rename(tempPath, session.SourcePath); err != nil {
+	journal.Step = SyncStepReplace
+	if err := writeSyncJournal(session, dirName, journal); err != nil {
+		return nil, err
+	}
+
+	if err := renameDurable(tempPath, session.SourcePath); err != nil {
 		return nil, fmt.Errorf("failed to rename temp file to source: %w", err)
 	}
-	cleanupTemp = false // Successfully renamed, don't clean up
 
 	// 11. Update metadata
 	now := time.Now()
 	session.LastSyncedAt = &now
 
-	newHash, err := ComputeZipHash(session.SourcePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute new hash: %w", err)
-	}
-	session.ZipHashSHA256 = newHash
+	session.ZipHashSHA256 = tempHash
 
-	// 12. Set state back to "open"
+	// 12. Set state back to "open" and clear the journal
 	session.State = "open"
+	session.SyncJournal = nil
 	restoreState = false // Don't restore in defer
 
 	if err := UpdateSession(session, dirName); err != nil {
@@ -144,6 +236,7 @@ func Sync(session *Session, force bool, cfg *Config) (*SyncResult, error) {
 	}
 
 	result := &SyncResult{
+		Recovery:        recovery,
 		BackupPath:      backupPath,
 		NewZipSizeBytes: uint64(tempInfo.Size()),
 	}
@@ -155,11 +248,42 @@ func Sync(session *Session, force bool, cfg *Config) (*SyncResult, error) {
 		result.FilesModified = len(statusResult.Modified)
 		result.FilesAdded = len(statusResult.Added)
 		result.FilesDeleted = len(statusResult.Deleted)
+		result.FilesRenamed = len(statusResult.Renamed)
+		result.DirsAdded = len(statusResult.AddedDirs)
+		result.DirsDeleted = len(statusResult.DeletedDirs)
 	}
 
 	return result, nil
 }
 
+// syncRepackOptions builds repack options from the session's original.zip and config.
+// Symlink entries skipped at extraction are carried over so sync never drops them.
+func syncRepackOptions(session *Session, cfg *Config) (RepackOptions, error) {
+	dirName := session.DirName()
+
+	fallback, err := ParseCompressionMethod(cfg.Defaults.CompressionFallback)
+	if err != nil {
+		return RepackOptions{}, fmt.Errorf("invalid compression_fallback: %w", err)
+	}
+
+	originalZipPath, err := OriginalZipPath(dirName)
+	if err != nil {
+		return RepackOptions{}, fmt.Errorf("failed to get original zip path: %w", err)
+	}
+
+	methods, err := ZipMethods(originalZipPath)
+	if err != nil {
+		return RepackOptions{}, fmt.Errorf("failed to read original compression methods: %w", err)
+	}
+
+	symlinks, err := ZipSymlinks(originalZipPath, session.SkippedSymlinks)
+	if err != nil {
+		return RepackOptions{}, fmt.Errorf("failed to read skipped symlinks: %w", err)
+	}
+
+	return RepackOptions{Methods: methods, Fallback: fallback, Symlinks: symlinks}, nil
+}
+
 // RotateBackups rotates backup files for a source zip.
 // Returns the path to the new backup file.
 func RotateBackups(sourcePath string, maxDepth int) (string, error) {
@@ -183,7 +307,7 @@ func RotateBackups(sourcePath string, maxDepth int) (string, error) {
 	}
 
 	// Rename source.bak to source.bak.2 if it exists
-	bakPath := fmt.Sprintf("%s.bak%s", base, ext)
+	bakPath := BackupPath(sourcePath)
 	bak2Path := fmt.Sprintf("%s.bak.2%s", base, ext)
 
 	if _, err := os.Stat(bakPath); err == nil {
@@ -198,9 +322,169 @@ func RotateBackups(sourcePath string, maxDepth int) (string, error) {
 		return "", fmt.Errorf("failed to create backup: %w", err)
 	}
 
+	// Persist the renames before the caller moves a new file into place
+	if err := syncDir(filepath.Dir(sourcePath)); err != nil {
+		return "", fmt.Errorf("failed to sync backup directory: %w", err)
+	}
+
 	return bakPath, nil
 }
 
+// BackupPath returns the path of the most recent backup for a source zip.
+func BackupPath(sourcePath string) string {
+	ext := filepath.Ext(sourcePath)
+	base := sourcePath[:len(sourcePath)-len(ext)]
+	return fmt.Sprintf("%s.bak%s", base, ext)
+}
+
+// writeSyncJournal records the step about to run in the session metadata.
+func writeSyncJournal(session *Session, dirName string, journal *SyncJournal) error {
+	session.SyncJournal = journal
+	if err := UpdateSession(session, dirName); err != nil {
+		return fmt.Errorf("failed to write sync journal: %w", err)
+	}
+	return nil
+}
+
+// recoverSync resolves a sync that stopped part-way, using the journal to decide
+// deterministically whether to roll forward or back. The caller must hold the
+// session's exclusive lock. On return the session is "open" with no journal.
+//
+// The rule is: roll forward only when the new zip is verifiably complete (its hash
+// matches the journal), otherwise restore the source from the backup.
+func recoverSync(session *Session) (*SyncRecovery, error) {
+	dirName := session.DirName()
+	journal := session.SyncJournal
+
+	// No journal means the crash happened before the source was touched
+	if journal == nil {
+		session.State = "open"
+		if err := UpdateSession(session, dirName); err != nil {
+			return nil, fmt.Errorf("failed to reset session state: %w", err)
+		}
+		return &SyncRecovery{Action: SyncRecoveryRolledBack}, nil
+	}
+
+	recovery := &SyncRecovery{Step: journal.Step, Action: SyncRecoveryRolledBack}
+	sourcePath := session.SourcePath
+
+	switch journal.Step {
+	case SyncStepRepack:
+		// Source untouched; discard the partial temp zip
+
+	case SyncStepRotateBackups, SyncStepReplace:
+		sourceExists := fileExists(sourcePath)
+		tempComplete := journal.TempHash != "" && hashMatches(journal.TempPath, journal.TempHash)
+
+		switch {
+		case sourceExists && hashMatches(sourcePath, journal.TempHash):
+			// Temp zip already renamed over source; only metadata is stale
+			recovery.Action = SyncRecoveryCompleted
+		case sourceExists:
+			// Source never moved; discard the temp zip
+		case tempComplete:
+			// Source moved to backup, new zip ready: finish the rename
+			if err := renameDurable(journal.TempPath, sourcePath); err == nil {
+				recovery.Action = SyncRecoveryCompleted
+			} else if err := restoreBackup(journal.BackupPath, sourcePath); err != nil {
+				return nil, err
+			}
+		default:
+			// Source moved to backup but the new zip is unusable: restore the backup
+			if err := restoreBackup(journal.BackupPath, sourcePath); err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown sync journal step %q", journal.Step)
+	}
+
+	if recovery.Action == SyncRecoveryCompleted {
+		now := time.Now()
+		session.LastSyncedAt = &now
+		session.ZipHashSHA256 = journal.TempHash
+	} else if journal.TempPath != "" {
+		os.Remove(journal.TempPath)
+	}
+
+	session.State = "open"
+	session.SyncJournal = nil
+	if err := UpdateSession(session, dirName); err != nil {
+		return nil, fmt.Errorf("failed to clear sync journal: %w", err)
+	}
+
+	return recovery, nil
+}
+
+// rollbackSync undoes a sync that failed in this process, restoring the source
+// from the backup even when the new zip is already complete. Unlike recoverSync
+// it never rolls forward, so the caller can report the failure truthfully. The
+// caller must hold the session's exclusive lock. On success the session is
+// "open" with no journal.
+func rollbackSync(session *Session) error {
+	journal := session.SyncJournal
+
+	if journal != nil {
+		switch journal.Step {
+		case SyncStepRepack:
+			// Source untouched
+		case SyncStepRotateBackups, SyncStepReplace:
+			// While the temp zip exists it has not replaced the source, which is
+			// then either untouched or moved to the backup. Once it is gone the
+			// source is the new zip and the backup holds the original.
+			if fileExists(journal.TempPath) {
+				if !fileExists(session.SourcePath) {
+					if err := restoreBackup(journal.BackupPath, session.SourcePath); err != nil {
+						return err
+					}
+				}
+			} else if err := restoreBackup(journal.BackupPath, session.SourcePath); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown sync journal step %q", journal.Step)
+		}
+
+		if journal.TempPath != "" {
+			os.Remove(journal.TempPath)
+		}
+	}
+
+	session.State = "open"
+	session.SyncJournal = nil
+	if err := UpdateSession(session, session.DirName()); err != nil {
+		return fmt.Errorf("failed to clear sync journal: %w", err)
+	}
+	return nil
+}
+
+// restoreBackup moves the most recent backup back to the source path.
+func restoreBackup(backupPath, sourcePath string) error {
+	if backupPath == "" {
+		return fmt.Errorf("source %q is missing and no backup is recorded", sourcePath)
+	}
+	if err := renameDurable(backupPath, sourcePath); err != nil {
+		return fmt.Errorf("failed to restore backup %q: %w", backupPath, err)
+	}
+	return nil
+}
+
+// hashMatches reports whether the file at path exists and has the given SHA-256.
+func hashMatches(path, hash string) bool {
+	if hash == "" {
+		return false
+	}
+	current, err := ComputeZipHash(path)
+	return err == nil && current == hash
+}
+
+// fileExists reports whether path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 // checkWritable checks if a directory is writable.
 func checkWritable(dir string) error {
 	tempFile, err := os.CreateTemp(dir, ".zipfs-write-test-*")
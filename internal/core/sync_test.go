@@ -565,3 +565,288 @@ func TestRotateBackups_ZeroDepth(t *testing.T) {
 		t.Error("expected backup path to be returned")
 	}
 }
+
+// simulateInterruptedSync prepares a session as if a sync crashed at the given step.
+// It returns the path and hash of the "new" zip the interrupted sync had built.
+func simulateInterruptedSync(t *testing.T, session *Session, step string) (string, string) {
+	t.Helper()
+
+	newDir := filepath.Join(t.TempDir(), "new")
+	os.MkdirAll(newDir, 0755)
+	os.WriteFile(filepath.Join(newDir, "file.txt"), []byte("synced before crash"), 0644)
+
+	tempPath := filepath.Join(filepath.Dir(session.SourcePath), ".test.zip.zipfs-tmp-crash")
+	if err := Repack(newDir, tempPath); err != nil {
+		t.Fatalf("failed to build temp zip: %v", err)
+	}
+	tempHash, err := ComputeZipHash(tempPath)
+	if err != nil {
+		t.Fatalf("failed to hash temp zip: %v", err)
+	}
+
+	session.State = "syncing"
+	session.SyncJournal = &SyncJournal{
+		Step:       step,
+		TempPath:   tempPath,
+		TempHash:   tempHash,
+		BackupPath: BackupPath(session.SourcePath),
+	}
+	if step == SyncStepRepack {
+		session.SyncJournal.TempHash = ""
+		session.SyncJournal.BackupPath = ""
+	}
+	if err := UpdateSession(session, ""); err != nil {
+		t.Fatalf("failed to write journal: %v", err)
+	}
+
+	return tempPath, tempHash
+}
+
+// zipFileContent returns the content of one entry in a zip file.
+func zipFileContent(t *testing.T, zipPath, name string) string {
+	t.Helper()
+
+	extractDir := t.TempDir()
+	if _, _, err := Extract(zipPath, extractDir, security.DefaultLimits()); err != nil {
+		t.Fatalf("failed to extract %s: %v", zipPath, err)
+	}
+	data, err := os.ReadFile(filepath.Join(extractDir, name))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestSync_RecoversInterruptedRepack(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "original"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "recover-repack", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	tempPath, _ := simulateInterruptedSync(t, session, SyncStepRepack)
+
+	result, err := Sync(session, false, cfg)
+	if err != nil {
+		t.Fatalf("sync after crash failed: %v", err)
+	}
+
+	if result.Recovery == nil || result.Recovery.Action != SyncRecoveryRolledBack {
+		t.Errorf("expected rolled_back recovery, got %+v", result.Recovery)
+	}
+	if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
+		t.Error("expected partial temp zip to be removed")
+	}
+	if got := zipFileContent(t, zipPath, "file.txt"); got != "original" {
+		t.Errorf("expected source content %q, got %q", "original", got)
+	}
+	if session.SyncJournal != nil {
+		t.Error("expected journal to be cleared")
+	}
+}
+
+func TestSync_RecoversAfterSourceMovedToBackup(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "original"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "recover-rotate", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	tempPath, tempHash := simulateInterruptedSync(t, session, SyncStepRotateBackups)
+
+	// Crash after the source was renamed to .bak but before temp replaced it
+	if err := os.Rename(zipPath, BackupPath(zipPath)); err != nil {
+		t.Fatalf("failed to move source: %v", err)
+	}
+
+	recovery, err := recoverSync(session)
+	if err != nil {
+		t.Fatalf("recovery failed: %v", err)
+	}
+
+	if recovery.Action != SyncRecoveryCompleted {
+		t.Errorf("expected completed recovery, got %q", recovery.Action)
+	}
+	if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
+		t.Error("expected temp zip to be renamed into place")
+	}
+	if got := zipFileContent(t, zipPath, "file.txt"); got != "synced before crash" {
+		t.Errorf("expected new content, got %q", got)
+	}
+	if session.ZipHashSHA256 != tempHash {
+		t.Error("expected session hash to match recovered zip")
+	}
+
+	// Metadata on disk must reflect the recovery
+	reloaded, err := GetSession(session.Name)
+	if err != nil {
+		t.Fatalf("failed to reload session: %v", err)
+	}
+	if reloaded.State != "open" || reloaded.SyncJournal != nil {
+		t.Errorf("expected open state without journal, got %q / %+v", reloaded.State, reloaded.SyncJournal)
+	}
+}
+
+func TestSync_RecoveryRestoresBackupWhenTempLost(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "original"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "recover-restore", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	tempPath, _ := simulateInterruptedSync(t, session, SyncStepReplace)
+
+	// Source moved to backup and the temp zip is gone (e.g. truncated and cleaned)
+	os.Rename(zipPath, BackupPath(zipPath))
+	os.Remove(tempPath)
+
+	recovery, err := recoverSync(session)
+	if err != nil {
+		t.Fatalf("recovery failed: %v", err)
+	}
+
+	if recovery.Action != SyncRecoveryRolledBack {
+		t.Errorf("expected rolled_back recovery, got %q", recovery.Action)
+	}
+	if got := zipFileContent(t, zipPath, "file.txt"); got != "original" {
+		t.Errorf("expected original content restored, got %q", got)
+	}
+}
+
+func TestSync_RecoveryCompletesAfterReplace(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "original"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "recover-replace", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	tempPath, _ := simulateInterruptedSync(t, session, SyncStepReplace)
+
+	// Crash after temp was renamed over source but before metadata was updated
+	os.Rename(zipPath, BackupPath(zipPath))
+	os.Rename(tempPath, zipPath)
+
+	// A normal sync must not report a conflict for the already-replaced source
+	result, err := Sync(session, false, cfg)
+	if err != nil {
+		t.Fatalf("sync after crash failed: %v", err)
+	}
+
+	if result.Recovery == nil || result.Recovery.Action != SyncRecoveryCompleted {
+		t.Errorf("expected completed recovery, got %+v", result.Recovery)
+	}
+	if result.Recovery != nil && result.Recovery.Step != SyncStepReplace {
+		t.Errorf("expected recovered step %q, got %q", SyncStepReplace, result.Recovery.Step)
+	}
+	if session.SyncJournal != nil {
+		t.Error("expected journal to be cleared")
+	}
+}
+
+func TestSync_RollbackNeverRollsForward(t *testing.T) {
+	tests := []struct {
+		name  string
+		crash func(zipPath, tempPath string)
+	}{
+		{"source moved to backup", func(zipPath, tempPath string) {
+			os.Rename(zipPath, BackupPath(zipPath))
+		}},
+		{"temp renamed over source", func(zipPath, tempPath string) {
+			os.Rename(zipPath, BackupPath(zipPath))
+			os.Rename(tempPath, zipPath)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestEnvironment(t)
+			tempDir := t.TempDir()
+
+			zipPath := filepath.Join(tempDir, "test.zip")
+			createTestZip(t, zipPath, map[string]string{"file.txt": "original"})
+
+			session, err := CreateSession(zipPath, "rollback", DefaultConfig())
+			if err != nil {
+				t.Fatalf("failed to create session: %v", err)
+			}
+			originalHash := session.ZipHashSHA256
+
+			tempPath, _ := simulateInterruptedSync(t, session, SyncStepReplace)
+			tt.crash(zipPath, tempPath)
+
+			// The new zip is complete, but a failing Sync must still undo it
+			if err := rollbackSync(session); err != nil {
+				t.Fatalf("rollback failed: %v", err)
+			}
+
+			if got := zipFileContent(t, zipPath, "file.txt"); got != "original" {
+				t.Errorf("expected original content, got %q", got)
+			}
+			if session.ZipHashSHA256 != originalHash || session.LastSyncedAt != nil {
+				t.Error("expected sync metadata to be unchanged")
+			}
+			if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
+				t.Error("expected temp zip to be removed")
+			}
+			if session.State != "open" || session.SyncJournal != nil {
+				t.Errorf("expected open state without journal, got %q / %+v", session.State, session.SyncJournal)
+			}
+		})
+	}
+}
+
+func TestSync_ClearsJournal(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "original"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "journal", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	if _, err := Sync(session, false, cfg); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+
+	reloaded, err := GetSession(session.Name)
+	if err != nil {
+		t.Fatalf("failed to reload session: %v", err)
+	}
+	if reloaded.SyncJournal != nil {
+		t.Errorf("expected no journal after successful sync, got %+v", reloaded.SyncJournal)
+	}
+
+	// No temp files may be left next to the source
+	matches, _ := filepath.Glob(filepath.Join(tempDir, ".test.zip.zipfs-tmp-*"))
+	if len(matches) != 0 {
+		t.Errorf("expected no leftover temp files, got %v", matches)
+	}
+}
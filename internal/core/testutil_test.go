@@ -83,3 +83,53 @@ func setupTestEnvironment(t *testing.T) string {
 
 	return tempDir
 }
+
+// createSymlinkZip creates a test zip with regular files and symlink entries.
+// links is a map of link path -> target.
+func createSymlinkZip(t *testing.T, zipPath string, files, links map[string]string) {
+	t.Helper()
+
+	createTestZip(t, zipPath, files)
+
+	// Re-open and append symlink entries by rewriting the archive
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("failed to open zip file: %v", err)
+	}
+	defer r.Close()
+
+	tmpPath := zipPath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		t.Fatalf("failed to create zip file: %v", err)
+	}
+
+	w := zip.NewWriter(out)
+	for _, f := range r.File {
+		if err := w.Copy(f); err != nil {
+			t.Fatalf("failed to copy entry %s: %v", f.Name, err)
+		}
+	}
+
+	for path, target := range links {
+		header := &zip.FileHeader{Name: path, Method: zip.Store}
+		header.SetMode(os.ModeSymlink | 0777)
+		lw, err := w.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("failed to create symlink %s: %v", path, err)
+		}
+		if _, err := lw.Write([]byte(target)); err != nil {
+			t.Fatalf("failed to write symlink %s: %v", path, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to finalize zip: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	if err := os.Rename(tmpPath, zipPath); err != nil {
+		t.Fatalf("failed to replace zip: %v", err)
+	}
+}
@@ -0,0 +1,437 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+	"github.com/google/uuid"
+)
+
+// Transaction layout inside a workspace directory.
+const (
+	txDirName      = "tx"
+	txManifestFile = "transaction.json"
+	txStagedDir    = "staged"
+)
+
+// Transaction operations.
+const (
+	TxOpWrite  = "write"
+	TxOpDelete = "delete"
+)
+
+// txLockTimeout bounds the wait for the session lock when staging or committing.
+const txLockTimeout = 10 * time.Second
+
+// Transaction groups writes and deletes so that they reach contents/ together.
+// While one is open on a session, WriteFile, DeleteFile and DeleteGlob stage
+// their changes under <workspace>/tx/ instead of touching contents/, and
+// EditFile, MoveFile and copies into the session are refused. Reads, listings
+// and Status see the committed contents only.
+type Transaction struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	Ops       []TxOp    `json:"ops"`
+}
+
+// TxOp is one staged change. Ops apply in order, so a later op on the same
+// path wins.
+type TxOp struct {
+	Op         string `json:"op"` // TxOpWrite or TxOpDelete
+	Path       string `json:"path"`
+	SizeBytes  int64  `json:"size_bytes,omitempty"`  // writes only
+	CreateDirs bool   `json:"create_dirs,omitempty"` // writes only
+	Recursive  bool   `json:"recursive,omitempty"`   // deletes only
+	Staged     string `json:"staged,omitempty"`      // file under tx/staged/, writes only
+}
+
+// BeginTransaction opens a transaction on a session. Only one may be open at a
+// time; a second begin fails with TX_OPEN.
+func BeginTransaction(session *Session) (*Transaction, error) {
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contents directory: %w", err)
+	}
+
+	lock, err := acquireTxLock(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = lock.Release() }()
+
+	if txExists(contentsDir) {
+		return nil, errors.TxOpen(session.DirName())
+	}
+
+	// Clear what a begin that crashed before writing its manifest left behind
+	dir := txDir(contentsDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to clear transaction directory: %w", err)
+	}
+
+	tx := &Transaction{ID: uuid.New().String(), StartedAt: time.Now(), Ops: []TxOp{}}
+	if err := os.MkdirAll(filepath.Join(dir, txStagedDir), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create transaction directory: %w", err)
+	}
+	if err := saveTransaction(dir, tx); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// GetTransaction returns the open transaction of a session, or a NO_TX error.
+func GetTransaction(session *Session) (*Transaction, error) {
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contents directory: %w", err)
+	}
+	return loadTransaction(contentsDir)
+}
+
+// CommitTransaction applies the staged ops to contents/ in order and closes
+// the transaction. Each write lands through a temp file renamed over the
+// target, so no file is ever seen half-written, and every path is recorded in
+// an UndoJournal first: if any op fails, the workspace is rolled back and the
+// transaction stays open. The session lock is held throughout, so a sync never
+// packs a partial commit.
+//
+// The journal survives a crash: the next transaction call or sync rolls the
+// workspace back to where the commit started, with the transaction still open.
+// Committing is idempotent, so a commit interrupted by a crash is finished by
+// committing again.
+func CommitTransaction(session *Session) (*Transaction, error) {
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contents directory: %w", err)
+	}
+
+	lock, err := acquireTxLock(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = lock.Release() }()
+
+	tx, err := loadTransaction(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	journal, err := NewUndoJournal(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = journal.Close() }()
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	dir := txDir(contentsDir)
+	for _, op := range tx.Ops {
+		err := journal.Record(op.Path)
+		if err == nil {
+			err = applyTxOp(root, contentsDir, dir, op)
+		}
+		if err != nil {
+			if rerr := journal.Rollback(); rerr != nil {
+				return nil, fmt.Errorf("commit failed at %s %s: %w (rollback failed: %v)", op.Op, op.Path, err, rerr)
+			}
+			return nil, fmt.Errorf("commit failed at %s %s, workspace restored: %w", op.Op, op.Path, err)
+		}
+	}
+
+	// Drop the journal before the manifest: a crash in between leaves a fully
+	// applied commit that committing again finishes, never one rolled back
+	// after its transaction is gone
+	if err := journal.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to remove transaction directory: %w", err)
+	}
+
+	return tx, nil
+}
+
+// AbortTransaction discards the staged ops and closes the transaction.
+// contents/ is not touched.
+func AbortTransaction(session *Session) (*Transaction, error) {
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contents directory: %w", err)
+	}
+
+	lock, err := acquireTxLock(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = lock.Release() }()
+
+	tx, err := loadTransaction(contentsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(txDir(contentsDir)); err != nil {
+		return nil, fmt.Errorf("failed to remove transaction directory: %w", err)
+	}
+
+	return tx, nil
+}
+
+// applyTxOp carries out one staged op on contents/.
+func applyTxOp(root *os.Root, contentsDir, dir string, op TxOp) error {
+	name := rootName(op.Path)
+
+	switch op.Op {
+	case TxOpWrite:
+		data, err := os.ReadFile(filepath.Join(dir, txStagedDir, op.Staged))
+		if err != nil {
+			return fmt.Errorf("failed to read staged file: %w", err)
+		}
+
+		if op.CreateDirs {
+			if err := root.MkdirAll(path.Dir(name), 0755); err != nil {
+				return rootedError(err, op.Path, "create parent directories")
+			}
+		}
+
+		// Keep the mode of a file being replaced, as an in-place write would
+		perm := os.FileMode(0644)
+		if info, err := root.Lstat(name); err == nil && info.Mode().IsRegular() {
+			perm = info.Mode().Perm()
+		}
+		if err := writeRootedAtomic(root, name, data, perm); err != nil {
+			return rootedError(err, op.Path, "write file")
+		}
+		markSearchIndexStale(contentsDir, name)
+
+	case TxOpDelete:
+		info, err := root.Lstat(name)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return rootedError(err, op.Path, "stat path")
+		}
+		if info.IsDir() && !op.Recursive {
+			return fmt.Errorf("path is a directory, use recursive=true to delete")
+		}
+		if err := root.RemoveAll(name); err != nil {
+			return rootedError(err, op.Path, "remove path")
+		}
+		markSearchIndexStale(contentsDir, name)
+
+	default:
+		return fmt.Errorf("unknown transaction op %q", op.Op)
+	}
+
+	return nil
+}
+
+// stageWrite stages a write when a transaction is open on the workspace and
+// reports whether it did. The path has already been validated.
+func stageWrite(contentsDir, relativePath string, content []byte, createDirs bool, cfg *Config) (bool, error) {
+	if !txExists(contentsDir) {
+		return false, nil
+	}
+
+	lock, err := acquireTxLock(contentsDir)
+	if err != nil {
+		return true, err
+	}
+	defer func() { _ = lock.Release() }()
+
+	tx, err := loadTransaction(contentsDir)
+	if errors.Is(err, errors.CodeNoTx) {
+		return false, nil // closed meanwhile
+	}
+	if err != nil {
+		return true, err
+	}
+
+	// Staged content is on disk already, so it counts against the budget now
+	if cfg != nil {
+		if err := EnsureDiskBudget(cfg, uint64(len(content))); err != nil {
+			return true, err
+		}
+	}
+
+	dir := txDir(contentsDir)
+	staged := strconv.Itoa(len(tx.Ops))
+	if err := writeFileAtomic(filepath.Join(dir, txStagedDir, staged), content, 0600); err != nil {
+		return true, fmt.Errorf("failed to stage write: %w", err)
+	}
+
+	tx.Ops = append(tx.Ops, TxOp{
+		Op:         TxOpWrite,
+		Path:       rootName(relativePath),
+		SizeBytes:  int64(len(content)),
+		CreateDirs: createDirs,
+		Staged:     staged,
+	})
+	return true, saveTransaction(dir, tx)
+}
+
+// stageDeletes stages deletes when a transaction is open on the workspace and
+// reports whether it did. Each path must exist, either in contents/ or as an
+// earlier staged write, and directories need recursive. The paths have already
+// been validated.
+func stageDeletes(contentsDir string, relativePaths []string, recursive bool) (bool, error) {
+	if !txExists(contentsDir) {
+		return false, nil
+	}
+
+	lock, err := acquireTxLock(contentsDir)
+	if err != nil {
+		return true, err
+	}
+	defer func() { _ = lock.Release() }()
+
+	tx, err := loadTransaction(contentsDir)
+	if errors.Is(err, errors.CodeNoTx) {
+		return false, nil // closed meanwhile
+	}
+	if err != nil {
+		return true, err
+	}
+
+	root, err := openContentsRoot(contentsDir)
+	if err != nil {
+		return true, err
+	}
+	defer root.Close()
+
+	for _, relativePath := range relativePaths {
+		name := rootName(relativePath)
+		if name == "." {
+			return true, fmt.Errorf("cannot delete the workspace root")
+		}
+
+		// Check against what the workspace will hold once earlier ops apply
+		switch state := tx.stagedState(name); state {
+		case TxOpDelete:
+			return true, errors.PathNotFound(relativePath)
+		case "":
+			info, err := root.Lstat(name)
+			if err != nil {
+				return true, rootedError(err, relativePath, "stat path")
+			}
+			if info.IsDir() && !recursive {
+				return true, fmt.Errorf("path is a directory, use recursive=true to delete")
+			}
+		}
+
+		tx.Ops = append(tx.Ops, TxOp{Op: TxOpDelete, Path: name, Recursive: recursive})
+	}
+
+	return true, saveTransaction(txDir(contentsDir), tx)
+}
+
+// stagedState reports the last staged op affecting name: TxOpWrite when the
+// file is written, TxOpDelete when it or a parent is deleted, "" when neither.
+func (tx *Transaction) stagedState(name string) string {
+	for _, op := range slices.Backward(tx.Ops) {
+		switch {
+		case op.Path == name:
+			return op.Op
+		case op.Op == TxOpDelete && strings.HasPrefix(name, op.Path+"/"):
+			return TxOpDelete
+		}
+	}
+	return ""
+}
+
+// EnsureNoTx refuses operations that cannot be staged while a transaction is
+// open, since committing would silently overwrite their changes, and changes
+// that must be undone together, which staging would put out of reach.
+func EnsureNoTx(contentsDir string) error {
+	if txExists(contentsDir) {
+		return errors.TxOpen(filepath.Base(filepath.Dir(contentsDir)))
+	}
+	return nil
+}
+
+// txDir returns the transaction directory for a contents directory; like the
+// search index, it lives in the workspace directory next to contents/.
+func txDir(contentsDir string) string {
+	return filepath.Join(filepath.Dir(contentsDir), txDirName)
+}
+
+// txExists reports whether a transaction is open, without taking the lock.
+func txExists(contentsDir string) bool {
+	_, err := os.Stat(filepath.Join(txDir(contentsDir), txManifestFile))
+	return err == nil
+}
+
+// acquireTxLock takes the session lock that Sync also holds, then rolls back
+// any commit that crashed part-way, so its transaction can be committed again
+// or aborted from the state it started in.
+func acquireTxLock(contentsDir string) (*Lock, error) {
+	lockPath, err := LockPath(filepath.Base(filepath.Dir(contentsDir)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock path: %w", err)
+	}
+	lock, err := AcquireExclusive(lockPath, txLockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	if err := RecoverUndoJournals(contentsDir); err != nil {
+		_ = lock.Release()
+		return nil, err
+	}
+	return lock, nil
+}
+
+// loadTransaction reads the manifest of the open transaction.
+func loadTransaction(contentsDir string) (*Transaction, error) {
+	data, err := os.ReadFile(filepath.Join(txDir(contentsDir), txManifestFile))
+	if os.IsNotExist(err) {
+		return nil, errors.NoTx(filepath.Base(filepath.Dir(contentsDir)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction: %w", err)
+	}
+
+	var tx Transaction
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", err)
+	}
+
+	// The manifest is only written by zipfs, but its paths still go to os.Root
+	for _, op := range tx.Ops {
+		if err := security.ValidateRelativePath(op.Path); err != nil {
+			return nil, fmt.Errorf("invalid path in transaction: %w", err)
+		}
+		if op.Op == TxOpWrite && (op.Staged == "" || strings.ContainsAny(op.Staged, `/\.`)) {
+			return nil, fmt.Errorf("invalid staged file %q in transaction", op.Staged)
+		}
+	}
+
+	return &tx, nil
+}
+
+// saveTransaction writes the manifest atomically.
+func saveTransaction(dir string, tx *Transaction) error {
+	data, err := json.MarshalIndent(tx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal transaction: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, txManifestFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write transaction: %w", err)
+	}
+	return nil
+}
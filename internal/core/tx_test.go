@@ -0,0 +1,288 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+)
+
+// newTxTestSession opens a session over a small archive for transaction tests.
+func newTxTestSession(t *testing.T) (*Session, string) {
+	t.Helper()
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"keep.txt":  "untouched",
+		"edit.txt":  "before the transaction",
+		"dir/a.txt": "inside a directory",
+	})
+
+	session, err := CreateSession(zipPath, "tx", DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	return session, contentsDir
+}
+
+func TestTransaction_Commit(t *testing.T) {
+	session, contentsDir := newTxTestSession(t)
+
+	if _, err := BeginTransaction(session); err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	if _, err := BeginTransaction(session); !errors.Is(err, errors.CodeTxOpen) {
+		t.Errorf("second begin: expected TX_OPEN, got %v", err)
+	}
+
+	if err := WriteFile(contentsDir, "edit.txt", []byte("after"), false, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := WriteFile(contentsDir, "new/file.txt", []byte("created"), true, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := DeleteFile(contentsDir, "dir", true); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+
+	// Nothing reaches contents/ before the commit
+	data, err := ReadFile(contentsDir, "edit.txt")
+	if err != nil || string(data) != "before the transaction" {
+		t.Errorf("edit.txt changed before commit: %q, %v", data, err)
+	}
+	status, err := Status(session)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.HasChanges() {
+		t.Errorf("expected no changes before commit, got %+v", status)
+	}
+
+	// Ops that cannot be staged are refused
+	if err := MoveFile(contentsDir, "keep.txt", "moved.txt", false); !errors.Is(err, errors.CodeTxOpen) {
+		t.Errorf("MoveFile: expected TX_OPEN, got %v", err)
+	}
+	if _, err := EditFile(contentsDir, "keep.txt", EditOptions{Blocks: []EditBlock{{Search: "un", Replace: ""}}}, nil); !errors.Is(err, errors.CodeTxOpen) {
+		t.Errorf("EditFile: expected TX_OPEN, got %v", err)
+	}
+
+	tx, err := GetTransaction(session)
+	if err != nil {
+		t.Fatalf("GetTransaction failed: %v", err)
+	}
+	if len(tx.Ops) != 3 {
+		t.Fatalf("expected 3 staged ops, got %+v", tx.Ops)
+	}
+
+	if _, err := CommitTransaction(session); err != nil {
+		t.Fatalf("CommitTransaction failed: %v", err)
+	}
+
+	data, err = ReadFile(contentsDir, "edit.txt")
+	if err != nil || string(data) != "after" {
+		t.Errorf("edit.txt = %q, %v; want %q", data, err, "after")
+	}
+	data, err = ReadFile(contentsDir, "new/file.txt")
+	if err != nil || string(data) != "created" {
+		t.Errorf("new/file.txt = %q, %v; want %q", data, err, "created")
+	}
+	if _, err := os.Lstat(filepath.Join(contentsDir, "dir")); !os.IsNotExist(err) {
+		t.Errorf("expected dir to be deleted, got %v", err)
+	}
+
+	if _, err := GetTransaction(session); !errors.Is(err, errors.CodeNoTx) {
+		t.Errorf("expected NO_TX after commit, got %v", err)
+	}
+	if _, err := os.Stat(txDir(contentsDir)); !os.IsNotExist(err) {
+		t.Errorf("expected transaction directory to be removed, got %v", err)
+	}
+
+	// Writes go straight to contents/ again
+	if err := WriteFile(contentsDir, "keep.txt", []byte("direct"), false, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if data, _ := ReadFile(contentsDir, "keep.txt"); string(data) != "direct" {
+		t.Errorf("keep.txt = %q, want %q", data, "direct")
+	}
+}
+
+func TestTransaction_Abort(t *testing.T) {
+	session, contentsDir := newTxTestSession(t)
+
+	if _, err := AbortTransaction(session); !errors.Is(err, errors.CodeNoTx) {
+		t.Errorf("abort without transaction: expected NO_TX, got %v", err)
+	}
+
+	if _, err := BeginTransaction(session); err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	if err := WriteFile(contentsDir, "edit.txt", []byte("after"), false, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	deleted, err := DeleteGlob(contentsDir, "*.txt", false, false)
+	if err != nil {
+		t.Fatalf("DeleteGlob failed: %v", err)
+	}
+	if len(deleted) != 3 {
+		t.Errorf("expected 3 staged deletes, got %v", deleted)
+	}
+
+	tx, err := AbortTransaction(session)
+	if err != nil {
+		t.Fatalf("AbortTransaction failed: %v", err)
+	}
+	if len(tx.Ops) != 4 {
+		t.Errorf("expected 4 discarded ops, got %+v", tx.Ops)
+	}
+
+	status, err := Status(session)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.HasChanges() {
+		t.Errorf("expected no changes after abort, got %+v", status)
+	}
+}
+
+func TestTransaction_StageDeleteChecks(t *testing.T) {
+	session, contentsDir := newTxTestSession(t)
+
+	if _, err := BeginTransaction(session); err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	defer AbortTransaction(session)
+
+	if err := DeleteFile(contentsDir, "missing.txt", false); !errors.Is(err, errors.CodePathNotFound) {
+		t.Errorf("missing path: expected PATH_NOT_FOUND, got %v", err)
+	}
+	if err := DeleteFile(contentsDir, "dir", false); err == nil {
+		t.Error("expected an error deleting a directory without recursive")
+	}
+
+	// A staged write can be deleted again, a staged delete cannot
+	if err := WriteFile(contentsDir, "staged.txt", []byte("x"), false, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := DeleteFile(contentsDir, "staged.txt", false); err != nil {
+		t.Errorf("deleting a staged write failed: %v", err)
+	}
+	if err := DeleteFile(contentsDir, "dir", true); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if err := DeleteFile(contentsDir, "dir/a.txt", false); !errors.Is(err, errors.CodePathNotFound) {
+		t.Errorf("path under a staged delete: expected PATH_NOT_FOUND, got %v", err)
+	}
+}
+
+func TestTransaction_CommitRollsBack(t *testing.T) {
+	session, contentsDir := newTxTestSession(t)
+
+	if _, err := BeginTransaction(session); err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	if err := WriteFile(contentsDir, "edit.txt", []byte("after"), false, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	// The parent is not created at commit, so this op fails
+	if err := WriteFile(contentsDir, "nowhere/file.txt", []byte("x"), false, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if _, err := CommitTransaction(session); err == nil {
+		t.Fatal("expected commit to fail")
+	}
+
+	data, err := ReadFile(contentsDir, "edit.txt")
+	if err != nil || string(data) != "before the transaction" {
+		t.Errorf("edit.txt = %q, %v; want the original contents", data, err)
+	}
+	if _, err := GetTransaction(session); err != nil {
+		t.Errorf("expected the transaction to stay open, got %v", err)
+	}
+}
+
+func TestTransaction_CrashedCommit(t *testing.T) {
+	session, contentsDir := newTxTestSession(t)
+	cfg := DefaultConfig()
+
+	if _, err := BeginTransaction(session); err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	if err := WriteFile(contentsDir, "edit.txt", []byte("committed"), false, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := WriteFile(contentsDir, "keep.txt", []byte("committed too"), false, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	// Sync refuses while the transaction is open
+	if _, err := Sync(session, false, cfg); !errors.Is(err, errors.CodeTxOpen) {
+		t.Fatalf("Sync: expected TX_OPEN, got %v", err)
+	}
+
+	// A commit that died after its first op: the journal and the write remain
+	journal, err := NewUndoJournal(contentsDir)
+	if err != nil {
+		t.Fatalf("NewUndoJournal failed: %v", err)
+	}
+	if err := journal.Record("edit.txt"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(contentsDir, "edit.txt"), []byte("committed"), 0644); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	_ = journal.lock.Release()
+	journal.lock = nil
+
+	// Aborting first rolls the half-applied commit back
+	if _, err := AbortTransaction(session); err != nil {
+		t.Fatalf("AbortTransaction failed: %v", err)
+	}
+	if data, _ := ReadFile(contentsDir, "edit.txt"); string(data) != "before the transaction" {
+		t.Errorf("edit.txt = %q, want the state before the commit", data)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(contentsDir), undoDirPrefix+"*")); len(leftovers) != 0 {
+		t.Errorf("expected undo directories to be removed, got %v", leftovers)
+	}
+
+	result, err := Sync(session, false, cfg)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.FilesModified != 0 {
+		t.Errorf("expected nothing to sync, got %+v", result)
+	}
+}
+
+func TestRecoverUndoJournals_SkipsLiveJournals(t *testing.T) {
+	_, contentsDir := newTxTestSession(t)
+
+	journal, err := NewUndoJournal(contentsDir)
+	if err != nil {
+		t.Fatalf("NewUndoJournal failed: %v", err)
+	}
+	defer journal.Close()
+	if err := journal.Record("edit.txt"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := WriteFile(contentsDir, "edit.txt", []byte("in progress"), false, nil); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if err := RecoverUndoJournals(contentsDir); err != nil {
+		t.Fatalf("RecoverUndoJournals failed: %v", err)
+	}
+	if data, _ := ReadFile(contentsDir, "edit.txt"); string(data) != "in progress" {
+		t.Errorf("expected a live journal to be left alone, got %q", data)
+	}
+	if _, err := os.Stat(journal.dir); err != nil {
+		t.Errorf("expected the live undo directory to remain: %v", err)
+	}
+}
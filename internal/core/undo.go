@@ -0,0 +1,329 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+)
+
+// Files in an undo directory besides the numbered backups.
+const (
+	undoDirPrefix   = "undo-"
+	undoEntriesFile = "entries.json" // the recorded paths, rewritten by every Record
+	undoLockFile    = "lock"         // held while the journal is live
+)
+
+// UndoJournal records workspace paths before they change so that a group of
+// changes can be rolled back. Each recorded path is copied, with its modes,
+// modification times and symlinks, into a scratch directory next to contents/;
+// restored files therefore compare as unchanged in Status again.
+//
+// The list of recorded paths is kept on disk and the directory is locked while
+// the journal is live, so a journal left behind by a crashed process is rolled
+// back by RecoverUndoJournals.
+type UndoJournal struct {
+	contentsDir string
+	dir         string
+	lock        *Lock
+	entries     []undoEntry
+}
+
+// undoEntry is one recorded path. An empty backup means the path did not exist.
+type undoEntry struct {
+	Name   string `json:"name"`
+	Backup string `json:"backup,omitempty"`
+}
+
+// NewUndoJournal starts a journal for a workspace contents directory. Call
+// Close when done, whether or not the journal was rolled back.
+func NewUndoJournal(contentsDir string) (*UndoJournal, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(contentsDir), undoDirPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create undo directory: %w", err)
+	}
+
+	lock, err := AcquireExclusive(filepath.Join(dir, undoLockFile), 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to lock undo directory: %w", err)
+	}
+
+	return &UndoJournal{contentsDir: contentsDir, dir: dir, lock: lock}, nil
+}
+
+// RecoverUndoJournals rolls back the journals in a workspace whose process
+// died before rolling back or closing them, such as a crash in the middle of a
+// transaction commit or an atomic batch, and removes them. Journals that are
+// still live are left alone. The caller should hold the session lock.
+func RecoverUndoJournals(contentsDir string) error {
+	dirs, err := filepath.Glob(filepath.Join(filepath.Dir(contentsDir), undoDirPrefix+"*"))
+	if err != nil {
+		return fmt.Errorf("failed to list undo directories: %w", err)
+	}
+
+	for _, dir := range dirs {
+		lock, err := AcquireExclusive(filepath.Join(dir, undoLockFile), 0)
+		if err != nil {
+			continue
+		}
+
+		j := &UndoJournal{contentsDir: contentsDir, dir: dir, lock: lock}
+		data, err := os.ReadFile(filepath.Join(dir, undoEntriesFile))
+		if err == nil {
+			err = json.Unmarshal(data, &j.entries)
+		} else if os.IsNotExist(err) {
+			// Nothing was recorded, so nothing changed
+			err = nil
+		}
+		if err == nil {
+			err = j.Rollback()
+		}
+		if err != nil {
+			_ = lock.Release()
+			return fmt.Errorf("failed to recover %s: %w", filepath.Base(dir), err)
+		}
+		if err := j.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addEntry records an entry and persists the list before the path changes.
+func (j *UndoJournal) addEntry(e undoEntry) error {
+	j.entries = append(j.entries, e)
+	data, err := json.Marshal(j.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode undo entries: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(j.dir, undoEntriesFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write undo entries: %w", err)
+	}
+	return nil
+}
+
+// Record saves the current state of a path, which is about to change. A path
+// whose parent directories are missing is recorded from the topmost missing
+// one, so rolling back also removes directories created along the way. Paths
+// already covered by an earlier record are skipped: that record holds the older
+// state. A symlink is recorded together with what it points to, since writes
+// through the workspace root follow links that stay inside it.
+func (j *UndoJournal) Record(relativePath string) error {
+	if err := security.ValidateRelativePath(relativePath); err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	if err := security.ValidatePath(j.contentsDir, relativePath); err != nil {
+		return errors.PathTraversal(relativePath)
+	}
+
+	name := rootName(relativePath)
+	if name == "." {
+		return fmt.Errorf("cannot record the workspace root")
+	}
+	for _, e := range j.entries {
+		if name == e.Name || strings.HasPrefix(name, e.Name+"/") {
+			return nil
+		}
+	}
+
+	root, err := openContentsRoot(j.contentsDir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	// Find what exists now: the path itself or its topmost missing ancestor
+	target := name
+	info, err := root.Lstat(name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return rootedError(err, relativePath, "stat path")
+		}
+		for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+			if _, err := root.Lstat(parent); err == nil {
+				break
+			}
+			target = parent
+		}
+		return j.addEntry(undoEntry{Name: target})
+	}
+
+	backup := strconv.Itoa(len(j.entries))
+	dst, err := os.OpenRoot(j.dir)
+	if err != nil {
+		return fmt.Errorf("failed to open undo directory: %w", err)
+	}
+	defer dst.Close()
+
+	if err := copyTree(root, target, dst, backup); err != nil {
+		return fmt.Errorf("failed to save %s: %w", target, err)
+	}
+	if err := j.addEntry(undoEntry{Name: target, Backup: backup}); err != nil {
+		return err
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		linked, ok, err := resolveLink(root, name)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return j.Record(linked)
+		}
+	}
+	return nil
+}
+
+// resolveLink returns the workspace path a symlink points to. It reports false
+// for targets outside the root, which rooted writes refuse anyway, and fails
+// when the target cannot be resolved lexically because it passes through
+// other links.
+func resolveLink(root *os.Root, name string) (string, bool, error) {
+	target, err := root.Readlink(name)
+	if err != nil {
+		return "", false, rootedError(err, name, "read symlink")
+	}
+	if filepath.IsAbs(target) {
+		return "", false, nil
+	}
+
+	resolved := path.Join(path.Dir(name), filepath.ToSlash(target))
+	if resolved == "." || resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", false, nil
+	}
+
+	// A dangling link is written by creating its target, which is not
+	// there to compare yet
+	linkInfo, err := root.Stat(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return resolved, true, nil
+		}
+		return "", false, nil
+	}
+	resolvedInfo, err := root.Stat(resolved)
+	if err != nil || !os.SameFile(linkInfo, resolvedInfo) {
+		return "", false, fmt.Errorf("cannot record %s: symlink target %q passes through another link", name, target)
+	}
+	return resolved, true, nil
+}
+
+// Rollback restores every recorded path, newest first, and empties the journal.
+// It keeps going past failures and returns the first one.
+func (j *UndoJournal) Rollback() error {
+	root, err := openContentsRoot(j.contentsDir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	src, err := os.OpenRoot(j.dir)
+	if err != nil {
+		return fmt.Errorf("failed to open undo directory: %w", err)
+	}
+	defer src.Close()
+
+	var firstErr error
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		e := j.entries[i]
+		err := root.RemoveAll(e.Name)
+		if err == nil && e.Backup != "" {
+			err = root.MkdirAll(path.Dir(e.Name), 0755)
+			if err == nil {
+				err = copyTree(src, e.Backup, root, e.Name)
+			}
+		}
+		if err == nil {
+			markSearchIndexStale(j.contentsDir, e.Name)
+		} else if firstErr == nil {
+			firstErr = fmt.Errorf("failed to restore %s: %w", e.Name, err)
+		}
+	}
+
+	j.entries = nil
+	return firstErr
+}
+
+// Close discards the saved copies. The lock is released only once they are
+// gone, so recovery never rolls back a journal that was closed on success.
+func (j *UndoJournal) Close() error {
+	err := os.RemoveAll(j.dir)
+	if j.lock != nil {
+		_ = j.lock.Release()
+		j.lock = nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove undo directory: %w", err)
+	}
+	return nil
+}
+
+// copyTree copies a file, symlink or directory tree between roots, keeping
+// permissions and modification times. Other file types are skipped.
+func copyTree(src *os.Root, srcName string, dst *os.Root, dstName string) error {
+	info, err := src.Lstat(srcName)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := src.Readlink(srcName)
+		if err != nil {
+			return err
+		}
+		return dst.Symlink(target, dstName)
+
+	case info.IsDir():
+		if err := dst.Mkdir(dstName, 0700); err != nil {
+			return err
+		}
+		entries, err := fs.ReadDir(src.FS(), srcName)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := copyTree(src, path.Join(srcName, e.Name()), dst, path.Join(dstName, e.Name())); err != nil {
+				return err
+			}
+		}
+
+	case info.Mode().IsRegular():
+		in, err := src.Open(srcName)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := dst.OpenFile(dstName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+
+	default:
+		return nil
+	}
+
+	// Set the mode explicitly, past the umask, and the mtime last
+	if err := dst.Chmod(dstName, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return dst.Chtimes(dstName, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUndoJournal_Rollback(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"keep.txt":      "untouched",
+		"edit.txt":      "before the edit",
+		"dir/a.txt":     "inside a directory",
+		"dir/sub/b.txt": "nested deeper",
+		"moved.txt":     "about to move",
+	})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "undo", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if err := os.Symlink("keep.txt", filepath.Join(contentsDir, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	journal, err := NewUndoJournal(contentsDir)
+	if err != nil {
+		t.Fatalf("NewUndoJournal failed: %v", err)
+	}
+	defer journal.Close()
+
+	change := func(path string, fn func() error) {
+		t.Helper()
+		if err := journal.Record(path); err != nil {
+			t.Fatalf("Record(%q) failed: %v", path, err)
+		}
+		if err := fn(); err != nil {
+			t.Fatalf("change to %q failed: %v", path, err)
+		}
+	}
+
+	change("edit.txt", func() error { return WriteFile(contentsDir, "edit.txt", []byte("after"), false, nil) })
+	change("dir/sub/b.txt", func() error { return WriteFile(contentsDir, "dir/sub/b.txt", []byte("changed"), false, nil) })
+	change("dir", func() error { return DeleteFile(contentsDir, "dir", true) })
+	change("new/deep/file.txt", func() error { return WriteFile(contentsDir, "new/deep/file.txt", []byte("x"), true, nil) })
+	change("link", func() error { return DeleteFile(contentsDir, "link", false) })
+	if err := journal.Record("moved.txt"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	change("elsewhere.txt", func() error { return MoveFile(contentsDir, "moved.txt", "elsewhere.txt", false) })
+
+	status, err := Status(session)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !status.HasChanges() {
+		t.Fatal("expected changes before rollback")
+	}
+
+	if err := journal.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	status, err = Status(session)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	// The symlink was added after extraction, so it is the only change left
+	if status.ChangeCount() != 1 || len(status.Added) != 1 || status.Added[0] != "link" {
+		t.Errorf("expected only the symlink to remain added, got %+v", status)
+	}
+	if target, err := os.Readlink(filepath.Join(contentsDir, "link")); err != nil || target != "keep.txt" {
+		t.Errorf("expected symlink to be restored, got %q, %v", target, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(contentsDir, "dir", "sub", "b.txt")); err != nil || string(data) != "nested deeper" {
+		t.Errorf("expected nested file to be restored, got %q, %v", data, err)
+	}
+
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(contentsDir))
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "undo-") {
+			t.Errorf("expected undo directory to be removed, found %s", e.Name())
+		}
+	}
+
+	if err := journal.Record("../escape"); err == nil {
+		t.Error("expected traversal to fail")
+	}
+}
+
+func TestUndoJournal_WriteThroughSymlink(t *testing.T) {
+	contentsDir := filepath.Join(t.TempDir(), "contents")
+	if err := os.MkdirAll(contentsDir, 0755); err != nil {
+		t.Fatalf("failed to create contents dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(contentsDir, "target.txt"), []byte("original"), 0644); err != nil {
+		t.Fatalf("failed to write target: %v", err)
+	}
+	if err := os.Symlink("target.txt", filepath.Join(contentsDir, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if err := os.Symlink("created.txt", filepath.Join(contentsDir, "dangling")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	journal, err := NewUndoJournal(contentsDir)
+	if err != nil {
+		t.Fatalf("NewUndoJournal failed: %v", err)
+	}
+	defer journal.Close()
+
+	for _, name := range []string{"link", "dangling"} {
+		if err := journal.Record(name); err != nil {
+			t.Fatalf("Record(%q) failed: %v", name, err)
+		}
+		if err := WriteFile(contentsDir, name, []byte("changed"), false, nil); err != nil {
+			t.Fatalf("WriteFile(%q) failed: %v", name, err)
+		}
+	}
+
+	if err := journal.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(contentsDir, "target.txt")); err != nil || string(data) != "original" {
+		t.Errorf("target.txt = %q, %v; want %q", data, err, "original")
+	}
+	if _, err := os.Lstat(filepath.Join(contentsDir, "created.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected created.txt to be removed, got %v", err)
+	}
+	for name, want := range map[string]string{"link": "target.txt", "dangling": "created.txt"} {
+		if target, err := os.Readlink(filepath.Join(contentsDir, name)); err != nil || target != want {
+			t.Errorf("%s -> %q, %v; want %q", name, target, err, want)
+		}
+	}
+}
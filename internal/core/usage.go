@@ -0,0 +1,210 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+)
+
+// DiskUsage summarizes the disk space consumed by session workspaces.
+type DiskUsage struct {
+	Workspaces map[string]uint64 `json:"workspaces"` // keyed by workspace directory name
+	TotalBytes uint64            `json:"total_bytes"`
+	LimitBytes uint64            `json:"limit_bytes"`
+}
+
+// WorkspaceDiskUsage returns the bytes used by one workspace: extracted contents,
+// original.zip and any bookkeeping files. Symlinks are not followed.
+func WorkspaceDiskUsage(dirName string) (uint64, error) {
+	workspaceDir, err := WorkspaceDir(dirName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get workspace directory: %w", err)
+	}
+
+	total, err := dirDiskUsage(workspaceDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure workspace %q: %w", dirName, err)
+	}
+	return total, nil
+}
+
+// dirDiskUsage sums the sizes of the regular files under dir. Symlinks are not
+// followed.
+func dirDiskUsage(dir string) (uint64, error) {
+	var total uint64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files may vanish while another operation runs; count what remains
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		total += uint64(info.Size())
+		return nil
+	})
+	return total, err
+}
+
+// TotalDiskUsage measures every workspace under the workspaces directory.
+func TotalDiskUsage(cfg *Config) (*DiskUsage, error) {
+	usage := &DiskUsage{
+		Workspaces: make(map[string]uint64),
+		LimitBytes: cfg.Security.MaxTotalDiskBytes,
+	}
+
+	workspacesDir, err := WorkspacesDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspaces directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(workspacesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return usage, nil
+		}
+		return nil, fmt.Errorf("failed to read workspaces directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		size, err := WorkspaceDiskUsage(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		usage.Workspaces[entry.Name()] = size
+		usage.TotalBytes += size
+	}
+
+	diskUsage.store(workspacesDir, usage.TotalBytes)
+	return usage, nil
+}
+
+// diskUsageTTL is how long a measured total is trusted before budget checks
+// walk the workspaces again.
+const diskUsageTTL = 30 * time.Second
+
+// diskUsage caches the total measured by TotalDiskUsage so that budget checks,
+// which run on every write, do not walk every file of every workspace. Bytes
+// granted by a check are added to the total. Deletes and changes made outside
+// this process are not tracked: a check that would fail measures again before
+// refusing, and the total is re-measured once it is older than diskUsageTTL.
+var diskUsage usageCache
+
+type usageCache struct {
+	mu         sync.Mutex
+	dir        string // workspaces directory the total belongs to
+	total      uint64
+	measuredAt time.Time
+}
+
+// store records a freshly measured total.
+func (c *usageCache) store(dir string, total uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dir, c.total, c.measuredAt = dir, total, time.Now()
+}
+
+// cached returns the total for dir if it is fresh enough.
+func (c *usageCache) cached(dir string) (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dir != dir || c.measuredAt.IsZero() || time.Since(c.measuredAt) > diskUsageTTL {
+		return 0, false
+	}
+	return c.total, true
+}
+
+// charge adds granted bytes to the total for dir.
+func (c *usageCache) charge(dir string, n uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dir == dir {
+		c.total += n
+	}
+}
+
+// EnsureDiskBudget returns a LIMIT_EXCEEDED error if adding additionalBytes would
+// push the combined size of all workspaces past MaxTotalDiskBytes, and otherwise
+// counts them as used. A limit of zero disables the check.
+func EnsureDiskBudget(cfg *Config, additionalBytes uint64) error {
+	return ensureDiskBudget(cfg, additionalBytes, true)
+}
+
+// ensureDiskBudget checks the budget against the cached total, measuring the
+// workspaces when the cache is stale and again before refusing. With charge the
+// granted bytes are added to the cached total; without it they are only
+// needed for a while, like a sync's temp zip.
+func ensureDiskBudget(cfg *Config, additionalBytes uint64, charge bool) error {
+	limit := cfg.Security.MaxTotalDiskBytes
+	if limit == 0 || additionalBytes == 0 {
+		return nil
+	}
+
+	workspacesDir, err := WorkspacesDir()
+	if err != nil {
+		return fmt.Errorf("failed to get workspaces directory: %w", err)
+	}
+
+	total, fresh := diskUsage.cached(workspacesDir)
+	if !fresh || total+additionalBytes > limit {
+		usage, err := TotalDiskUsage(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to compute disk usage: %w", err)
+		}
+		total = usage.TotalBytes
+	}
+
+	if total+additionalBytes > limit {
+		return errors.LimitExceeded(fmt.Sprintf(
+			"max total disk bytes (%d): %d in use, %d more requested",
+			limit, total, additionalBytes,
+		))
+	}
+
+	if charge {
+		diskUsage.charge(workspacesDir, additionalBytes)
+	}
+	return nil
+}
+
+// ensureWriteBudget checks the budget for replacing the workspace file name with
+// newSize bytes. Only growth counts against the budget. A nil cfg skips the check.
+func ensureWriteBudget(cfg *Config, root *os.Root, name string, newSize int64) error {
+	if cfg == nil {
+		return nil
+	}
+
+	var existing int64
+	if info, err := root.Lstat(name); err == nil && info.Mode().IsRegular() {
+		existing = info.Size()
+	}
+
+	if newSize <= existing {
+		return nil
+	}
+
+	return EnsureDiskBudget(cfg, uint64(newSize-existing))
+}
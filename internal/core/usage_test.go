@@ -0,0 +1,269 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+)
+
+func TestTotalDiskUsage(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "content"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "usage", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	usage, err := TotalDiskUsage(cfg)
+	if err != nil {
+		t.Fatalf("TotalDiskUsage failed: %v", err)
+	}
+
+	workspaceBytes, ok := usage.Workspaces[session.DirName()]
+	if !ok {
+		t.Fatalf("expected usage for workspace %q", session.DirName())
+	}
+
+	// The workspace holds at least original.zip plus the extracted file
+	if workspaceBytes <= uint64(len("content")) {
+		t.Errorf("expected workspace usage above %d bytes, got %d", len("content"), workspaceBytes)
+	}
+
+	if usage.TotalBytes != workspaceBytes {
+		t.Errorf("expected total %d, got %d", workspaceBytes, usage.TotalBytes)
+	}
+
+	if usage.LimitBytes != cfg.Security.MaxTotalDiskBytes {
+		t.Errorf("expected limit %d, got %d", cfg.Security.MaxTotalDiskBytes, usage.LimitBytes)
+	}
+}
+
+func TestCreateSession_DiskBudgetExceeded(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	// Random hex keeps the compression ratio under the zip bomb threshold
+	payload := make([]byte, 2048)
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatalf("failed to generate payload: %v", err)
+	}
+	createTestZip(t, zipPath, map[string]string{"file.txt": hex.EncodeToString(payload)})
+
+	cfg := DefaultConfig()
+	cfg.Security.MaxTotalDiskBytes = 1024
+
+	_, err := CreateSession(zipPath, "too-big", cfg)
+	if err == nil {
+		t.Fatal("expected error when disk budget is exceeded")
+	}
+
+	if !errors.Is(err, errors.CodeLimitExceeded) {
+		t.Errorf("expected LIMIT_EXCEEDED, got %v", err)
+	}
+
+	// Nothing should be left behind
+	sessions, err := ListSessions()
+	if err != nil {
+		t.Fatalf("failed to list sessions: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("expected no sessions, got %d", len(sessions))
+	}
+}
+
+func TestWriteFile_DiskBudgetExceeded(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "content"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "budget", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	usage, err := TotalDiskUsage(cfg)
+	if err != nil {
+		t.Fatalf("TotalDiskUsage failed: %v", err)
+	}
+
+	// Leave room for 100 more bytes
+	cfg.Security.MaxTotalDiskBytes = usage.TotalBytes + 100
+
+	if err := WriteFile(contentsDir, "small.txt", []byte(strings.Repeat("a", 50)), false, cfg); err != nil {
+		t.Fatalf("expected write within budget to succeed: %v", err)
+	}
+
+	err = WriteFile(contentsDir, "large.txt", []byte(strings.Repeat("b", 200)), false, cfg)
+	if err == nil {
+		t.Fatal("expected write beyond budget to fail")
+	}
+	if !errors.Is(err, errors.CodeLimitExceeded) {
+		t.Errorf("expected LIMIT_EXCEEDED, got %v", err)
+	}
+
+	// Shrinking an existing file never counts against the budget
+	if err := WriteFile(contentsDir, "small.txt", []byte("a"), false, cfg); err != nil {
+		t.Errorf("expected shrinking write to succeed: %v", err)
+	}
+
+	// A zero limit disables the check
+	cfg.Security.MaxTotalDiskBytes = 0
+	if err := WriteFile(contentsDir, "large.txt", []byte(strings.Repeat("b", 200)), false, cfg); err != nil {
+		t.Errorf("expected write with limit disabled to succeed: %v", err)
+	}
+}
+
+func TestCopyFiles_DiskBudgetOverwrite(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": strings.Repeat("a", 300)})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "budget-copy", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	usage, err := TotalDiskUsage(cfg)
+	if err != nil {
+		t.Fatalf("TotalDiskUsage failed: %v", err)
+	}
+
+	// Leave room for 100 more bytes
+	cfg.Security.MaxTotalDiskBytes = usage.TotalBytes + 100
+
+	hostFile := filepath.Join(tempDir, "host.txt")
+	os.WriteFile(hostFile, []byte(strings.Repeat("b", 350)), 0644)
+
+	// Overwriting charges only the growth over the replaced file
+	if _, err := CopyFiles(
+		CopyLocation{Path: hostFile},
+		CopyLocation{ContentsDir: contentsDir, Path: "file.txt"},
+		CopyOptions{Overwrite: true}, cfg); err != nil {
+		t.Fatalf("expected overwrite within budget to succeed: %v", err)
+	}
+
+	_, err = CopyFiles(
+		CopyLocation{Path: hostFile},
+		CopyLocation{ContentsDir: contentsDir, Path: "new.txt"},
+		CopyOptions{}, cfg)
+	if !errors.Is(err, errors.CodeLimitExceeded) {
+		t.Errorf("expected LIMIT_EXCEEDED for a new file, got %v", err)
+	}
+}
+
+func TestSync_DiskBudgetExceeded(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "content"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "budget-sync", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if err := WriteFile(contentsDir, "file.txt", []byte("changed"), false, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	usage, err := TotalDiskUsage(cfg)
+	if err != nil {
+		t.Fatalf("TotalDiskUsage failed: %v", err)
+	}
+
+	// No room for the temp zip
+	cfg.Security.MaxTotalDiskBytes = usage.TotalBytes + 1
+
+	_, err = Sync(session, false, cfg)
+	if !errors.Is(err, errors.CodeLimitExceeded) {
+		t.Fatalf("expected LIMIT_EXCEEDED, got %v", err)
+	}
+
+	if got := zipFileContent(t, zipPath, "file.txt"); got != "content" {
+		t.Errorf("expected source to be untouched, got %q", got)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(tempDir, ".test.zip.zipfs-tmp-*")); len(matches) != 0 {
+		t.Errorf("expected temp zip to be removed, got %v", matches)
+	}
+	if session.State != "open" {
+		t.Errorf("expected session to be open, got %q", session.State)
+	}
+
+	cfg.Security.MaxTotalDiskBytes = 0
+	if _, err := Sync(session, false, cfg); err != nil {
+		t.Errorf("expected sync with limit disabled to succeed: %v", err)
+	}
+}
+
+func TestEnsureDiskBudget_CachedTotal(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "content"})
+
+	cfg := DefaultConfig()
+	if _, err := CreateSession(zipPath, "cached", cfg); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	usage, err := TotalDiskUsage(cfg)
+	if err != nil {
+		t.Fatalf("TotalDiskUsage failed: %v", err)
+	}
+	cfg.Security.MaxTotalDiskBytes = usage.TotalBytes + 100
+
+	// Granted bytes are charged to the cached total
+	if err := EnsureDiskBudget(cfg, 60); err != nil {
+		t.Fatalf("expected 60 bytes to fit: %v", err)
+	}
+	workspacesDir, err := WorkspacesDir()
+	if err != nil {
+		t.Fatalf("failed to get workspaces dir: %v", err)
+	}
+	if total, fresh := diskUsage.cached(workspacesDir); !fresh || total != usage.TotalBytes+60 {
+		t.Errorf("cached total = %d (fresh %v), want %d", total, fresh, usage.TotalBytes+60)
+	}
+
+	// The charge was never written, so measuring again before refusing lets
+	// the next request through
+	if err := EnsureDiskBudget(cfg, 60); err != nil {
+		t.Errorf("expected a re-measured total to allow 60 more bytes: %v", err)
+	}
+	if err := EnsureDiskBudget(cfg, 101); !errors.Is(err, errors.CodeLimitExceeded) {
+		t.Errorf("expected LIMIT_EXCEEDED, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long a workspace must stay quiet before a watch syncs it.
+const DefaultWatchDebounce = 2 * time.Second
+
+// watchLockFile keeps a second watcher off the same session.
+const watchLockFile = "watch.lock"
+
+// WatchOptions controls WatchSync.
+type WatchOptions struct {
+	Debounce time.Duration // quiet period before syncing; DefaultWatchDebounce if zero
+}
+
+// WatchEvent reports one sync attempted by WatchSync. Exactly one of Result and
+// Err is set.
+type WatchEvent struct {
+	Time    time.Time
+	Changes int // file system events in the burst that triggered the sync
+	Result  *SyncResult
+	Err     error
+}
+
+// WatchSync watches a session's contents/ and syncs it each time a burst of
+// changes has settled for opts.Debounce. Every sync goes through Sync with its
+// usual conflict check, and its outcome is passed to report; a failed sync is
+// reported and watching continues, so a conflict is retried after the next
+// change. Directories created while watching are watched too.
+//
+// It refuses to start, with LOCKED, while another sync or watch holds the
+// session, and returns nil once ctx is done. A sync in progress is finished
+// first.
+func WatchSync(ctx context.Context, session *Session, cfg *Config, opts WatchOptions, report func(WatchEvent)) error {
+	debounce := opts.Debounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	dirName := session.DirName()
+	workspaceDir, err := WorkspaceDir(dirName)
+	if err != nil {
+		return fmt.Errorf("failed to get workspace directory: %w", err)
+	}
+	contentsDir := filepath.Join(workspaceDir, "contents")
+
+	// One watcher per session
+	watchLock, err := AcquireExclusive(filepath.Join(workspaceDir, watchLockFile), 0)
+	if err != nil {
+		return errors.Locked(dirName)
+	}
+	defer func() { _ = watchLock.Release() }()
+
+	// Refuse to start under a running sync; Sync takes the lock itself later
+	lockPath, err := LockPath(dirName)
+	if err != nil {
+		return fmt.Errorf("failed to get lock path: %w", err)
+	}
+	syncLock, err := AcquireExclusive(lockPath, 0)
+	if err != nil {
+		return errors.Locked(dirName)
+	}
+	_ = syncLock.Release()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watchTree(watcher, contentsDir); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	pending := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Name == contentsDir && event.Has(fsnotify.Remove|fsnotify.Rename) {
+				return fmt.Errorf("workspace contents directory was removed")
+			}
+			// Mode changes alone do not reach the zip
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+					// Files created before the watch was added are caught by the sync anyway
+					_ = watchTree(watcher, event.Name)
+				}
+			}
+			pending++
+			timer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			// An overflow lost events, so treat it as a change
+			if err == fsnotify.ErrEventOverflow {
+				pending++
+				timer.Reset(debounce)
+				continue
+			}
+			return fmt.Errorf("watch failed: %w", err)
+
+		case <-timer.C:
+			report(watchSyncOnce(session, cfg, pending))
+			pending = 0
+		}
+	}
+}
+
+// watchSyncOnce runs one sync on fresh session metadata, since other processes
+// may have synced or touched the session since the watch started.
+func watchSyncOnce(session *Session, cfg *Config, changes int) WatchEvent {
+	event := WatchEvent{Time: time.Now(), Changes: changes}
+
+	current, err := GetSession(session.ID)
+	if err != nil {
+		event.Err = err
+		return event
+	}
+	event.Result, event.Err = Sync(current, false, cfg)
+	return event
+}
+
+// watchTree adds dir and every directory below it to the watcher. Symlinked
+// directories are not followed.
+func watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
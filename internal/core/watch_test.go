@@ -0,0 +1,134 @@
+package core
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/zipfs/internal/errors"
+)
+
+func TestWatchSync_SyncsAfterChanges(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file1.txt": "content1"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "watch", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	contentsDir, err := ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan WatchEvent, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchSync(ctx, session, cfg, WatchOptions{Debounce: 100 * time.Millisecond}, func(e WatchEvent) {
+			select {
+			case events <- e:
+			default:
+			}
+		})
+	}()
+
+	// Keep writing until the watcher, which starts asynchronously, syncs a burst
+	if err := os.MkdirAll(filepath.Join(contentsDir, "sub"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	deadline := time.After(10 * time.Second)
+	var event WatchEvent
+wait:
+	for {
+		for _, name := range []string{"file1.txt", "sub/new.txt"} {
+			if err := os.WriteFile(filepath.Join(contentsDir, name), []byte("changed"), 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", name, err)
+			}
+		}
+		select {
+		case event = <-events:
+			break wait
+		case err := <-done:
+			t.Fatalf("WatchSync stopped early: %v", err)
+		case <-deadline:
+			t.Fatal("timed out waiting for a sync")
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	if event.Err != nil {
+		t.Fatalf("watch sync failed: %v", event.Err)
+	}
+	if event.Result == nil || event.Changes == 0 {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("failed to open synced zip: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	zr.Close()
+	if !names["sub/new.txt"] {
+		t.Errorf("expected sub/new.txt in the synced zip, got %v", names)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("WatchSync returned %v after cancel", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WatchSync did not stop after cancel")
+	}
+}
+
+func TestWatchSync_RefusesWhileLocked(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file1.txt": "content1"})
+
+	cfg := DefaultConfig()
+	session, err := CreateSession(zipPath, "watch-locked", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	lockPath, err := LockPath(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get lock path: %v", err)
+	}
+	workspaceDir, err := WorkspaceDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get workspace dir: %v", err)
+	}
+
+	// Held by a running sync, then by another watcher
+	for _, path := range []string{lockPath, filepath.Join(workspaceDir, watchLockFile)} {
+		lock, err := AcquireExclusive(path, time.Second)
+		if err != nil {
+			t.Fatalf("failed to acquire lock: %v", err)
+		}
+
+		err = WatchSync(context.Background(), session, cfg, WatchOptions{}, func(WatchEvent) {})
+		if !errors.Is(err, errors.CodeLocked) {
+			t.Errorf("%s held: expected LOCKED, got %v", filepath.Base(path), err)
+		}
+		lock.Release()
+	}
+}
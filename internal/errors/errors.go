@@ -17,9 +17,13 @@ const (
 	CodeSyncFailed       = "SYNC_FAILED"
 	CodePathTraversal    = "PATH_TRAVERSAL"
 	CodePathNotFound     = "PATH_NOT_FOUND"
+	CodePathExists       = "PATH_EXISTS"
 	CodeLocked           = "LOCKED"
 	CodeLimitExceeded    = "LIMIT_EXCEEDED"
 	CodeNameCollision    = "NAME_COLLISION"
+	CodeEditMismatch     = "EDIT_MISMATCH"
+	CodeTxOpen           = "TX_OPEN"
+	CodeNoTx             = "NO_TX"
 )
 
 // Error represents a zipfs error with a code and message.
@@ -105,6 +109,12 @@ func ZipInvalid(path string) *Error {
 	return New(CodeZipInvalid, fmt.Sprintf("file %q is not a valid zip archive", path))
 }
 
+// UnsupportedCompression creates a ZIP_INVALID error for archives using compression
+// methods zipfs cannot decompress.
+func UnsupportedCompression(path string, methods []uint16) *Error {
+	return New(CodeZipInvalid, fmt.Sprintf("zip file %q uses unsupported compression method(s) %v", path, methods))
+}
+
 // ZipBombDetected creates a ZIP_BOMB_DETECTED error.
 func ZipBombDetected(reason string) *Error {
 	return New(CodeZipBombDetected, fmt.Sprintf("zip bomb detected: %s", reason))
@@ -130,6 +140,11 @@ func PathNotFound(path string) *Error {
 	return New(CodePathNotFound, fmt.Sprintf("path %q not found in workspace", path))
 }
 
+// PathExists creates a PATH_EXISTS error.
+func PathExists(path string) *Error {
+	return New(CodePathExists, fmt.Sprintf("path %q already exists in workspace", path))
+}
+
 // Locked creates a LOCKED error.
 func Locked(sessionID string) *Error {
 	return New(CodeLocked, fmt.Sprintf("session %q is locked by another operation", sessionID))
@@ -144,3 +159,18 @@ func LimitExceeded(limit string) *Error {
 func NameCollision(name string) *Error {
 	return New(CodeNameCollision, fmt.Sprintf("session name %q is already in use", name))
 }
+
+// EditMismatch creates an EDIT_MISMATCH error.
+func EditMismatch(path, reason string) *Error {
+	return New(CodeEditMismatch, fmt.Sprintf("edit does not apply to %q: %s", path, reason))
+}
+
+// TxOpen creates a TX_OPEN error.
+func TxOpen(sessionID string) *Error {
+	return New(CodeTxOpen, fmt.Sprintf("session %q has an open transaction; commit or abort it first", sessionID))
+}
+
+// NoTx creates a NO_TX error.
+func NoTx(sessionID string) *Error {
+	return New(CodeNoTx, fmt.Sprintf("session %q has no open transaction", sessionID))
+}
@@ -267,6 +267,20 @@ func TestZipInvalid(t *testing.T) {
 	}
 }
 
+func TestUnsupportedCompression(t *testing.T) {
+	err := UnsupportedCompression("/tmp/test.zip", []uint16{98})
+
+	if err.Code != CodeZipInvalid {
+		t.Errorf("Code = %q, want %q", err.Code, CodeZipInvalid)
+	}
+	if !strings.Contains(err.Message, "/tmp/test.zip") {
+		t.Errorf("Message = %q, should contain %q", err.Message, "/tmp/test.zip")
+	}
+	if !strings.Contains(err.Message, "98") {
+		t.Errorf("Message = %q, should contain method %d", err.Message, 98)
+	}
+}
+
 func TestZipBombDetected(t *testing.T) {
 	err := ZipBombDetected("compression ratio exceeds 100:1")
 
@@ -383,6 +397,34 @@ func TestNameCollision(t *testing.T) {
 	}
 }
 
+func TestPathExists(t *testing.T) {
+	err := PathExists("data/file.txt")
+
+	if err.Code != CodePathExists {
+		t.Errorf("Code = %q, want %q", err.Code, CodePathExists)
+	}
+	if !strings.Contains(err.Message, "data/file.txt") {
+		t.Errorf("Message = %q, should contain %q", err.Message, "data/file.txt")
+	}
+	if !strings.Contains(err.Message, "already exists") {
+		t.Errorf("Message = %q, should mention already exists", err.Message)
+	}
+}
+
+func TestEditMismatch(t *testing.T) {
+	err := EditMismatch("xl/sheet1.xml", "search block 1 not found")
+
+	if err.Code != CodeEditMismatch {
+		t.Errorf("Code = %q, want %q", err.Code, CodeEditMismatch)
+	}
+	if !strings.Contains(err.Message, "xl/sheet1.xml") {
+		t.Errorf("Message = %q, should contain %q", err.Message, "xl/sheet1.xml")
+	}
+	if !strings.Contains(err.Message, "search block 1 not found") {
+		t.Errorf("Message = %q, should contain the reason", err.Message)
+	}
+}
+
 // Benchmark tests
 func BenchmarkNew(b *testing.B) {
 	for i := 0; i < b.N; i++ {
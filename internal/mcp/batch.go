@@ -0,0 +1,278 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/Fuabioo/zipfs/internal/errors"
+	"github.com/Fuabioo/zipfs/internal/security"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxBatchOperations bounds the operations accepted by one zipfs_batch call.
+const maxBatchOperations = 100
+
+// Batch operation outcomes.
+const (
+	batchStatusOK      = "ok"
+	batchStatusError   = "error"
+	batchStatusSkipped = "skipped"
+)
+
+// batchOps lists the operations zipfs_batch accepts, in the order they are documented.
+var batchOps = []string{"read", "write", "delete", "move", "edit", "ls", "grep", "status"}
+
+// batchError is the error of a failed operation, as the matching tool reports it.
+type batchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// batchOpResult is the outcome of one operation. Result holds what the matching
+// single-operation tool would have returned.
+type batchOpResult struct {
+	Index  int             `json:"index"`
+	Op     string          `json:"op"`
+	Status string          `json:"status"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *batchError     `json:"error,omitempty"`
+}
+
+// batchRun carries the state of one zipfs_batch call.
+type batchRun struct {
+	atomic   bool
+	journals map[string]*core.UndoJournal // by contents directory
+}
+
+// batchHandlers maps operation names onto the tool handlers that implement them.
+func (s *Server) batchHandlers() map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"read":   s.handleRead,
+		"write":  s.handleWrite,
+		"delete": s.handleDelete,
+		"move":   s.handleMove,
+		"edit":   s.handleEdit,
+		"ls":     s.handleLs,
+		"grep":   s.handleGrep,
+		"status": s.handleStatus,
+	}
+}
+
+// handleBatch implements zipfs_batch: Runs an ordered list of operations in one call.
+func (s *Server) handleBatch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters
+	defaultSession := request.GetString("session", "")
+	atomic := request.GetBool("atomic", false)
+	stopOnError := atomic || request.GetBool("stop_on_error", false)
+
+	rawOps, ok := request.GetArguments()["operations"].([]interface{})
+	if !ok || len(rawOps) == 0 {
+		return errorResult("INVALID_PARAMS", "operations must be a non-empty array"), nil
+	}
+	if len(rawOps) > maxBatchOperations {
+		return errorResult("INVALID_PARAMS", fmt.Sprintf("at most %d operations are allowed, got %d", maxBatchOperations, len(rawOps))), nil
+	}
+
+	// Validate every operation before running any
+	handlers := s.batchHandlers()
+	names := make([]string, len(rawOps))
+	ops := make([]map[string]interface{}, len(rawOps))
+	for i, raw := range rawOps {
+		op, ok := raw.(map[string]interface{})
+		if !ok {
+			return errorResult("INVALID_PARAMS", fmt.Sprintf("operation %d must be an object", i)), nil
+		}
+		name, _ := op["op"].(string)
+		if _, ok := handlers[name]; !ok {
+			return errorResult("INVALID_PARAMS", fmt.Sprintf("operation %d: unknown op %q (expected one of %v)", i, name, batchOps)), nil
+		}
+
+		args := make(map[string]interface{}, len(op))
+		for k, v := range op {
+			if k != "op" {
+				args[k] = v
+			}
+		}
+		if _, ok := args["session"]; !ok && defaultSession != "" {
+			args["session"] = defaultSession
+		}
+		names[i], ops[i] = name, args
+	}
+
+	run := &batchRun{atomic: atomic, journals: make(map[string]*core.UndoJournal)}
+	defer run.close()
+
+	results := make([]batchOpResult, len(ops))
+	var succeeded, failed, skipped int
+	stopped := false
+	for i, args := range ops {
+		name := names[i]
+		results[i] = batchOpResult{Index: i, Op: name}
+
+		if stopped {
+			results[i].Status = batchStatusSkipped
+			skipped++
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			results[i].Status = batchStatusError
+			results[i].Error = &batchError{Code: "CANCELLED", Message: err.Error()}
+		} else if err := run.record(name, args); err != nil {
+			code := errors.Code(err)
+			if code == "" {
+				code = "INTERNAL_ERROR"
+			}
+			results[i].Status = batchStatusError
+			results[i].Error = &batchError{Code: code, Message: err.Error()}
+		} else {
+			result, err := handlers[name](ctx, newBatchRequest(name, args))
+			results[i].Status, results[i].Result, results[i].Error = batchOutcome(result, err)
+		}
+
+		if results[i].Status == batchStatusOK {
+			succeeded++
+			continue
+		}
+		failed++
+		stopped = stopOnError
+	}
+
+	response := map[string]interface{}{
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    failed,
+		"skipped":   skipped,
+	}
+
+	// Undo every mutation if an atomic batch failed
+	if atomic && failed > 0 {
+		response["rolled_back"] = true
+		if err := run.rollback(); err != nil {
+			response["rollback_error"] = err.Error()
+		}
+	}
+
+	return jsonResult(response), nil
+}
+
+// newBatchRequest builds the request the single-operation tool expects.
+func newBatchRequest(name string, args map[string]interface{}) mcp.CallToolRequest {
+	var request mcp.CallToolRequest
+	request.Params.Name = "zipfs_" + name
+	request.Params.Arguments = args
+	return request
+}
+
+// batchOutcome classifies a tool result. Tools report failures as a JSON object
+// with an "error" member.
+func batchOutcome(result *mcp.CallToolResult, err error) (string, json.RawMessage, *batchError) {
+	if err != nil {
+		return batchStatusError, nil, &batchError{Code: "INTERNAL_ERROR", Message: err.Error()}
+	}
+	if result == nil || len(result.Content) == 0 {
+		return batchStatusError, nil, &batchError{Code: "INTERNAL_ERROR", Message: "empty tool result"}
+	}
+
+	text, ok := mcp.AsTextContent(result.Content[0])
+	if !ok {
+		return batchStatusError, nil, &batchError{Code: "INTERNAL_ERROR", Message: "unexpected tool result content"}
+	}
+
+	var failure struct {
+		Error *batchError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(text.Text), &failure); err == nil && failure.Error != nil {
+		return batchStatusError, nil, failure.Error
+	}
+	if !json.Valid([]byte(text.Text)) {
+		return batchStatusError, nil, &batchError{Code: "INTERNAL_ERROR", Message: text.Text}
+	}
+	return batchStatusOK, json.RawMessage(text.Text), nil
+}
+
+// record saves the paths a mutating operation is about to change when the
+// batch is atomic. Arguments the tool would reject are left for it to report.
+func (r *batchRun) record(name string, args map[string]interface{}) error {
+	if !r.atomic {
+		return nil
+	}
+
+	str := func(key string) string {
+		v, _ := args[key].(string)
+		return v
+	}
+
+	var paths []string
+	switch name {
+	case "write", "edit":
+		paths = []string{str("path")}
+	case "move":
+		paths = []string{str("source"), str("destination")}
+	case "delete":
+		paths = []string{str("path")}
+	default:
+		return nil
+	}
+
+	session, err := core.ResolveSession(str("session"))
+	if err != nil {
+		return nil
+	}
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		return err
+	}
+
+	// Staged changes would escape the rollback and land on the next commit
+	if err := core.EnsureNoTx(contentsDir); err != nil {
+		return err
+	}
+
+	// A glob delete changes every matching path
+	if name == "delete" && str("glob") != "" {
+		matches, err := core.DeleteGlob(contentsDir, str("glob"), true, true)
+		if err != nil {
+			return nil
+		}
+		paths = matches
+	}
+
+	journal := r.journals[contentsDir]
+	for _, p := range paths {
+		// Invalid paths fail in the tool itself, before anything changes
+		if p == "" || security.ValidateRelativePath(p) != nil {
+			continue
+		}
+		if journal == nil {
+			if journal, err = core.NewUndoJournal(contentsDir); err != nil {
+				return err
+			}
+			r.journals[contentsDir] = journal
+		}
+		if err := journal.Record(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollback restores every session touched by the batch, returning the first failure.
+func (r *batchRun) rollback() error {
+	var firstErr error
+	for _, journal := range r.journals {
+		if err := journal.Rollback(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// close discards the journals.
+func (r *batchRun) close() {
+	for _, journal := range r.journals {
+		_ = journal.Close()
+	}
+}
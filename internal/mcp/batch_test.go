@@ -0,0 +1,224 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+)
+
+type batchResponse struct {
+	Results []struct {
+		Index  int             `json:"index"`
+		Op     string          `json:"op"`
+		Status string          `json:"status"`
+		Result json.RawMessage `json:"result"`
+		Error  *batchError     `json:"error"`
+	} `json:"results"`
+	Succeeded     int    `json:"succeeded"`
+	Failed        int    `json:"failed"`
+	Skipped       int    `json:"skipped"`
+	RolledBack    bool   `json:"rolled_back"`
+	RollbackError string `json:"rollback_error"`
+}
+
+func runBatch(t *testing.T, srv *Server, args map[string]interface{}) batchResponse {
+	t.Helper()
+
+	result, err := srv.handleBatch(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleBatch failed: %v", err)
+	}
+
+	var response batchResponse
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v (text: %s)", err, getResultText(result))
+	}
+	return response
+}
+
+func TestHandleBatch_Success(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"a.txt": "alpha",
+		"b.txt": "bravo",
+	})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "batch", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	response := runBatch(t, srv, map[string]interface{}{
+		"session": session.ID,
+		"operations": []interface{}{
+			map[string]interface{}{"op": "read", "path": "a.txt"},
+			map[string]interface{}{"op": "write", "path": "c.txt", "content": "charlie"},
+			map[string]interface{}{"op": "read", "path": "missing.txt"},
+			map[string]interface{}{"op": "edit", "path": "b.txt", "edits": []interface{}{
+				map[string]interface{}{"search": "bravo", "replace": "BRAVO"},
+			}},
+			map[string]interface{}{"op": "status"},
+		},
+	})
+
+	if response.Succeeded != 4 || response.Failed != 1 || response.Skipped != 0 || response.RolledBack {
+		t.Fatalf("unexpected counts: %+v", response)
+	}
+
+	var read struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(response.Results[0].Result, &read); err != nil || read.Content != "alpha" {
+		t.Errorf("unexpected read result: %s", response.Results[0].Result)
+	}
+	if r := response.Results[2]; r.Status != batchStatusError || r.Error == nil || r.Error.Code != "PATH_NOT_FOUND" {
+		t.Errorf("expected the missing read to fail with PATH_NOT_FOUND, got %+v", r)
+	}
+
+	var status core.StatusResult
+	if err := json.Unmarshal(response.Results[4].Result, &status); err != nil {
+		t.Fatalf("failed to parse status result: %v", err)
+	}
+	if len(status.Added) != 1 || len(status.Modified) != 1 {
+		t.Errorf("expected status to see the earlier writes, got %+v", status)
+	}
+}
+
+func TestHandleBatch_AtomicRollback(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"a.txt":     "alpha",
+		"dir/b.txt": "bravo",
+	})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "atomic", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	response := runBatch(t, srv, map[string]interface{}{
+		"session": session.ID,
+		"atomic":  true,
+		"operations": []interface{}{
+			map[string]interface{}{"op": "write", "path": "a.txt", "content": "changed"},
+			map[string]interface{}{"op": "write", "path": "new/c.txt", "content": "charlie"},
+			map[string]interface{}{"op": "delete", "path": "dir", "recursive": true},
+			map[string]interface{}{"op": "move", "source": "a.txt", "destination": "moved.txt"},
+			map[string]interface{}{"op": "edit", "path": "moved.txt", "edits": []interface{}{
+				map[string]interface{}{"search": "not there", "replace": "x"},
+			}},
+			map[string]interface{}{"op": "write", "path": "never.txt", "content": "skipped"},
+		},
+	})
+
+	if response.Succeeded != 4 || response.Failed != 1 || response.Skipped != 1 || !response.RolledBack || response.RollbackError != "" {
+		t.Fatalf("unexpected counts: %+v", response)
+	}
+	if response.Results[5].Status != batchStatusSkipped {
+		t.Errorf("expected the last operation to be skipped, got %+v", response.Results[5])
+	}
+
+	status, err := core.Status(session)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.HasChanges() {
+		t.Errorf("expected the rollback to leave no changes, got %+v", status)
+	}
+}
+
+func TestHandleBatch_AtomicRefusedDuringTransaction(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"a.txt": "alpha"})
+
+	session, err := core.CreateSession(zipPath, "atomic-tx", core.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if _, err := core.BeginTransaction(session); err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	response := runBatch(t, srv, map[string]interface{}{
+		"session": session.ID,
+		"atomic":  true,
+		"operations": []interface{}{
+			map[string]interface{}{"op": "read", "path": "a.txt"},
+			map[string]interface{}{"op": "write", "path": "a.txt", "content": "changed"},
+		},
+	})
+
+	if response.Succeeded != 1 || response.Failed != 1 {
+		t.Fatalf("unexpected counts: %+v", response)
+	}
+	if e := response.Results[1].Error; e == nil || e.Code != "TX_OPEN" {
+		t.Errorf("expected TX_OPEN for the write, got %+v", response.Results[1])
+	}
+
+	// Nothing was staged for the next commit
+	tx, err := core.GetTransaction(session)
+	if err != nil {
+		t.Fatalf("GetTransaction failed: %v", err)
+	}
+	if len(tx.Ops) != 0 {
+		t.Errorf("expected no staged ops, got %+v", tx.Ops)
+	}
+}
+
+func TestHandleBatch_InvalidParams(t *testing.T) {
+	setupTestEnvironment(t)
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"operations": []interface{}{}},
+		{"operations": []interface{}{map[string]interface{}{"op": "sync"}}},
+		{"operations": []interface{}{"read"}},
+	} {
+		result, err := srv.handleBatch(context.Background(), newTestRequest(args))
+		if err != nil {
+			t.Fatalf("handleBatch failed: %v", err)
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		if response["error"] == nil {
+			t.Errorf("expected %v to be rejected, got %s", args, getResultText(result))
+		}
+	}
+}
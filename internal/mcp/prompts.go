@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerPrompts registers the guided workflows clients can offer as slash
+// commands. Each expands into messages that walk the agent through the zipfs
+// tools for one job.
+func (s *Server) registerPrompts() {
+	// edit-archive
+	s.mcp.AddPrompt(mcp.NewPrompt("edit-archive",
+		mcp.WithPromptDescription("Open a zip, make changes to files inside it, review them and sync them back"),
+		mcp.WithArgument("path",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Absolute path to the zip file")),
+		mcp.WithArgument("task",
+			mcp.ArgumentDescription("What to change in the archive")),
+		mcp.WithArgument("name",
+			mcp.ArgumentDescription("Session name to open the zip under")),
+	), s.handleEditArchivePrompt)
+
+	// audit-archive
+	s.mcp.AddPrompt(mcp.NewPrompt("audit-archive",
+		mcp.WithPromptDescription("Inspect a zip without changing it and report its layout, sizes, compression and anything suspicious"),
+		mcp.WithArgument("path",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Absolute path to the zip file")),
+		mcp.WithArgument("focus",
+			mcp.ArgumentDescription("What to look for in particular, e.g. secrets, leftover OS metadata, large entries")),
+	), s.handleAuditArchivePrompt)
+
+	// compare-versions
+	s.mcp.AddPrompt(mcp.NewPrompt("compare-versions",
+		mcp.WithPromptDescription("Compare two versions of a zip and explain what changed between them"),
+		mcp.WithArgument("path",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Absolute path to the newer zip file")),
+		mcp.WithArgument("old_path",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Absolute path to the older zip file")),
+	), s.handleCompareVersionsPrompt)
+}
+
+func (s *Server) handleEditArchivePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	path, err := requirePromptArgument(request, "path")
+	if err != nil {
+		return nil, err
+	}
+
+	task := request.Params.Arguments["task"]
+	if task == "" {
+		task = "Ask me what to change before editing anything."
+	}
+
+	open := fmt.Sprintf("zipfs_open(path=%q)", path)
+	session := "the session name returned by zipfs_open"
+	if name := request.Params.Arguments["name"]; name != "" {
+		open = fmt.Sprintf("zipfs_open(path=%q, name=%q)", path, name)
+		session = fmt.Sprintf("session=%q", name)
+	}
+
+	return mcp.NewGetPromptResult(
+		"Edit "+path,
+		[]mcp.PromptMessage{
+			promptMessage(fmt.Sprintf("I want to change files inside the zip archive %s.\n\nTask: %s", path, task)),
+			promptMessage(promptSteps(
+				"Follow these steps with the zipfs tools, passing "+session+" to every call after the first:",
+				open+" to extract the archive into a workspace. If it fails with NAME_COLLISION, the archive may already be open; check zipfs_sessions.",
+				"zipfs_tree to see the layout, then zipfs_grep or zipfs_find to locate what the task refers to, and zipfs_read to read it. Read a file before changing it.",
+				"Make the changes. Prefer zipfs_edit with small search/replace blocks over rewriting whole files with zipfs_write; use zipfs_move and zipfs_delete for renames and removals. If several changes must land together, group them with zipfs_batch (atomic=true) or zipfs_tx.",
+				"zipfs_status to list modified, added and deleted entries, and zipfs_diff to review the content changes. Fix anything that does not match the task.",
+				"zipfs_sync with dry_run=true, then zipfs_sync to write the archive back; a .bak.zip backup is kept. On CONFLICT_DETECTED, stop and ask me before retrying with force=true.",
+				"zipfs_close once the sync succeeded, and summarize what changed.",
+			)),
+		},
+	), nil
+}
+
+func (s *Server) handleAuditArchivePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	path, err := requirePromptArgument(request, "path")
+	if err != nil {
+		return nil, err
+	}
+
+	intro := fmt.Sprintf("Audit the zip archive %s without changing it.", path)
+	if focus := request.Params.Arguments["focus"]; focus != "" {
+		intro += "\n\nFocus on: " + focus
+	}
+
+	return mcp.NewGetPromptResult(
+		"Audit "+path,
+		[]mcp.PromptMessage{
+			promptMessage(intro),
+			promptMessage(promptSteps(
+				"Follow these steps with the zipfs tools. Do not call zipfs_write, zipfs_edit, zipfs_delete, zipfs_move or zipfs_sync:",
+				fmt.Sprintf("zipfs_open(path=%q) and note the file count, extracted size and any skipped symlinks it reports. Pass the returned session name to every later call.", path),
+				"zipfs_tree for the overall layout.",
+				"zipfs_find to list the largest entries (min_size in bytes) and leftover OS or editor files (name "+`"^(__MACOSX|\.DS_Store|Thumbs\.db|\._.*|.*~)$"`+").",
+				"zipfs_grep for credentials and personal data, e.g. pattern "+`"(?i)(password|secret|api[_-]?key|token)"`+"; set documents=true to search inside Office files.",
+				"zipfs_stat on entries worth a closer look, for their compression method, CRC-32 and sizes from the original zip.",
+				"zipfs_status to confirm the workspace is unchanged, then zipfs_close with sync=false.",
+				"Report the findings: layout, sizes, anything suspicious and suggested cleanups.",
+			)),
+		},
+	), nil
+}
+
+func (s *Server) handleCompareVersionsPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	newPath, err := requirePromptArgument(request, "path")
+	if err != nil {
+		return nil, err
+	}
+	oldPath, err := requirePromptArgument(request, "old_path")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Compare %s with %s", newPath, oldPath),
+		[]mcp.PromptMessage{
+			promptMessage(fmt.Sprintf("Explain what changed between the older zip archive %s and the newer %s.", oldPath, newPath)),
+			promptMessage(promptSteps(
+				"Follow these steps with the zipfs tools:",
+				fmt.Sprintf("zipfs_diff_archives(old_path=%q, new_path=%q) for the added, deleted and changed entries. No session is needed.", oldPath, newPath),
+				"If entries changed, call it again with content=true for unified diffs of the changed text entries.",
+				"For binary or Office entries, which the diff only summarizes, open both archives with zipfs_open under distinct names, read the entry from each with zipfs_read (or zipfs_grep with documents=true), and close both with zipfs_close and sync=false.",
+				"Summarize the changes by theme rather than file by file, and call out anything that looks accidental.",
+			)),
+		},
+	), nil
+}
+
+// requirePromptArgument returns a required prompt argument or an error naming it.
+func requirePromptArgument(request mcp.GetPromptRequest, name string) (string, error) {
+	value := strings.TrimSpace(request.Params.Arguments[name])
+	if value == "" {
+		return "", fmt.Errorf("argument %q is required", name)
+	}
+	return value, nil
+}
+
+// promptMessage wraps text in a user message.
+func promptMessage(text string) mcp.PromptMessage {
+	return mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text))
+}
+
+// promptSteps renders a heading followed by numbered steps.
+func promptSteps(heading string, steps ...string) string {
+	var b strings.Builder
+	b.WriteString(heading)
+	for i, step := range steps {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, step)
+	}
+	return b.String()
+}
@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// promptResult is a prompts/get result with text contents decoded.
+type promptResult struct {
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content mcp.TextContent `json:"content"`
+	} `json:"messages"`
+}
+
+// text joins the text of every message.
+func (r promptResult) text() string {
+	var texts []string
+	for _, message := range r.Messages {
+		texts = append(texts, message.Content.Text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+func TestPrompts_List(t *testing.T) {
+	setupTestEnvironment(t)
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	var list mcp.ListPromptsResult
+	call(t, srv, "prompts/list", nil, &list)
+
+	required := make(map[string][]string)
+	for _, prompt := range list.Prompts {
+		for _, arg := range prompt.Arguments {
+			if arg.Required {
+				required[prompt.Name] = append(required[prompt.Name], arg.Name)
+			}
+		}
+	}
+	want := map[string]string{
+		"edit-archive":     "path",
+		"audit-archive":    "path",
+		"compare-versions": "path,old_path",
+	}
+	if len(list.Prompts) != len(want) {
+		t.Errorf("expected %d prompts, got %+v", len(want), list.Prompts)
+	}
+	for name, args := range want {
+		if got := strings.Join(required[name], ","); got != args {
+			t.Errorf("%s: required arguments %q, want %q", name, got, args)
+		}
+	}
+}
+
+func TestPrompts_Get(t *testing.T) {
+	setupTestEnvironment(t)
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	var tools mcp.ListToolsResult
+	call(t, srv, "tools/list", nil, &tools)
+	registered := make(map[string]bool)
+	for _, tool := range tools.Tools {
+		registered[tool.Name] = true
+	}
+	toolRef := regexp.MustCompile(`zipfs_[a-z_]+`)
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want []string // in order
+	}{
+		{
+			"edit-archive",
+			map[string]interface{}{"path": "/data/report.zip", "name": "report", "task": "Fix the title"},
+			[]string{"Fix the title", `zipfs_open(path="/data/report.zip", name="report")`, "zipfs_tree", "zipfs_edit", "zipfs_status", "zipfs_diff", "zipfs_sync", "zipfs_close"},
+		},
+		{
+			"audit-archive",
+			map[string]interface{}{"path": "/data/report.zip", "focus": "secrets"},
+			[]string{"secrets", `zipfs_open(path="/data/report.zip")`, "zipfs_tree", "zipfs_find", "zipfs_grep", "zipfs_stat", "zipfs_status", "zipfs_close"},
+		},
+		{
+			"compare-versions",
+			map[string]interface{}{"path": "/data/v2.zip", "old_path": "/data/v1.zip"},
+			[]string{`zipfs_diff_archives(old_path="/data/v1.zip", new_path="/data/v2.zip")`, "content=true", "zipfs_read", "zipfs_close"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result promptResult
+			call(t, srv, "prompts/get", map[string]interface{}{"name": tt.name, "arguments": tt.args}, &result)
+
+			if len(result.Messages) == 0 {
+				t.Fatal("expected messages")
+			}
+			text := result.text()
+
+			rest := text
+			for _, want := range tt.want {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("expected %q after the earlier steps in:\n%s", want, text)
+				}
+				rest = rest[i+len(want):]
+			}
+
+			// Every tool named must exist
+			for _, tool := range toolRef.FindAllString(text, -1) {
+				if !registered[tool] {
+					t.Errorf("prompt refers to unknown tool %s", tool)
+				}
+			}
+		})
+	}
+}
+
+func TestPrompts_MissingArgument(t *testing.T) {
+	setupTestEnvironment(t)
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	request := mcp.GetPromptRequest{Params: mcp.GetPromptParams{
+		Name:      "compare-versions",
+		Arguments: map[string]string{"path": "/data/v2.zip"},
+	}}
+	if _, err := srv.handleCompareVersionsPrompt(context.Background(), request); err == nil || !strings.Contains(err.Error(), "old_path") {
+		t.Errorf("expected an error naming old_path, got %v", err)
+	}
+
+	request = mcp.GetPromptRequest{Params: mcp.GetPromptParams{Name: "edit-archive"}}
+	if _, err := srv.handleEditArchivePrompt(context.Background(), request); err == nil {
+		t.Error("expected an error without path")
+	}
+}
@@ -0,0 +1,251 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Fuabioo/zipfs/internal/core"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resourceScheme prefixes the URIs of workspace files: zipfs://<session>/<path>.
+const resourceScheme = "zipfs"
+
+// maxResourcesPerSession bounds the files listed per session in resources/list.
+// Files past the limit are still readable through the template.
+const maxResourcesPerSession = 1000
+
+// resourceMIMETypes covers extensions common in archives that the mime package
+// may not know without system tables.
+var resourceMIMETypes = map[string]string{
+	".txt":  "text/plain; charset=utf-8",
+	".md":   "text/markdown; charset=utf-8",
+	".csv":  "text/csv; charset=utf-8",
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".odp":  "application/vnd.oasis.opendocument.presentation",
+	".rels": "application/vnd.openxmlformats-package.relationships+xml",
+}
+
+// resourceMutatingTools are the tools after which the published resources are
+// compared with the workspaces again.
+var resourceMutatingTools = map[string]bool{
+	"zipfs_open":   true,
+	"zipfs_close":  true,
+	"zipfs_prune":  true,
+	"zipfs_write":  true,
+	"zipfs_delete": true,
+	"zipfs_move":   true,
+	"zipfs_copy":   true,
+	"zipfs_edit":   true,
+	"zipfs_batch":  true,
+	"zipfs_tx":     true,
+}
+
+// resourceStamp identifies a version of a published file. Both fields appear
+// in the resource description, so a new stamp is a change to the list.
+type resourceStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// resourceState is the last published snapshot of the workspaces.
+type resourceState struct {
+	mu     sync.Mutex
+	stamps map[string]resourceStamp // by URI
+}
+
+// registerResources publishes the workspace file template and the files of
+// every open session.
+func (s *Server) registerResources() {
+	s.mcp.AddResourceTemplate(mcp.NewResourceTemplate(
+		resourceScheme+"://{session}/{+path}",
+		"Workspace file",
+		mcp.WithTemplateDescription("A file in an open zipfs session, by session name or ID and path within the archive. Text files are returned as text, others base64-encoded; the MIME type follows the file extension"),
+	), s.handleReadResource)
+
+	s.refreshResources()
+}
+
+// resourceMiddleware refreshes the published resources after tools that may
+// have changed workspaces or the set of sessions.
+func (s *Server) resourceMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if resourceMutatingTools[request.Params.Name] {
+			s.refreshResources()
+		}
+		return result, err
+	}
+}
+
+// refreshResources compares the workspaces with the last snapshot. When files
+// or sessions appear or disappear, or a file's size or modification time
+// changes, the resource list is replaced, which sends list_changed; every
+// content change made through a tool therefore notifies clients. The SDK cannot
+// track subscriptions, so resources/updated is not sent.
+//
+// Only tool calls trigger a refresh. Changes made through the CLI or directly
+// under the workspace path (zipfs path) are not noticed until the next
+// mutating tool call.
+func (s *Server) refreshResources() {
+	s.resources.mu.Lock()
+	defer s.resources.mu.Unlock()
+
+	resources, stamps := s.scanResources()
+
+	listChanged := s.resources.stamps == nil || len(stamps) != len(s.resources.stamps)
+	for uri, stamp := range stamps {
+		if old, ok := s.resources.stamps[uri]; !ok || old != stamp {
+			listChanged = true
+			break
+		}
+	}
+
+	if listChanged {
+		s.mcp.SetResources(resources...)
+	}
+	s.resources.stamps = stamps
+}
+
+// scanResources lists the files of every open session. Sessions that cannot be
+// listed are left out.
+func (s *Server) scanResources() ([]server.ServerResource, map[string]resourceStamp) {
+	var resources []server.ServerResource
+	stamps := make(map[string]resourceStamp)
+
+	sessions, err := core.ListSessions()
+	if err != nil {
+		return resources, stamps
+	}
+
+	for _, session := range sessions {
+		contentsDir, err := core.ContentsDir(session.DirName())
+		if err != nil {
+			continue
+		}
+
+		count := 0
+		// Walked directly rather than with core.ListFiles for sub-second
+		// modification times; symlinks are not followed
+		_ = filepath.WalkDir(contentsDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			if count == maxResourcesPerSession {
+				return filepath.SkipAll
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			rel, err := filepath.Rel(contentsDir, p)
+			if err != nil {
+				return nil
+			}
+			name := filepath.ToSlash(rel)
+			count++
+
+			uri := resourceURI(session.DirName(), name)
+			stamp := resourceStamp{size: info.Size(), modTime: info.ModTime().UTC()}
+			stamps[uri] = stamp
+			resources = append(resources, server.ServerResource{
+				Resource: mcp.NewResource(uri, session.DirName()+"/"+name,
+					mcp.WithMIMEType(resourceMIMEType(name)),
+					mcp.WithResourceDescription(fmt.Sprintf("%d bytes, modified %s", stamp.size, stamp.modTime.Format(time.RFC3339Nano)))),
+				Handler: s.handleReadResource,
+			})
+			return nil
+		})
+	}
+
+	return resources, stamps
+}
+
+// handleReadResource implements resources/read for workspace files.
+func (s *Server) handleReadResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return readResource(request.Params.URI)
+}
+
+// readResource reads a workspace file named by a zipfs:// URI.
+func readResource(uri string) ([]mcp.ResourceContents, error) {
+	sessionID, relativePath, err := parseResourceURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	// An explicit session only; never fall back to the single open one
+	session, err := core.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := core.ReadFile(contentsDir, relativePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Touch session (non-fatal)
+	_ = core.TouchSession(session)
+
+	mimeType := resourceMIMEType(relativePath)
+	if utf8.Valid(data) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: string(data)}}, nil
+	}
+	return []mcp.ResourceContents{mcp.BlobResourceContents{URI: uri, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)}}, nil
+}
+
+// resourceURI builds the URI of a workspace file, escaping each path segment.
+// Session names need no escaping.
+func resourceURI(session, name string) string {
+	segments := strings.Split(name, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return resourceScheme + "://" + session + "/" + strings.Join(segments, "/")
+}
+
+// parseResourceURI splits a zipfs:// URI into session and relative path.
+func parseResourceURI(uri string) (session, relativePath string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != resourceScheme || u.Host == "" {
+		return "", "", fmt.Errorf("invalid resource URI %q: expected %s://<session>/<path>", uri, resourceScheme)
+	}
+	relativePath = strings.TrimPrefix(u.Path, "/")
+	if relativePath == "" {
+		return "", "", fmt.Errorf("invalid resource URI %q: no file path", uri)
+	}
+	return u.Host, relativePath, nil
+}
+
+// resourceMIMEType guesses a MIME type from the file extension.
+func resourceMIMEType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if t, ok := resourceMIMETypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,219 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testClientSession records the notifications the server broadcasts.
+type testClientSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (c *testClientSession) Initialize()       {}
+func (c *testClientSession) Initialized() bool { return true }
+func (c *testClientSession) SessionID() string { return "resources-test" }
+func (c *testClientSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return c.notifications
+}
+
+// drain returns the notifications received so far, by method.
+func (c *testClientSession) drain() map[string][]mcp.JSONRPCNotification {
+	got := make(map[string][]mcp.JSONRPCNotification)
+	for {
+		select {
+		case n := <-c.notifications:
+			got[n.Method] = append(got[n.Method], n)
+		case <-time.After(100 * time.Millisecond):
+			return got
+		}
+	}
+}
+
+// call sends a JSON-RPC request through the server and decodes its result.
+func call(t *testing.T, srv *Server, method string, params map[string]interface{}, result interface{}) {
+	t.Helper()
+
+	request, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+
+	response, err := json.Marshal(srv.mcp.HandleMessage(context.Background(), request))
+	if err != nil {
+		t.Fatalf("failed to marshal response: %v", err)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(response, &envelope); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if envelope.Error != nil {
+		t.Fatalf("%s failed: %s", method, envelope.Error.Message)
+	}
+	if result != nil {
+		if err := json.Unmarshal(envelope.Result, result); err != nil {
+			t.Fatalf("failed to parse %s result: %v", method, err)
+		}
+	}
+}
+
+func callTool(t *testing.T, srv *Server, name string, args map[string]interface{}) {
+	t.Helper()
+	call(t, srv, "tools/call", map[string]interface{}{"name": name, "arguments": args}, nil)
+}
+
+func TestResources_ListAndRead(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"file1.txt":         "content1",
+		"dir/data.json":     `{"a":1}`,
+		"dir/with space.md": "# title",
+	})
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	callTool(t, srv, "zipfs_open", map[string]interface{}{"path": zipPath, "name": "res"})
+	callTool(t, srv, "zipfs_write", map[string]interface{}{
+		"session": "res", "path": "bin.dat", "content": "AP8A", "encoding": "base64",
+	})
+
+	var templates mcp.ListResourceTemplatesResult
+	call(t, srv, "resources/templates/list", nil, &templates)
+	if len(templates.ResourceTemplates) != 1 || templates.ResourceTemplates[0].URITemplate.Raw() != "zipfs://{session}/{+path}" {
+		t.Errorf("unexpected templates: %+v", templates.ResourceTemplates)
+	}
+
+	var list mcp.ListResourcesResult
+	call(t, srv, "resources/list", nil, &list)
+	mimeTypes := make(map[string]string)
+	for _, r := range list.Resources {
+		mimeTypes[r.URI] = r.MIMEType
+	}
+	want := map[string]string{
+		"zipfs://res/file1.txt":           "text/plain; charset=utf-8",
+		"zipfs://res/dir/data.json":       "application/json",
+		"zipfs://res/dir/with%20space.md": "text/markdown; charset=utf-8",
+		"zipfs://res/bin.dat":             "application/octet-stream",
+	}
+	for uri, mimeType := range want {
+		if got, ok := mimeTypes[uri]; !ok || got != mimeType {
+			t.Errorf("%s: got MIME type %q (listed %v), want %q", uri, got, ok, mimeType)
+		}
+	}
+
+	// Text through the template, including an escaped path and a nested one
+	for uri, text := range map[string]string{
+		"zipfs://res/dir/data.json":       `{"a":1}`,
+		"zipfs://res/dir/with%20space.md": "# title",
+	} {
+		var read struct {
+			Contents []mcp.TextResourceContents `json:"contents"`
+		}
+		call(t, srv, "resources/read", map[string]interface{}{"uri": uri}, &read)
+		if len(read.Contents) != 1 || read.Contents[0].Text != text {
+			t.Errorf("%s: got %+v, want %q", uri, read.Contents, text)
+		}
+	}
+
+	var blob struct {
+		Contents []mcp.BlobResourceContents `json:"contents"`
+	}
+	call(t, srv, "resources/read", map[string]interface{}{"uri": "zipfs://res/bin.dat"}, &blob)
+	if len(blob.Contents) != 1 || blob.Contents[0].Blob != "AP8A" {
+		t.Errorf("expected base64 blob, got %+v", blob.Contents)
+	}
+}
+
+func TestResources_ReadErrors(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file1.txt": "content1"})
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	callTool(t, srv, "zipfs_open", map[string]interface{}{"path": zipPath, "name": "res"})
+
+	for _, uri := range []string{
+		"zipfs://res/missing.txt",
+		"zipfs://other/file1.txt",
+		"zipfs://res/../outside.txt",
+		"zipfs://res/",
+	} {
+		result, err := readResource(uri)
+		if err == nil {
+			t.Errorf("%s: expected an error, got %+v", uri, result)
+		}
+	}
+}
+
+func TestResources_Notifications(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file1.txt": "content1"})
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	client := &testClientSession{notifications: make(chan mcp.JSONRPCNotification, 64)}
+	if err := srv.mcp.RegisterSession(context.Background(), client); err != nil {
+		t.Fatalf("failed to register client session: %v", err)
+	}
+
+	steps := []struct {
+		name        string
+		tool        string
+		args        map[string]interface{}
+		listChanged bool
+	}{
+		{"open", "zipfs_open", map[string]interface{}{"path": zipPath, "name": "res"}, true},
+		{"resize", "zipfs_write", map[string]interface{}{"session": "res", "path": "file1.txt", "content": "changed"}, true},
+		{"same size", "zipfs_write", map[string]interface{}{"session": "res", "path": "file1.txt", "content": "CHANGED"}, true},
+		{"create", "zipfs_write", map[string]interface{}{"session": "res", "path": "new.txt", "content": "new"}, true},
+		{"read only", "zipfs_read", map[string]interface{}{"session": "res", "path": "new.txt"}, false},
+		{"delete", "zipfs_delete", map[string]interface{}{"session": "res", "path": "new.txt"}, true},
+		{"close", "zipfs_close", map[string]interface{}{"session": "res", "sync": false}, true},
+	}
+
+	client.drain()
+	for _, step := range steps {
+		callTool(t, srv, step.tool, step.args)
+		got := client.drain()
+
+		if listChanged := len(got[mcp.MethodNotificationResourcesListChanged]) > 0; listChanged != step.listChanged {
+			t.Errorf("%s: list_changed sent = %v, want %v", step.name, listChanged, step.listChanged)
+		}
+
+		// Without subscriptions, resources/updated is never sent
+		if n := len(got[mcp.MethodNotificationResourceUpdated]); n != 0 {
+			t.Errorf("%s: sent %d resources/updated notifications", step.name, n)
+		}
+	}
+}
@@ -17,11 +17,13 @@ const (
 
 // Server wraps the MCP server with zipfs-specific state.
 type Server struct {
-	mcp *server.MCPServer
-	cfg *core.Config
+	mcp       *server.MCPServer
+	cfg       *core.Config
+	resources resourceState
 }
 
-// NewServer creates and configures the MCP server with all zipfs tools registered.
+// NewServer creates and configures the MCP server with all zipfs tools,
+// workspace resources and workflow prompts registered.
 func NewServer() (*Server, error) {
 	// Load configuration
 	dataDir, err := core.DataDir()
@@ -39,13 +41,22 @@ func NewServer() (*Server, error) {
 	}
 
 	// Create MCP server
-	s.mcp = server.NewMCPServer(serverName, serverVersion)
+	s.mcp = server.NewMCPServer(serverName, serverVersion,
+		server.WithResourceCapabilities(false, true),
+		server.WithPromptCapabilities(false),
+		server.WithToolHandlerMiddleware(s.resourceMiddleware))
 
 	// Register all tools
 	if err := s.registerTools(); err != nil {
 		return nil, fmt.Errorf("failed to register tools: %w", err)
 	}
 
+	// Publish open sessions as resources
+	s.registerResources()
+
+	// Register workflow prompts
+	s.registerPrompts()
+
 	return s, nil
 }
 
@@ -79,6 +90,8 @@ func (s *Server) registerTools() error {
 			mcp.Description("Relative path within workspace (default: \"/\")")),
 		mcp.WithBoolean("recursive",
 			mcp.Description("Include subdirectories (default: false)")),
+		mcp.WithString("glob",
+			mcp.Description("Only list entries whose workspace-relative path matches; ** spans directories, {a,b} alternates, a leading ! excludes (e.g., \"xl/**/*.xml\")")),
 	), s.handleLs)
 
 	// zipfs_tree
@@ -106,6 +119,12 @@ func (s *Server) registerTools() error {
 			mcp.Description("Byte offset to start reading")),
 		mcp.WithNumber("limit",
 			mcp.Description("Maximum bytes to read")),
+		mcp.WithNumber("start_line",
+			mcp.Description("First line to read (1-based); switches to line mode with numbered lines")),
+		mcp.WithNumber("end_line",
+			mcp.Description("Last line to read, inclusive (default: end of file)")),
+		mcp.WithNumber("max_bytes",
+			mcp.Description("Maximum text returned; in line mode (default: 262144, line terminators not counted) next_line continues from where it stopped, otherwise it caps limit and next_offset continues")),
 	), s.handleRead)
 
 	// zipfs_write
@@ -127,16 +146,78 @@ func (s *Server) registerTools() error {
 
 	// zipfs_delete
 	s.mcp.AddTool(mcp.NewTool("zipfs_delete",
-		mcp.WithDescription("Deletes a file or directory from the workspace"),
+		mcp.WithDescription("Deletes a file or directory from the workspace, or every path matching a glob"),
 		mcp.WithString("session",
 			mcp.Description("Session name or ID")),
 		mcp.WithString("path",
-			mcp.Required(),
-			mcp.Description("Relative path within workspace")),
+			mcp.Description("Relative path within workspace (required unless glob is given)")),
+		mcp.WithString("glob",
+			mcp.Description("Delete every path matching this glob instead of one path; ** spans directories, {a,b} alternates, a leading ! excludes (e.g., \"**/*.tmp\")")),
 		mcp.WithBoolean("recursive",
-			mcp.Description("For directories (default: false)")),
+			mcp.Description("For directories, including directories matched by glob (default: false)")),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("With glob, return the matching paths without deleting them (default: false)")),
 	), s.handleDelete)
 
+	// zipfs_edit
+	s.mcp.AddTool(mcp.NewTool("zipfs_edit",
+		mcp.WithDescription("Edits a file with exact search/replace blocks or a unified diff; returns only the changed hunks. Nothing is written if any edit fails to match"),
+		mcp.WithString("session",
+			mcp.Description("Session name or ID")),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Relative path to file")),
+		mcp.WithArray("edits",
+			mcp.Description("Search/replace blocks applied in order; each search must match exactly once unless replace_all is true"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"search":      map[string]any{"type": "string", "description": "Exact text to find"},
+					"replace":     map[string]any{"type": "string", "description": "Replacement text"},
+					"replace_all": map[string]any{"type": "boolean", "description": "Replace every occurrence (default: false)"},
+				},
+				"required": []string{"search", "replace"},
+			})),
+		mcp.WithString("patch",
+			mcp.Description("Unified diff to apply instead of edits")),
+		mcp.WithNumber("context",
+			mcp.Description("Context lines around returned hunks (default: 3)")),
+	), s.handleEdit)
+
+	// zipfs_move
+	s.mcp.AddTool(mcp.NewTool("zipfs_move",
+		mcp.WithDescription("Moves or renames a file or directory in the workspace"),
+		mcp.WithString("session",
+			mcp.Description("Session name or ID")),
+		mcp.WithString("source",
+			mcp.Required(),
+			mcp.Description("Relative path to move")),
+		mcp.WithString("destination",
+			mcp.Required(),
+			mcp.Description("New relative path; missing parent directories are created")),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace an existing destination (default: false)")),
+	), s.handleMove)
+
+	// zipfs_copy
+	s.mcp.AddTool(mcp.NewTool("zipfs_copy",
+		mcp.WithDescription("Copies files on disk between sessions, or between the host and a session, without passing content through the model"),
+		mcp.WithString("source",
+			mcp.Required(),
+			mcp.Description("Path or glob to copy; relative to source_session, or an absolute host path")),
+		mcp.WithString("source_session",
+			mcp.Description("Session name or ID for the source (omit with an absolute host path)")),
+		mcp.WithString("destination",
+			mcp.Required(),
+			mcp.Description("Destination path; relative to destination_session, or an absolute host path")),
+		mcp.WithString("destination_session",
+			mcp.Description("Session name or ID for the destination (omit with an absolute host path)")),
+		mcp.WithBoolean("recursive",
+			mcp.Description("Copy directories recursively (default: false)")),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace existing destination files (default: false)")),
+	), s.handleCopy)
+
 	// zipfs_grep
 	s.mcp.AddTool(mcp.NewTool("zipfs_grep",
 		mcp.WithDescription("Searches file contents in the workspace"),
@@ -148,13 +229,105 @@ func (s *Server) registerTools() error {
 		mcp.WithString("path",
 			mcp.Description("Root path to search from (default: \"/\")")),
 		mcp.WithString("glob",
-			mcp.Description("File glob filter (e.g., \"*.txt\")")),
+			mcp.Description("Path glob filter; without a slash it matches file names at any depth, ** spans directories, {a,b} alternates, a leading ! excludes (e.g., \"*.txt\", \"xl/worksheets/*.xml\")")),
 		mcp.WithBoolean("ignore_case",
 			mcp.Description("Case-insensitive search (default: false)")),
 		mcp.WithNumber("max_results",
-			mcp.Description("Maximum matches to return (default: 100)")),
+			mcp.Description("Maximum matches to return, or files with files_with_matches/count (default: 100)")),
+		mcp.WithNumber("before_context",
+			mcp.Description("Lines of context before each match")),
+		mcp.WithNumber("after_context",
+			mcp.Description("Lines of context after each match")),
+		mcp.WithNumber("context",
+			mcp.Description("Lines of context before and after each match")),
+		mcp.WithBoolean("files_with_matches",
+			mcp.Description("Only list files that match (default: false)")),
+		mcp.WithBoolean("count",
+			mcp.Description("Count matching lines per file instead of listing matches (default: false)")),
+		mcp.WithBoolean("include_binary",
+			mcp.Description("Search files that look binary; they are skipped by default (default: false)")),
+		mcp.WithBoolean("documents",
+			mcp.Description("Search cells and paragraphs inside xlsx/docx/pptx and ODF files; matches carry a location like \"report.xlsx!Sheet1!B7\" (default: false)")),
 	), s.handleGrep)
 
+	// zipfs_find
+	s.mcp.AddTool(mcp.NewTool("zipfs_find",
+		mcp.WithDescription("Finds files in the workspace by path glob, name, size, modification time, type or change state"),
+		mcp.WithString("session",
+			mcp.Description("Session name or ID")),
+		mcp.WithString("path",
+			mcp.Description("Directory to search from (default: \"/\")")),
+		mcp.WithString("glob",
+			mcp.Description("Glob matched against the workspace-relative path; ** spans directories, {a,b} alternates, a leading ! excludes (e.g., \"xl/**/*.xml\")")),
+		mcp.WithString("name",
+			mcp.Description("Regular expression matched against the base name")),
+		mcp.WithString("type",
+			mcp.Description("Entry type: \"file\", \"dir\" or \"symlink\"")),
+		mcp.WithNumber("min_size",
+			mcp.Description("Minimum size in bytes")),
+		mcp.WithNumber("max_size",
+			mcp.Description("Maximum size in bytes")),
+		mcp.WithString("modified_after",
+			mcp.Description("Only entries modified at or after this RFC 3339 time")),
+		mcp.WithString("modified_before",
+			mcp.Description("Only entries modified before this RFC 3339 time")),
+		mcp.WithArray("status",
+			mcp.Description("Change states to include, compared with the original zip: modified, added, renamed, unchanged"),
+			mcp.WithStringItems()),
+		mcp.WithNumber("max_results",
+			mcp.Description("Maximum entries to return (default: 1000)")),
+	), s.handleFind)
+
+	// zipfs_stat
+	s.mcp.AddTool(mcp.NewTool("zipfs_stat",
+		mcp.WithDescription("Returns details of one entry: type, size, mode, modification time, change state and the original zip header (compression method, CRC-32, compressed size, comment)"),
+		mcp.WithString("session",
+			mcp.Description("Session name or ID")),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Relative path within workspace; deleted entries are reported from the original zip")),
+	), s.handleStat)
+
+	// zipfs_batch
+	s.mcp.AddTool(mcp.NewTool("zipfs_batch",
+		mcp.WithDescription("Runs an ordered list of read, write, delete, move, edit, ls, grep and status operations in one call and returns each result; with atomic, all file changes are rolled back if any operation fails"),
+		mcp.WithString("session",
+			mcp.Description("Default session name or ID for operations that do not name one")),
+		mcp.WithArray("operations",
+			mcp.Required(),
+			mcp.Description("Operations run in order. Each takes the parameters of the matching zipfs_<op> tool plus \"op\""),
+			mcp.MaxItems(maxBatchOperations),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"op": map[string]any{
+						"type": "string",
+						"enum": batchOps,
+					},
+					"session": map[string]any{
+						"type":        "string",
+						"description": "Session name or ID (default: the batch session)",
+					},
+				},
+				"required": []string{"op"},
+			})),
+		mcp.WithBoolean("atomic",
+			mcp.Description("Stop at the first failure and roll back every file change made by the batch (default: false)")),
+		mcp.WithBoolean("stop_on_error",
+			mcp.Description("Skip the remaining operations after a failure (default: false; implied by atomic)")),
+	), s.handleBatch)
+
+	// zipfs_tx
+	s.mcp.AddTool(mcp.NewTool("zipfs_tx",
+		mcp.WithDescription("Begins, commits, aborts or inspects a transaction. While one is open, zipfs_write and zipfs_delete stage their changes instead of applying them, and commit applies all of them or none"),
+		mcp.WithString("action",
+			mcp.Required(),
+			mcp.Description("begin, commit, abort or status"),
+			mcp.Enum(txActions...)),
+		mcp.WithString("session",
+			mcp.Description("Session name or ID")),
+	), s.handleTx)
+
 	// zipfs_path
 	s.mcp.AddTool(mcp.NewTool("zipfs_path",
 		mcp.WithDescription("Returns the filesystem path to the workspace contents directory"),
@@ -180,9 +353,38 @@ func (s *Server) registerTools() error {
 			mcp.Description("Session name or ID")),
 	), s.handleStatus)
 
+	// zipfs_diff
+	s.mcp.AddTool(mcp.NewTool("zipfs_diff",
+		mcp.WithDescription("Shows unified diffs between the original zip entries and the workspace files; binary files are summarized by size and hash"),
+		mcp.WithString("session",
+			mcp.Description("Session name or ID")),
+		mcp.WithArray("paths",
+			mcp.Description("Limit to these files, directories or globs; a leading ! excludes (default: all changes)"),
+			mcp.WithStringItems()),
+		mcp.WithNumber("context",
+			mcp.Description("Context lines around each change (default: 3)")),
+		mcp.WithBoolean("stat",
+			mcp.Description("Return only per-file line counts, without diffs (default: false)")),
+	), s.handleDiff)
+
+	// zipfs_diff_archives
+	s.mcp.AddTool(mcp.NewTool("zipfs_diff_archives",
+		mcp.WithDescription("Compares two zip files without opening sessions: added, deleted and changed entries by CRC-32 and size, optionally with diffs of changed entries"),
+		mcp.WithString("old_path",
+			mcp.Required(),
+			mcp.Description("Absolute path to the older zip file")),
+		mcp.WithString("new_path",
+			mcp.Required(),
+			mcp.Description("Absolute path to the newer zip file")),
+		mcp.WithBoolean("content",
+			mcp.Description("Stream changed entries and include unified diffs (default: false)")),
+		mcp.WithNumber("context",
+			mcp.Description("Context lines around each change (default: 3)")),
+	), s.handleDiffArchives)
+
 	// zipfs_sessions
 	s.mcp.AddTool(mcp.NewTool("zipfs_sessions",
-		mcp.WithDescription("Lists all open sessions"),
+		mcp.WithDescription("Lists all open sessions with their disk usage and the global disk limit"),
 	), s.handleSessions)
 
 	// zipfs_prune
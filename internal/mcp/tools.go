@@ -5,6 +5,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Fuabioo/zipfs/internal/core"
@@ -43,6 +45,9 @@ func (s *Server) handleOpen(ctx context.Context, request mcp.CallToolRequest) (*
 		"file_count":           session.FileCount,
 		"extracted_size_bytes": session.ExtractedSizeBytes,
 	}
+	if len(session.SkippedSymlinks) > 0 {
+		response["skipped_symlinks"] = session.SkippedSymlinks
+	}
 
 	return jsonResult(response), nil
 }
@@ -92,6 +97,7 @@ func (s *Server) handleLs(ctx context.Context, request mcp.CallToolRequest) (*mc
 		path = "."
 	}
 	recursive := request.GetBool("recursive", false)
+	glob := request.GetString("glob", "")
 
 	// Resolve session
 	session, err := core.ResolveSession(sessionID)
@@ -107,7 +113,7 @@ func (s *Server) handleLs(ctx context.Context, request mcp.CallToolRequest) (*mc
 	}
 
 	// List files
-	entries, err := core.ListFiles(contentsDir, path, recursive)
+	entries, err := core.ListFilesGlob(contentsDir, path, recursive, glob)
 	if err != nil {
 		return mcpErrorResult(err), nil
 	}
@@ -115,12 +121,16 @@ func (s *Server) handleLs(ctx context.Context, request mcp.CallToolRequest) (*mc
 	// Convert to response format
 	var responseEntries []map[string]interface{}
 	for _, entry := range entries {
-		responseEntries = append(responseEntries, map[string]interface{}{
+		responseEntry := map[string]interface{}{
 			"name":        entry.Name,
 			"type":        entry.Type,
 			"size_bytes":  entry.SizeBytes,
 			"modified_at": time.Unix(entry.ModifiedAt, 0).Format(time.RFC3339),
-		})
+		}
+		if entry.Type == "symlink" {
+			responseEntry["target"] = entry.Target
+		}
+		responseEntries = append(responseEntries, responseEntry)
 	}
 
 	response := map[string]interface{}{
@@ -186,6 +196,9 @@ func (s *Server) handleRead(ctx context.Context, request mcp.CallToolRequest) (*
 	encoding := request.GetString("encoding", "utf-8")
 	offset := request.GetInt("offset", 0)
 	limit := request.GetInt("limit", 0)
+	startLine := request.GetInt("start_line", 0)
+	endLine := request.GetInt("end_line", 0)
+	maxBytes := request.GetInt("max_bytes", 0)
 
 	// Resolve session
 	session, err := core.ResolveSession(sessionID)
@@ -200,22 +213,27 @@ func (s *Server) handleRead(ctx context.Context, request mcp.CallToolRequest) (*
 		return errorResult("INTERNAL_ERROR", err.Error()), nil
 	}
 
-	// Read file
-	data, err := core.ReadFile(contentsDir, path)
-	if err != nil {
-		return mcpErrorResult(err), nil
+	// Line mode returns numbered lines and a continuation cursor
+	if startLine > 0 || endLine > 0 {
+		if offset > 0 || limit > 0 {
+			return errorResult("INVALID_PARAMS", "use either start_line/end_line or offset/limit"), nil
+		}
+		return s.readLines(session, contentsDir, path, core.ReadLinesOptions{
+			StartLine: startLine,
+			EndLine:   endLine,
+			MaxBytes:  maxBytes,
+		})
 	}
 
-	// Apply offset and limit
-	if offset > 0 {
-		if offset >= len(data) {
-			data = []byte{}
-		} else {
-			data = data[offset:]
-		}
+	// Outside line mode max_bytes caps the byte range
+	if maxBytes > 0 && (limit == 0 || limit > maxBytes) {
+		limit = maxBytes
 	}
-	if limit > 0 && len(data) > limit {
-		data = data[:limit]
+
+	// Read only the requested byte range
+	data, size, err := core.ReadFileRange(contentsDir, path, int64(offset), int64(limit))
+	if err != nil {
+		return mcpErrorResult(err), nil
 	}
 
 	// Encode based on encoding parameter
@@ -231,6 +249,39 @@ func (s *Server) handleRead(ctx context.Context, request mcp.CallToolRequest) (*
 		"size_bytes": len(data),
 		"encoding":   encoding,
 	}
+	if end := int64(offset) + int64(len(data)); maxBytes > 0 && len(data) == maxBytes && end < size {
+		response["truncated"] = true
+		response["next_offset"] = end
+	}
+
+	// Touch session (non-fatal)
+	_ = core.TouchSession(session)
+
+	return jsonResult(response), nil
+}
+
+// readLines serves zipfs_read in line mode. Content is rendered like cat -n.
+func (s *Server) readLines(session *core.Session, contentsDir, path string, opts core.ReadLinesOptions) (*mcp.CallToolResult, error) {
+	result, err := core.ReadLines(contentsDir, path, opts)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	var content strings.Builder
+	for _, line := range result.Lines {
+		fmt.Fprintf(&content, "%6d\t%s\n", line.Number, line.Text)
+	}
+
+	response := map[string]interface{}{
+		"content":     content.String(),
+		"start_line":  result.StartLine,
+		"end_line":    result.EndLine,
+		"total_lines": result.TotalLines,
+		"truncated":   result.Truncated,
+	}
+	if result.NextLine > 0 {
+		response["next_line"] = result.NextLine
+	}
 
 	// Touch session (non-fatal)
 	_ = core.TouchSession(session)
@@ -279,7 +330,7 @@ func (s *Server) handleWrite(ctx context.Context, request mcp.CallToolRequest) (
 	}
 
 	// Write file
-	if err := core.WriteFile(contentsDir, path, data, createDirs); err != nil {
+	if err := core.WriteFile(contentsDir, path, data, createDirs, s.cfg); err != nil {
 		return mcpErrorResult(err), nil
 	}
 
@@ -298,11 +349,19 @@ func (s *Server) handleWrite(ctx context.Context, request mcp.CallToolRequest) (
 func (s *Server) handleDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Extract parameters
 	sessionID := request.GetString("session", "")
-	path, err := request.RequireString("path")
-	if err != nil {
+	path := request.GetString("path", "")
+	glob := request.GetString("glob", "")
+	recursive := request.GetBool("recursive", false)
+	dryRun := request.GetBool("dry_run", false)
+
+	switch {
+	case path != "" && glob != "":
+		return errorResult("INVALID_PARAMS", "path and glob are mutually exclusive"), nil
+	case path == "" && glob == "":
 		return errorResult("INVALID_PARAMS", "path is required"), nil
+	case dryRun && glob == "":
+		return errorResult("INVALID_PARAMS", "dry_run requires glob"), nil
 	}
-	recursive := request.GetBool("recursive", false)
 
 	// Resolve session
 	session, err := core.ResolveSession(sessionID)
@@ -317,6 +376,23 @@ func (s *Server) handleDelete(ctx context.Context, request mcp.CallToolRequest)
 		return errorResult("INTERNAL_ERROR", err.Error()), nil
 	}
 
+	if glob != "" {
+		paths, err := core.DeleteGlob(contentsDir, glob, recursive, dryRun)
+		if err != nil {
+			return mcpErrorResult(err), nil
+		}
+
+		// Touch session (non-fatal)
+		_ = core.TouchSession(session)
+
+		return jsonResult(map[string]interface{}{
+			"deleted": !dryRun && len(paths) > 0,
+			"paths":   paths,
+			"count":   len(paths),
+			"dry_run": dryRun,
+		}), nil
+	}
+
 	// Delete file
 	if err := core.DeleteFile(contentsDir, path, recursive); err != nil {
 		return mcpErrorResult(err), nil
@@ -333,6 +409,190 @@ func (s *Server) handleDelete(ctx context.Context, request mcp.CallToolRequest)
 	return jsonResult(response), nil
 }
 
+// handleMove implements zipfs_move: Moves or renames a file or directory in the workspace.
+func (s *Server) handleMove(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters
+	sessionID := request.GetString("session", "")
+	src, err := request.RequireString("source")
+	if err != nil {
+		return errorResult("INVALID_PARAMS", "source is required"), nil
+	}
+	dst, err := request.RequireString("destination")
+	if err != nil {
+		return errorResult("INVALID_PARAMS", "destination is required"), nil
+	}
+	overwrite := request.GetBool("overwrite", false)
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	// Get contents directory
+	dirName := session.DirName()
+	contentsDir, err := core.ContentsDir(dirName)
+	if err != nil {
+		return errorResult("INTERNAL_ERROR", err.Error()), nil
+	}
+
+	// Move file or directory
+	if err := core.MoveFile(contentsDir, src, dst, overwrite); err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	response := map[string]interface{}{
+		"moved":       true,
+		"source":      src,
+		"destination": dst,
+	}
+
+	// Touch session (non-fatal)
+	_ = core.TouchSession(session)
+
+	return jsonResult(response), nil
+}
+
+// handleEdit implements zipfs_edit: Applies search/replace blocks or a unified diff to a file.
+func (s *Server) handleEdit(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters
+	sessionID := request.GetString("session", "")
+	path, err := request.RequireString("path")
+	if err != nil {
+		return errorResult("INVALID_PARAMS", "path is required"), nil
+	}
+
+	opts := core.EditOptions{
+		Patch:   request.GetString("patch", ""),
+		Context: request.GetInt("context", core.DefaultEditContext),
+	}
+
+	// Edits arrive as an array of {search, replace, replace_all} objects
+	if raw, ok := request.GetArguments()["edits"]; ok && raw != nil {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return errorResult("INVALID_PARAMS", "edits must be an array of objects"), nil
+		}
+		if err := json.Unmarshal(data, &opts.Blocks); err != nil {
+			return errorResult("INVALID_PARAMS", "edits must be an array of {search, replace, replace_all} objects"), nil
+		}
+	}
+
+	if (len(opts.Blocks) == 0) == (opts.Patch == "") {
+		return errorResult("INVALID_PARAMS", "provide either edits or patch"), nil
+	}
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	// Get contents directory
+	dirName := session.DirName()
+	contentsDir, err := core.ContentsDir(dirName)
+	if err != nil {
+		return errorResult("INTERNAL_ERROR", err.Error()), nil
+	}
+
+	// Apply edit
+	result, err := core.EditFile(contentsDir, path, opts, s.cfg)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	response := map[string]interface{}{
+		"path":         result.Path,
+		"replacements": result.Replacements,
+		"bytes_before": result.BytesBefore,
+		"bytes_after":  result.BytesAfter,
+		"diff":         result.Diff(),
+	}
+
+	// Touch session (non-fatal)
+	_ = core.TouchSession(session)
+
+	return jsonResult(response), nil
+}
+
+// handleCopy implements zipfs_copy: Copies files on disk between sessions or between host and session.
+func (s *Server) handleCopy(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters
+	src, err := request.RequireString("source")
+	if err != nil {
+		return errorResult("INVALID_PARAMS", "source is required"), nil
+	}
+	dst, err := request.RequireString("destination")
+	if err != nil {
+		return errorResult("INVALID_PARAMS", "destination is required"), nil
+	}
+	opts := core.CopyOptions{
+		Recursive: request.GetBool("recursive", false),
+		Overwrite: request.GetBool("overwrite", false),
+	}
+
+	// Absolute paths are host paths; relative paths belong to a session
+	srcLoc, srcSession, err := resolveCopyLocation(request.GetString("source_session", ""), src)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+	dstLoc, dstSession, err := resolveCopyLocation(request.GetString("destination_session", ""), dst)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	if srcLoc.IsHost() && dstLoc.IsHost() {
+		return errorResult("INVALID_PARAMS", "at least one of source or destination must be a session path"), nil
+	}
+	// Host paths would let any client read or overwrite files outside the workspaces
+	if (srcLoc.IsHost() || dstLoc.IsHost()) && !s.cfg.Security.AllowMCPHostCopy {
+		return errorResult("INVALID_PARAMS", "host paths are disabled for zipfs_copy; set security.allow_mcp_host_copy in config.json to enable them, or use the zipfs cp command"), nil
+	}
+
+	// Copy on disk
+	result, err := core.CopyFiles(srcLoc, dstLoc, opts, s.cfg)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	response := map[string]interface{}{
+		"copied": len(result.Files),
+		"files":  result.Files,
+		"bytes":  result.Bytes,
+	}
+	if len(result.Skipped) > 0 {
+		response["skipped"] = result.Skipped
+	}
+
+	// Touch sessions (non-fatal)
+	for _, session := range []*core.Session{srcSession, dstSession} {
+		if session != nil {
+			_ = core.TouchSession(session)
+		}
+	}
+
+	return jsonResult(response), nil
+}
+
+// resolveCopyLocation maps a zipfs_copy path to a host path or a session path.
+func resolveCopyLocation(sessionID, path string) (core.CopyLocation, *core.Session, error) {
+	if sessionID == "" && filepath.IsAbs(path) {
+		return core.CopyLocation{Path: path}, nil, nil
+	}
+
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return core.CopyLocation{}, nil, err
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		return core.CopyLocation{}, nil, err
+	}
+
+	return core.CopyLocation{ContentsDir: contentsDir, Path: path}, session, nil
+}
+
 // handleGrep implements zipfs_grep: Searches file contents in the workspace.
 func (s *Server) handleGrep(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Extract parameters
@@ -349,6 +609,13 @@ func (s *Server) handleGrep(ctx context.Context, request mcp.CallToolRequest) (*
 	glob := request.GetString("glob", "")
 	ignoreCase := request.GetBool("ignore_case", false)
 	maxResults := request.GetInt("max_results", 100)
+	contextLines := request.GetInt("context", 0)
+	beforeContext := max(request.GetInt("before_context", 0), contextLines)
+	afterContext := max(request.GetInt("after_context", 0), contextLines)
+	filesWithMatches := request.GetBool("files_with_matches", false)
+	count := request.GetBool("count", false)
+	includeBinary := request.GetBool("include_binary", false)
+	documents := request.GetBool("documents", false)
 
 	// Resolve session
 	session, err := core.ResolveSession(sessionID)
@@ -363,26 +630,193 @@ func (s *Server) handleGrep(ctx context.Context, request mcp.CallToolRequest) (*
 		return errorResult("INTERNAL_ERROR", err.Error()), nil
 	}
 
-	// Search files
-	matches, totalMatches, err := core.GrepFiles(contentsDir, path, pattern, glob, ignoreCase, maxResults)
+	// Search files, stopping early if the request is cancelled
+	opts := s.cfg.GrepOptions()
+	opts.Glob = glob
+	opts.IgnoreCase = ignoreCase
+	opts.MaxResults = maxResults
+	opts.BeforeContext = beforeContext
+	opts.AfterContext = afterContext
+	opts.FilesWithMatches = filesWithMatches
+	opts.Count = count
+	opts.IncludeBinary = includeBinary
+	opts.Documents = documents
+
+	result, err := core.GrepFiles(ctx, contentsDir, path, pattern, opts)
 	if err != nil {
 		return mcpErrorResult(err), nil
 	}
+	matches, totalMatches := result.Matches, result.TotalMatches
+	filesMode := filesWithMatches || count
 
 	// Convert to response format
 	var responseMatches []map[string]interface{}
 	for _, match := range matches {
-		responseMatches = append(responseMatches, map[string]interface{}{
+		entry := map[string]interface{}{
 			"file":         match.File,
 			"line_number":  match.LineNumber,
+			"column":       match.Column,
 			"line_content": match.LineContent,
-		})
+		}
+		if match.LineTruncated {
+			entry["line_truncated"] = true
+		}
+		if match.Location != "" {
+			entry["location"] = match.Location
+		}
+		if len(match.Before) > 0 {
+			entry["before"] = match.Before
+		}
+		if len(match.After) > 0 {
+			entry["after"] = match.After
+		}
+		responseMatches = append(responseMatches, entry)
 	}
 
 	response := map[string]interface{}{
 		"matches":       responseMatches,
 		"total_matches": totalMatches,
-		"truncated":     totalMatches > len(matches),
+		"truncated":     (!filesMode && totalMatches > len(matches)) || result.TruncatedReason != "",
+		"bytes_scanned": result.BytesScanned,
+	}
+	if filesMode {
+		response["files"] = result.Files
+	}
+	if result.SkippedBinary > 0 {
+		response["skipped_binary"] = result.SkippedBinary
+	}
+	if result.IndexSkipped > 0 {
+		response["index_skipped"] = result.IndexSkipped
+	}
+	if result.TruncatedReason != "" {
+		response["truncated_reason"] = result.TruncatedReason
+	}
+
+	// Touch session (non-fatal)
+	_ = core.TouchSession(session)
+
+	return jsonResult(response), nil
+}
+
+// handleFind implements zipfs_find: Finds workspace entries matching filters.
+func (s *Server) handleFind(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters
+	sessionID := request.GetString("session", "")
+	path := request.GetString("path", ".")
+	// Convert "/" to "." for root path
+	if path == "/" || path == "" {
+		path = "."
+	}
+
+	opts := core.FindOptions{
+		Path:       request.GetString("glob", ""),
+		Name:       request.GetString("name", ""),
+		Type:       request.GetString("type", ""),
+		MinSize:    int64(request.GetInt("min_size", 0)),
+		MaxSize:    int64(request.GetInt("max_size", 0)),
+		Status:     request.GetStringSlice("status", nil),
+		MaxResults: request.GetInt("max_results", 1000),
+	}
+
+	for param, dst := range map[string]*time.Time{
+		"modified_after":  &opts.ModifiedAfter,
+		"modified_before": &opts.ModifiedBefore,
+	} {
+		if value := request.GetString(param, ""); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return errorResult("INVALID_PARAMS", fmt.Sprintf("invalid %s: %s", param, err)), nil
+			}
+			*dst = parsed
+		}
+	}
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	result, err := core.FindFiles(session, path, opts)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	// Touch session (non-fatal)
+	_ = core.TouchSession(session)
+
+	return jsonResult(result), nil
+}
+
+// handleStat implements zipfs_stat: Reports on a single entry and its original zip header.
+func (s *Server) handleStat(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters
+	sessionID := request.GetString("session", "")
+	path, err := request.RequireString("path")
+	if err != nil {
+		return errorResult("INVALID_PARAMS", "path is required"), nil
+	}
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	stat, err := core.StatFile(session, path)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	// Touch session (non-fatal)
+	_ = core.TouchSession(session)
+
+	return jsonResult(stat), nil
+}
+
+// txActions lists the actions zipfs_tx accepts.
+var txActions = []string{"begin", "commit", "abort", "status"}
+
+// handleTx implements zipfs_tx: Begins, commits, aborts or inspects a transaction.
+func (s *Server) handleTx(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters
+	sessionID := request.GetString("session", "")
+	action, err := request.RequireString("action")
+	if err != nil {
+		return errorResult("INVALID_PARAMS", "action is required"), nil
+	}
+
+	var fn func(*core.Session) (*core.Transaction, error)
+	switch action {
+	case "begin":
+		fn = core.BeginTransaction
+	case "commit":
+		fn = core.CommitTransaction
+	case "abort":
+		fn = core.AbortTransaction
+	case "status":
+		fn = core.GetTransaction
+	default:
+		return errorResult("INVALID_PARAMS", fmt.Sprintf("unknown action %q (expected one of %v)", action, txActions)), nil
+	}
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	tx, err := fn(session)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	response := map[string]interface{}{
+		"action":     action,
+		"id":         tx.ID,
+		"started_at": tx.StartedAt,
+		"ops":        tx.Ops,
+		"count":      len(tx.Ops),
 	}
 
 	// Touch session (non-fatal)
@@ -445,6 +879,9 @@ func (s *Server) handleSync(ctx context.Context, request mcp.CallToolRequest) (*
 			"files_modified": len(status.Modified),
 			"files_added":    len(status.Added),
 			"files_deleted":  len(status.Deleted),
+			"files_renamed":  len(status.Renamed),
+			"dirs_added":     len(status.AddedDirs),
+			"dirs_deleted":   len(status.DeletedDirs),
 		}
 
 		return jsonResult(response), nil
@@ -462,12 +899,19 @@ func (s *Server) handleSync(ctx context.Context, request mcp.CallToolRequest) (*
 		"files_modified": result.FilesModified,
 		"files_added":    result.FilesAdded,
 		"files_deleted":  result.FilesDeleted,
+		"files_renamed":  result.FilesRenamed,
+		"dirs_added":     result.DirsAdded,
+		"dirs_deleted":   result.DirsDeleted,
 	}
 
 	if result.StatusError != nil {
 		response["status_error"] = result.StatusError.Error()
 	}
 
+	if result.Recovery != nil {
+		response["recovery"] = result.Recovery
+	}
+
 	return jsonResult(response), nil
 }
 
@@ -492,6 +936,9 @@ func (s *Server) handleStatus(ctx context.Context, request mcp.CallToolRequest)
 		"modified":        status.Modified,
 		"added":           status.Added,
 		"deleted":         status.Deleted,
+		"renamed":         status.Renamed,
+		"added_dirs":      status.AddedDirs,
+		"deleted_dirs":    status.DeletedDirs,
 		"unchanged_count": status.UnchangedCount,
 	}
 
@@ -501,6 +948,120 @@ func (s *Server) handleStatus(ctx context.Context, request mcp.CallToolRequest)
 	return jsonResult(response), nil
 }
 
+// handleDiff implements zipfs_diff: Shows content diffs against the original zip.
+func (s *Server) handleDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters
+	sessionID := request.GetString("session", "")
+	paths := request.GetStringSlice("paths", nil)
+	contextLines := request.GetInt("context", core.DefaultEditContext)
+	stat := request.GetBool("stat", false)
+
+	// Resolve session
+	session, err := core.ResolveSession(sessionID)
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	// Compute diffs
+	diffs, err := core.DiffSession(session, core.SessionDiffOptions{
+		Paths:   paths,
+		Context: contextLines,
+	})
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	// Each file carries its summary and, unless stat is set, its unified diff
+	files := make([]map[string]interface{}, 0, len(diffs))
+	for i := range diffs {
+		d := &diffs[i]
+		file := map[string]interface{}{
+			"path":      d.Path,
+			"status":    d.Status,
+			"additions": d.Additions,
+			"deletions": d.Deletions,
+		}
+		if d.OldPath != "" {
+			file["old_path"] = d.OldPath
+		}
+		if d.Binary {
+			file["binary"] = true
+			file["old_size"] = d.OldSize
+			file["new_size"] = d.NewSize
+			file["old_sha256"] = d.OldSHA256
+			file["new_sha256"] = d.NewSHA256
+		}
+		if !stat {
+			file["diff"] = d.Unified()
+		}
+		files = append(files, file)
+	}
+
+	response := map[string]interface{}{
+		"files": files,
+	}
+
+	// Touch session (non-fatal)
+	_ = core.TouchSession(session)
+
+	return jsonResult(response), nil
+}
+
+// handleDiffArchives implements zipfs_diff_archives: Compares two zip files without opening sessions.
+func (s *Server) handleDiffArchives(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters
+	oldPath, err := request.RequireString("old_path")
+	if err != nil {
+		return errorResult("INVALID_PARAMS", "old_path is required"), nil
+	}
+	newPath, err := request.RequireString("new_path")
+	if err != nil {
+		return errorResult("INVALID_PARAMS", "new_path is required"), nil
+	}
+	content := request.GetBool("content", false)
+	contextLines := request.GetInt("context", core.DefaultEditContext)
+
+	// Compare archives under the configured zip bomb limits
+	result, err := core.DiffArchives(oldPath, newPath, core.ArchiveDiffOptions{
+		Content: content,
+		Context: contextLines,
+	}, s.cfg.ToSecurityLimits())
+	if err != nil {
+		return mcpErrorResult(err), nil
+	}
+
+	files := make([]map[string]interface{}, 0, len(result.Files))
+	for i := range result.Files {
+		d := &result.Files[i]
+		file := map[string]interface{}{
+			"path":     d.Path,
+			"status":   d.Status,
+			"old_size": d.OldSize,
+			"new_size": d.NewSize,
+		}
+		if content && d.Status == core.DiffModified {
+			file["additions"] = d.Additions
+			file["deletions"] = d.Deletions
+			if d.Binary {
+				file["binary"] = true
+				file["old_sha256"] = d.OldSHA256
+				file["new_sha256"] = d.NewSHA256
+			}
+			file["diff"] = d.Unified()
+		}
+		files = append(files, file)
+	}
+
+	response := map[string]interface{}{
+		"old_path":        result.OldPath,
+		"new_path":        result.NewPath,
+		"files":           files,
+		"unchanged_count": result.UnchangedCount,
+	}
+
+	return jsonResult(response), nil
+}
+
 // handleSessions implements zipfs_sessions: Lists all open sessions.
 func (s *Server) handleSessions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// List all sessions
@@ -509,6 +1070,12 @@ func (s *Server) handleSessions(ctx context.Context, request mcp.CallToolRequest
 		return errorResult("INTERNAL_ERROR", err.Error()), nil
 	}
 
+	// Measure disk usage against the global budget
+	usage, err := core.TotalDiskUsage(s.cfg)
+	if err != nil {
+		return errorResult("INTERNAL_ERROR", err.Error()), nil
+	}
+
 	// Convert to response format
 	var responseSessions []map[string]interface{}
 	for _, session := range sessions {
@@ -527,11 +1094,14 @@ func (s *Server) handleSessions(ctx context.Context, request mcp.CallToolRequest
 			"last_synced_at":       lastSyncedAt,
 			"file_count":           session.FileCount,
 			"extracted_size_bytes": session.ExtractedSizeBytes,
+			"disk_usage_bytes":     usage.Workspaces[session.DirName()],
 		})
 	}
 
 	response := map[string]interface{}{
-		"sessions": responseSessions,
+		"sessions":             responseSessions,
+		"disk_usage_bytes":     usage.TotalBytes,
+		"max_total_disk_bytes": usage.LimitBytes,
 	}
 
 	return jsonResult(response), nil
@@ -236,6 +236,172 @@ func TestHandleLs_Success(t *testing.T) {
 	}
 }
 
+func TestHandleStat_Success(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"doc.txt": "original text"})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if err := core.WriteFile(contentsDir, "doc.txt", []byte("edited text, longer"), false, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session": session.ID,
+		"path":    "doc.txt",
+	}
+
+	result, err := srv.handleStat(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleStat failed: %v", err)
+	}
+
+	var response core.FileStat
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if !response.Exists || response.State != "modified" || response.SizeBytes != 19 {
+		t.Errorf("unexpected stat: %+v", response)
+	}
+	if response.Original == nil || response.Original.UncompressedSize != 13 || len(response.Original.CRC32) != 8 {
+		t.Errorf("unexpected original entry: %+v", response.Original)
+	}
+
+	args["path"] = "missing.txt"
+	result, err = srv.handleStat(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleStat failed: %v", err)
+	}
+	if !strings.Contains(getResultText(result), "PATH_NOT_FOUND") {
+		t.Errorf("expected PATH_NOT_FOUND, got %s", getResultText(result))
+	}
+}
+
+func TestHandleTx_Commit(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"doc.txt": "original text"})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) string {
+		t.Helper()
+		args["session"] = session.ID
+		result, err := handler(context.Background(), newTestRequest(args))
+		if err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+		return getResultText(result)
+	}
+
+	if text := call(srv.handleTx, map[string]interface{}{"action": "begin"}); strings.Contains(text, "error") {
+		t.Fatalf("begin failed: %s", text)
+	}
+	if text := call(srv.handleWrite, map[string]interface{}{"path": "doc.txt", "content": "staged"}); strings.Contains(text, "error") {
+		t.Fatalf("write failed: %s", text)
+	}
+	if text := call(srv.handleRead, map[string]interface{}{"path": "doc.txt"}); !strings.Contains(text, "original text") {
+		t.Errorf("expected the committed contents before commit, got %s", text)
+	}
+	if text := call(srv.handleTx, map[string]interface{}{"action": "begin"}); !strings.Contains(text, "TX_OPEN") {
+		t.Errorf("expected TX_OPEN for a second begin, got %s", text)
+	}
+
+	var response struct {
+		Count int         `json:"count"`
+		Ops   []core.TxOp `json:"ops"`
+	}
+	text := call(srv.handleTx, map[string]interface{}{"action": "commit"})
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if response.Count != 1 || response.Ops[0].Path != "doc.txt" {
+		t.Errorf("unexpected commit response: %s", text)
+	}
+	if text := call(srv.handleRead, map[string]interface{}{"path": "doc.txt"}); !strings.Contains(text, "staged") {
+		t.Errorf("expected the staged contents after commit, got %s", text)
+	}
+
+	if text := call(srv.handleTx, map[string]interface{}{"action": "abort"}); !strings.Contains(text, "NO_TX") {
+		t.Errorf("expected NO_TX without a transaction, got %s", text)
+	}
+	if text := call(srv.handleTx, map[string]interface{}{"action": "rollback"}); !strings.Contains(text, "INVALID_PARAMS") {
+		t.Errorf("expected INVALID_PARAMS for an unknown action, got %s", text)
+	}
+}
+func TestHandleLs_Glob(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"file1.txt":         "content1",
+		"dir/file2.txt":     "content2",
+		"dir/sub/file3.xml": "content3",
+	})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session":   session.ID,
+		"recursive": true,
+		"glob":      "dir/**/*.{txt,xml}",
+	}
+
+	result, err := srv.handleLs(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleLs failed: %v", err)
+	}
+
+	var response struct {
+		Entries []struct {
+			Name string `json:"name"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(response.Entries) != 2 || response.Entries[0].Name != "dir/file2.txt" || response.Entries[1].Name != "dir/sub/file3.xml" {
+		t.Errorf("unexpected entries: %+v", response.Entries)
+	}
+}
+
 func TestHandleTree_Success(t *testing.T) {
 	setupTestEnvironment(t)
 	tempDir := t.TempDir()
@@ -332,6 +498,126 @@ func TestHandleRead_UTF8(t *testing.T) {
 	}
 }
 
+func TestHandleRead_LineRange(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "a\nb\nc\nd\n"})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session":    session.ID,
+		"path":       "file.txt",
+		"start_line": float64(2),
+		"max_bytes":  float64(2),
+	}
+
+	result, err := srv.handleRead(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleRead failed: %v", err)
+	}
+
+	var response struct {
+		Content    string `json:"content"`
+		StartLine  int    `json:"start_line"`
+		EndLine    int    `json:"end_line"`
+		TotalLines int    `json:"total_lines"`
+		NextLine   int    `json:"next_line"`
+	}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if response.Content != "     2\tb\n     3\tc\n" {
+		t.Errorf("unexpected content %q", response.Content)
+	}
+	if response.StartLine != 2 || response.EndLine != 3 || response.TotalLines != 4 || response.NextLine != 4 {
+		t.Errorf("unexpected range: %+v", response)
+	}
+
+	// Byte ranges still work
+	args = map[string]interface{}{
+		"session": session.ID,
+		"path":    "file.txt",
+		"offset":  float64(2),
+		"limit":   float64(3),
+	}
+
+	result, err = srv.handleRead(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleRead failed: %v", err)
+	}
+	if !strings.Contains(getResultText(result), `"content":"b\nc"`) {
+		t.Errorf("unexpected byte range: %s", getResultText(result))
+	}
+}
+
+func TestHandleRead_MaxBytes(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "0123456789"})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		args       map[string]interface{}
+		content    string
+		nextOffset int
+	}{
+		{"whole file", map[string]interface{}{"max_bytes": float64(4)}, "0123", 4},
+		{"from offset", map[string]interface{}{"offset": float64(4), "max_bytes": float64(4)}, "4567", 8},
+		{"smaller limit", map[string]interface{}{"limit": float64(2), "max_bytes": float64(4)}, "01", 0},
+		{"last chunk", map[string]interface{}{"offset": float64(8), "max_bytes": float64(4)}, "89", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["session"] = session.ID
+			tt.args["path"] = "file.txt"
+
+			result, err := srv.handleRead(context.Background(), newTestRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleRead failed: %v", err)
+			}
+
+			var response struct {
+				Content    string `json:"content"`
+				Truncated  bool   `json:"truncated"`
+				NextOffset int    `json:"next_offset"`
+			}
+			if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if response.Content != tt.content || response.NextOffset != tt.nextOffset || response.Truncated != (tt.nextOffset > 0) {
+				t.Errorf("got %+v, want content %q and next_offset %d", response, tt.content, tt.nextOffset)
+			}
+		})
+	}
+}
+
 func TestHandleRead_Base64(t *testing.T) {
 	setupTestEnvironment(t)
 	tempDir := t.TempDir()
@@ -487,15 +773,14 @@ func TestHandleDelete_Success(t *testing.T) {
 	}
 }
 
-func TestHandleGrep_Success(t *testing.T) {
+func TestHandleEdit_Success(t *testing.T) {
 	setupTestEnvironment(t)
 	tempDir := t.TempDir()
 
 	// Create session
 	zipPath := filepath.Join(tempDir, "test.zip")
 	createTestZip(t, zipPath, map[string]string{
-		"file1.txt": "Hello World\nTest line\n",
-		"file2.txt": "Another file\nWith Hello\n",
+		"config.xml": "<config>\n  <mode>draft</mode>\n  <owner>alice</owner>\n</config>\n",
 	})
 
 	cfg := core.DefaultConfig()
@@ -511,12 +796,15 @@ func TestHandleGrep_Success(t *testing.T) {
 
 	args := map[string]interface{}{
 		"session": session.ID,
-		"pattern": "Hello",
+		"path":    "config.xml",
+		"edits": []interface{}{
+			map[string]interface{}{"search": "<mode>draft</mode>", "replace": "<mode>final</mode>"},
+		},
 	}
 
-	result, err := srv.handleGrep(context.Background(), newTestRequest(args))
+	result, err := srv.handleEdit(context.Background(), newTestRequest(args))
 	if err != nil {
-		t.Fatalf("handleGrep failed: %v", err)
+		t.Fatalf("handleEdit failed: %v", err)
 	}
 
 	var response map[string]interface{}
@@ -524,81 +812,91 @@ func TestHandleGrep_Success(t *testing.T) {
 		t.Fatalf("failed to parse response: %v", err)
 	}
 
-	matches := response["matches"].([]interface{})
-	if len(matches) != 2 {
-		t.Errorf("expected 2 matches, got %d", len(matches))
+	diff, _ := response["diff"].(string)
+	if !strings.Contains(diff, "+  <mode>final</mode>") {
+		t.Errorf("expected changed hunk in diff, got %q", diff)
+	}
+
+	// A search that no longer matches reports EDIT_MISMATCH
+	result, err = srv.handleEdit(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleEdit failed: %v", err)
+	}
+	if !strings.Contains(getResultText(result), "EDIT_MISMATCH") {
+		t.Errorf("expected EDIT_MISMATCH, got %s", getResultText(result))
 	}
 }
 
-func TestHandlePath_Success(t *testing.T) {
+func TestHandleDiff_Success(t *testing.T) {
 	setupTestEnvironment(t)
 	tempDir := t.TempDir()
 
 	// Create session
 	zipPath := filepath.Join(tempDir, "test.zip")
-	createTestZip(t, zipPath, map[string]string{"file.txt": "content"})
+	createTestZip(t, zipPath, map[string]string{
+		"notes.txt": "one\ntwo\nthree\n",
+	})
 
 	cfg := core.DefaultConfig()
-	session, err := core.CreateSession(zipPath, "path-test", cfg)
+	session, err := core.CreateSession(zipPath, "", cfg)
 	if err != nil {
 		t.Fatalf("failed to create session: %v", err)
 	}
 
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if err := core.WriteFile(contentsDir, "notes.txt", []byte("one\n2\nthree\n"), false, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
 	srv, err := NewServer()
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
 
 	args := map[string]interface{}{
-		"session": session.Name,
+		"session": session.ID,
 	}
 
-	result, err := srv.handlePath(context.Background(), newTestRequest(args))
+	result, err := srv.handleDiff(context.Background(), newTestRequest(args))
 	if err != nil {
-		t.Fatalf("handlePath failed: %v", err)
+		t.Fatalf("handleDiff failed: %v", err)
 	}
 
-	var response map[string]interface{}
+	var response struct {
+		Files []struct {
+			Path      string `json:"path"`
+			Status    string `json:"status"`
+			Additions int    `json:"additions"`
+			Diff      string `json:"diff"`
+		} `json:"files"`
+	}
 	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
 		t.Fatalf("failed to parse response: %v", err)
 	}
 
-	path := response["path"].(string)
-	if !strings.Contains(path, "path-test") {
-		t.Errorf("expected path to contain 'path-test', got %q", path)
+	if len(response.Files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(response.Files))
 	}
-
-	// Verify path exists
-	if _, err := os.Stat(path); err != nil {
-		t.Errorf("path does not exist: %v", err)
+	file := response.Files[0]
+	if file.Path != "notes.txt" || file.Status != "modified" || file.Additions != 1 {
+		t.Errorf("unexpected file summary: %+v", file)
+	}
+	if !strings.Contains(file.Diff, "-two\n+2") {
+		t.Errorf("unexpected diff: %q", file.Diff)
 	}
 }
 
-func TestHandleStatus_Success(t *testing.T) {
+func TestHandleDiffArchives_Success(t *testing.T) {
 	setupTestEnvironment(t)
 	tempDir := t.TempDir()
 
-	// Create session
-	zipPath := filepath.Join(tempDir, "test.zip")
-	createTestZip(t, zipPath, map[string]string{"file.txt": "content"})
-
-	cfg := core.DefaultConfig()
-	session, err := core.CreateSession(zipPath, "", cfg)
-	if err != nil {
-		t.Fatalf("failed to create session: %v", err)
-	}
-
-	// Modify workspace
-	dirName := session.DirName()
-	contentsDir, err := core.ContentsDir(dirName)
-	if err != nil {
-		t.Fatalf("failed to get contents dir: %v", err)
-	}
-
-	// Add a file
-	if err := core.WriteFile(contentsDir, "newfile.txt", []byte("new"), true); err != nil {
-		t.Fatalf("failed to write file: %v", err)
-	}
+	oldZip := filepath.Join(tempDir, "v1.zip")
+	createTestZip(t, oldZip, map[string]string{"a.txt": "one\n", "b.txt": "same\n"})
+	newZip := filepath.Join(tempDir, "v2.zip")
+	createTestZip(t, newZip, map[string]string{"a.txt": "two\n", "b.txt": "same\n"})
 
 	srv, err := NewServer()
 	if err != nil {
@@ -606,43 +904,657 @@ func TestHandleStatus_Success(t *testing.T) {
 	}
 
 	args := map[string]interface{}{
-		"session": session.ID,
+		"old_path": oldZip,
+		"new_path": newZip,
+		"content":  true,
 	}
 
-	result, err := srv.handleStatus(context.Background(), newTestRequest(args))
+	result, err := srv.handleDiffArchives(context.Background(), newTestRequest(args))
 	if err != nil {
-		t.Fatalf("handleStatus failed: %v", err)
+		t.Fatalf("handleDiffArchives failed: %v", err)
 	}
 
-	var response map[string]interface{}
+	var response struct {
+		Files []struct {
+			Path   string `json:"path"`
+			Status string `json:"status"`
+			Diff   string `json:"diff"`
+		} `json:"files"`
+		UnchangedCount int `json:"unchanged_count"`
+	}
 	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
 		t.Fatalf("failed to parse response: %v", err)
 	}
 
-	added := response["added"].([]interface{})
-	if len(added) != 1 {
-		t.Errorf("expected 1 added file, got %d", len(added))
+	if len(response.Files) != 1 || response.Files[0].Path != "a.txt" || response.Files[0].Status != "modified" {
+		t.Fatalf("unexpected files: %+v", response.Files)
+	}
+	if !strings.Contains(response.Files[0].Diff, "-one\n+two") {
+		t.Errorf("unexpected diff: %q", response.Files[0].Diff)
+	}
+	if response.UnchangedCount != 1 {
+		t.Errorf("expected 1 unchanged, got %d", response.UnchangedCount)
+	}
+
+	// No sessions are created
+	sessions, err := core.ListSessions()
+	if err != nil {
+		t.Fatalf("failed to list sessions: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("expected no sessions, got %d", len(sessions))
 	}
 }
 
-func TestHandleSessions_Success(t *testing.T) {
+func TestHandleDelete_Glob(t *testing.T) {
 	setupTestEnvironment(t)
 	tempDir := t.TempDir()
 
-	// Create multiple sessions
-	zipPath1 := filepath.Join(tempDir, "test1.zip")
-	createTestZip(t, zipPath1, map[string]string{"file.txt": "content"})
-
-	zipPath2 := filepath.Join(tempDir, "test2.zip")
-	createTestZip(t, zipPath2, map[string]string{"file.txt": "content"})
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"keep.txt":   "content",
+		"a.tmp":      "scratch one",
+		"dir/b.tmp":  "scratch two",
+		"dir/c.xml":  "<c/>",
+		"other.tmpl": "template",
+	})
 
 	cfg := core.DefaultConfig()
-	_, err := core.CreateSession(zipPath1, "session1", cfg)
+	session, err := core.CreateSession(zipPath, "", cfg)
 	if err != nil {
-		t.Fatalf("failed to create session1: %v", err)
+		t.Fatalf("failed to create session: %v", err)
 	}
 
-	_, err = core.CreateSession(zipPath2, "session2", cfg)
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session": session.ID,
+		"glob":    "*.tmp",
+		"dry_run": true,
+	}
+
+	var response struct {
+		Deleted bool     `json:"deleted"`
+		Paths   []string `json:"paths"`
+		Count   int      `json:"count"`
+		DryRun  bool     `json:"dry_run"`
+	}
+
+	result, err := srv.handleDelete(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleDelete failed: %v", err)
+	}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if response.Deleted || !response.DryRun || response.Count != 2 {
+		t.Errorf("unexpected dry run response: %+v", response)
+	}
+
+	args["dry_run"] = false
+	result, err = srv.handleDelete(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleDelete failed: %v", err)
+	}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if !response.Deleted || strings.Join(response.Paths, ",") != "a.tmp,dir/b.tmp" {
+		t.Errorf("unexpected response: %+v", response)
+	}
+
+	contentsDir, err := core.ContentsDir(session.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	if _, err := core.ReadFile(contentsDir, "dir/b.tmp"); !errors.Is(err, errors.CodePathNotFound) {
+		t.Error("expected dir/b.tmp to be deleted")
+	}
+	if _, err := core.ReadFile(contentsDir, "other.tmpl"); err != nil {
+		t.Errorf("expected other.tmpl to remain: %v", err)
+	}
+
+	// The workspace root cannot be matched, and path and glob exclude each other
+	for _, bad := range []map[string]interface{}{
+		{"session": session.ID, "glob": "**", "recursive": true},
+		{"session": session.ID, "glob": "*.xml", "path": "dir/c.xml"},
+	} {
+		result, err := srv.handleDelete(context.Background(), newTestRequest(bad))
+		if err != nil {
+			t.Fatalf("handleDelete failed: %v", err)
+		}
+		if !strings.Contains(getResultText(result), `"error"`) {
+			t.Errorf("expected %v to fail, got %s", bad, getResultText(result))
+		}
+	}
+}
+
+func TestHandleMove_Success(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// Create session
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"old.txt":  "content",
+		"keep.txt": "keep",
+	})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session":     session.ID,
+		"source":      "old.txt",
+		"destination": "new/name.txt",
+	}
+
+	result, err := srv.handleMove(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleMove failed: %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if response["moved"] != true {
+		t.Errorf("expected moved to be true, got %v", response)
+	}
+
+	// Moving onto an existing file without overwrite is refused
+	args = map[string]interface{}{
+		"session":     session.ID,
+		"source":      "new/name.txt",
+		"destination": "keep.txt",
+	}
+
+	result, err = srv.handleMove(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleMove failed: %v", err)
+	}
+
+	if !strings.Contains(getResultText(result), "PATH_EXISTS") {
+		t.Errorf("expected PATH_EXISTS error, got %s", getResultText(result))
+	}
+}
+
+func TestHandleCopy_BetweenSessions(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// Create two sessions
+	zipA := filepath.Join(tempDir, "a.zip")
+	createTestZip(t, zipA, map[string]string{"xl/sheet1.xml": "<sheet/>"})
+	zipB := filepath.Join(tempDir, "b.zip")
+	createTestZip(t, zipB, map[string]string{"other.txt": "other"})
+
+	cfg := core.DefaultConfig()
+	sessionA, err := core.CreateSession(zipA, "copy-a", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	sessionB, err := core.CreateSession(zipB, "copy-b", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"source_session":      sessionA.Name,
+		"source":              "xl/sheet1.xml",
+		"destination_session": sessionB.Name,
+		"destination":         "xl/sheet2.xml",
+	}
+
+	result, err := srv.handleCopy(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleCopy failed: %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if response["copied"] != float64(1) {
+		t.Errorf("expected 1 file copied, got %v", response)
+	}
+
+	contentsDir, err := core.ContentsDir(sessionB.DirName())
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+	data, err := core.ReadFile(contentsDir, "xl/sheet2.xml")
+	if err != nil || string(data) != "<sheet/>" {
+		t.Errorf("expected copied content, got %q (%v)", data, err)
+	}
+
+	// Copying to the host requires an absolute path, and is off unless configured
+	hostPath := filepath.Join(tempDir, "sheet.xml")
+	args = map[string]interface{}{
+		"source_session": sessionA.Name,
+		"source":         "xl/sheet1.xml",
+		"destination":    hostPath,
+	}
+
+	result, err = srv.handleCopy(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleCopy failed: %v", err)
+	}
+	if !strings.Contains(getResultText(result), "allow_mcp_host_copy") {
+		t.Errorf("expected host copy to be refused, got %s", getResultText(result))
+	}
+	if _, err := os.Stat(hostPath); !os.IsNotExist(err) {
+		t.Errorf("expected no host file, got %v", err)
+	}
+
+	srv.cfg.Security.AllowMCPHostCopy = true
+	result, err = srv.handleCopy(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleCopy failed: %v", err)
+	}
+	if strings.Contains(getResultText(result), `"error"`) {
+		t.Fatalf("expected success, got %s", getResultText(result))
+	}
+	if _, err := os.Stat(hostPath); err != nil {
+		t.Errorf("expected host file: %v", err)
+	}
+}
+
+func TestHandleGrep_Success(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// Create session
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"file1.txt": "Hello World\nTest line\n",
+		"file2.txt": "Another file\nWith Hello\n",
+	})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session": session.ID,
+		"pattern": "Hello",
+	}
+
+	result, err := srv.handleGrep(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleGrep failed: %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	matches := response["matches"].([]interface{})
+	if len(matches) != 2 {
+		t.Errorf("expected 2 matches, got %d", len(matches))
+	}
+}
+
+func TestHandleGrep_ContextAndCount(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"file1.txt": "before\nHello World\nafter\n",
+		"file2.txt": "Hello\nHello again\n",
+	})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session": session.ID,
+		"pattern": "World",
+		"context": float64(1),
+	}
+
+	result, err := srv.handleGrep(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleGrep failed: %v", err)
+	}
+
+	var response struct {
+		Matches []core.GrepMatch `json:"matches"`
+	}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if len(response.Matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(response.Matches))
+	}
+	match := response.Matches[0]
+	if match.Column != 7 || len(match.Before) != 1 || match.Before[0].LineContent != "before" ||
+		len(match.After) != 1 || match.After[0].LineContent != "after" {
+		t.Errorf("unexpected match: %+v", match)
+	}
+
+	args = map[string]interface{}{
+		"session": session.ID,
+		"pattern": "Hello",
+		"count":   true,
+	}
+
+	result, err = srv.handleGrep(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleGrep failed: %v", err)
+	}
+
+	var countResponse struct {
+		Files        []core.GrepFileCount `json:"files"`
+		TotalMatches int                  `json:"total_matches"`
+	}
+	if err := json.Unmarshal([]byte(getResultText(result)), &countResponse); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if len(countResponse.Files) != 2 || countResponse.Files[1].Count != 2 || countResponse.TotalMatches != 3 {
+		t.Errorf("unexpected counts: %+v", countResponse)
+	}
+}
+
+func TestHandleGrep_Documents(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// A docx nested in the archive
+	docxPath := filepath.Join(tempDir, "notes.docx")
+	createTestZip(t, docxPath, map[string]string{
+		"word/document.xml": `<w:document xmlns:w="urn:w"><w:body><w:p><w:r><w:t>Quarterly EBITDA</w:t></w:r></w:p></w:body></w:document>`,
+	})
+	docx, err := os.ReadFile(docxPath)
+	if err != nil {
+		t.Fatalf("failed to read docx: %v", err)
+	}
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"docs/notes.docx": string(docx)})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session":   session.ID,
+		"pattern":   "EBITDA",
+		"documents": true,
+	}
+
+	result, err := srv.handleGrep(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleGrep failed: %v", err)
+	}
+
+	var response struct {
+		Matches []core.GrepMatch `json:"matches"`
+	}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if len(response.Matches) != 1 || response.Matches[0].Location != "docs/notes.docx!paragraph 1" {
+		t.Errorf("unexpected matches: %+v", response.Matches)
+	}
+}
+
+func TestHandleFind_Success(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"xl/workbook.xml":          "<workbook/>",
+		"xl/worksheets/sheet1.xml": "<sheet/>",
+		"xl/media/image1.png":      "png",
+	})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session": session.ID,
+		"glob":    "xl/**/*.xml",
+		"type":    "file",
+	}
+
+	result, err := srv.handleFind(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleFind failed: %v", err)
+	}
+
+	var response core.FindResult
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if response.Total != 2 || response.Entries[0].Name != "xl/workbook.xml" || response.Entries[1].Name != "xl/worksheets/sheet1.xml" {
+		t.Errorf("unexpected entries: %+v", response)
+	}
+
+	args["modified_after"] = "yesterday"
+	result, err = srv.handleFind(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleFind failed: %v", err)
+	}
+	if !strings.Contains(getResultText(result), "INVALID_PARAMS") {
+		t.Errorf("expected an invalid time to fail, got %s", getResultText(result))
+	}
+}
+
+func TestHandleGrep_Cancelled(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// Create session
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "Hello World\n"})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session": session.ID,
+		"pattern": "Hello",
+	}
+
+	// The client gave up before the search started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := srv.handleGrep(ctx, newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleGrep failed: %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if response["truncated_reason"] != "cancelled" {
+		t.Errorf("expected truncated_reason \"cancelled\", got %v", response["truncated_reason"])
+	}
+	if response["truncated"] != true {
+		t.Errorf("expected truncated to be true, got %v", response["truncated"])
+	}
+}
+
+func TestHandlePath_Success(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// Create session
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "content"})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "path-test", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session": session.Name,
+	}
+
+	result, err := srv.handlePath(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handlePath failed: %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	path := response["path"].(string)
+	if !strings.Contains(path, "path-test") {
+		t.Errorf("expected path to contain 'path-test', got %q", path)
+	}
+
+	// Verify path exists
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("path does not exist: %v", err)
+	}
+}
+
+func TestHandleStatus_Success(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// Create session
+	zipPath := filepath.Join(tempDir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{"file.txt": "content"})
+
+	cfg := core.DefaultConfig()
+	session, err := core.CreateSession(zipPath, "", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	// Modify workspace
+	dirName := session.DirName()
+	contentsDir, err := core.ContentsDir(dirName)
+	if err != nil {
+		t.Fatalf("failed to get contents dir: %v", err)
+	}
+
+	// Add a file
+	if err := core.WriteFile(contentsDir, "newfile.txt", []byte("new"), true, nil); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	srv, err := NewServer()
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	args := map[string]interface{}{
+		"session": session.ID,
+	}
+
+	result, err := srv.handleStatus(context.Background(), newTestRequest(args))
+	if err != nil {
+		t.Fatalf("handleStatus failed: %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(getResultText(result)), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	added := response["added"].([]interface{})
+	if len(added) != 1 {
+		t.Errorf("expected 1 added file, got %d", len(added))
+	}
+}
+
+func TestHandleSessions_Success(t *testing.T) {
+	setupTestEnvironment(t)
+	tempDir := t.TempDir()
+
+	// Create multiple sessions
+	zipPath1 := filepath.Join(tempDir, "test1.zip")
+	createTestZip(t, zipPath1, map[string]string{"file.txt": "content"})
+
+	zipPath2 := filepath.Join(tempDir, "test2.zip")
+	createTestZip(t, zipPath2, map[string]string{"file.txt": "content"})
+
+	cfg := core.DefaultConfig()
+	_, err := core.CreateSession(zipPath1, "session1", cfg)
+	if err != nil {
+		t.Fatalf("failed to create session1: %v", err)
+	}
+
+	_, err = core.CreateSession(zipPath2, "session2", cfg)
 	if err != nil {
 		t.Fatalf("failed to create session2: %v", err)
 	}
@@ -668,6 +1580,20 @@ func TestHandleSessions_Success(t *testing.T) {
 	if len(sessions) != 2 {
 		t.Errorf("expected 2 sessions, got %d", len(sessions))
 	}
+
+	total, ok := response["disk_usage_bytes"].(float64)
+	if !ok || total <= 0 {
+		t.Errorf("expected positive disk_usage_bytes, got %v", response["disk_usage_bytes"])
+	}
+
+	if _, ok := response["max_total_disk_bytes"].(float64); !ok {
+		t.Errorf("expected max_total_disk_bytes in response, got %v", response["max_total_disk_bytes"])
+	}
+
+	first := sessions[0].(map[string]interface{})
+	if first["disk_usage_bytes"].(float64) <= 0 {
+		t.Errorf("expected per-session disk_usage_bytes, got %v", first["disk_usage_bytes"])
+	}
 }
 
 func TestHandlePrune_DryRun(t *testing.T) {
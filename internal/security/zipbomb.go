@@ -3,22 +3,50 @@ package security
 import (
 	"archive/zip"
 	"fmt"
+	"sort"
 )
 
+// Compression methods zipfs knows how to decompress (APPNOTE.TXT 4.4.5).
+const (
+	MethodStore      uint16 = 0
+	MethodDeflate    uint16 = 8
+	MethodBzip2      uint16 = 12
+	MethodLZMA       uint16 = 14
+	MethodZstdPKWare uint16 = 20
+	MethodZstd       uint16 = 93
+)
+
+// supportedMethods is the set of compression methods accepted by the pre-scan.
+var supportedMethods = map[uint16]bool{
+	MethodStore:      true,
+	MethodDeflate:    true,
+	MethodBzip2:      true,
+	MethodLZMA:       true,
+	MethodZstdPKWare: true,
+	MethodZstd:       true,
+}
+
+// IsSupportedMethod reports whether entries compressed with method can be extracted.
+func IsSupportedMethod(method uint16) bool {
+	return supportedMethods[method]
+}
+
 // BombCheckResult contains the results of a zip bomb pre-scan.
 type BombCheckResult struct {
 	Reason                string
+	UnsupportedMethods    []uint16
 	TotalUncompressedSize uint64
 	FileCount             int
 	MaxCompressionRatio   float64
 	IsSafe                bool
 }
 
-// Limits configures the zip bomb detection thresholds.
+// Limits configures the zip bomb detection thresholds and extraction policy.
 type Limits struct {
 	MaxExtractedSize    uint64  // bytes, default 1GB
 	MaxFileCount        int     // default 100000
 	MaxCompressionRatio float64 // default 100.0
+	AllowSymlinks       bool    // extract symlink entries as symlinks, default false
 }
 
 // DefaultLimits returns the default security limits from ADR-008.
@@ -55,6 +83,7 @@ func CheckZipBombFromReader(r *zip.Reader, limits Limits) *BombCheckResult {
 
 	var totalUncompressedSize uint64
 	var maxCompressionRatio float64
+	unsupported := make(map[uint16]bool)
 
 	for _, f := range r.File {
 		// Skip directories (they don't contribute to size)
@@ -62,6 +91,11 @@ func CheckZipBombFromReader(r *zip.Reader, limits Limits) *BombCheckResult {
 			continue
 		}
 
+		// Record methods we cannot decompress so callers can fail before extracting
+		if !IsSupportedMethod(f.Method) {
+			unsupported[f.Method] = true
+		}
+
 		totalUncompressedSize += f.UncompressedSize64
 
 		// Calculate compression ratio for this file
@@ -78,6 +112,13 @@ func CheckZipBombFromReader(r *zip.Reader, limits Limits) *BombCheckResult {
 	result.FileCount = len(r.File)
 	result.MaxCompressionRatio = maxCompressionRatio
 
+	for method := range unsupported {
+		result.UnsupportedMethods = append(result.UnsupportedMethods, method)
+	}
+	sort.Slice(result.UnsupportedMethods, func(i, j int) bool {
+		return result.UnsupportedMethods[i] < result.UnsupportedMethods[j]
+	})
+
 	// Check total uncompressed size limit
 	if totalUncompressedSize > limits.MaxExtractedSize {
 		result.IsSafe = false
@@ -273,6 +273,67 @@ func TestCheckZipBombFromReader(t *testing.T) {
 	}
 }
 
+func TestCheckZipBombFromReader_UnsupportedMethods(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	// Raw entries bypass compressor lookup, so any method number can be written
+	entries := []struct {
+		name   string
+		method uint16
+	}{
+		{"zstd.bin", MethodZstd},
+		{"ppmd.bin", 98},
+		{"unknown.bin", 99},
+		{"ppmd2.bin", 98},
+	}
+	for _, e := range entries {
+		fw, err := w.CreateRaw(&zip.FileHeader{
+			Name:               e.name,
+			Method:             e.method,
+			CompressedSize64:   4,
+			UncompressedSize64: 4,
+		})
+		if err != nil {
+			t.Fatalf("failed to create raw entry: %v", err)
+		}
+		if _, err := fw.Write([]byte("data")); err != nil {
+			t.Fatalf("failed to write raw entry: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close zip writer: %v", err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to open zip reader: %v", err)
+	}
+
+	result := CheckZipBombFromReader(r, DefaultLimits())
+
+	want := []uint16{98, 99}
+	if len(result.UnsupportedMethods) != len(want) {
+		t.Fatalf("UnsupportedMethods = %v, want %v", result.UnsupportedMethods, want)
+	}
+	for i := range want {
+		if result.UnsupportedMethods[i] != want[i] {
+			t.Errorf("UnsupportedMethods = %v, want %v", result.UnsupportedMethods, want)
+		}
+	}
+}
+
+func TestIsSupportedMethod(t *testing.T) {
+	for _, method := range []uint16{MethodStore, MethodDeflate, MethodBzip2, MethodLZMA, MethodZstdPKWare, MethodZstd} {
+		if !IsSupportedMethod(method) {
+			t.Errorf("IsSupportedMethod(%d) = false, want true", method)
+		}
+	}
+	if IsSupportedMethod(98) {
+		t.Error("IsSupportedMethod(98) = true, want false")
+	}
+}
+
 func TestCheckZipBombFromReader_ExceedsLimits(t *testing.T) {
 	tests := []struct {
 		name      string
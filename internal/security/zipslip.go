@@ -78,3 +78,30 @@ func ValidateAllPaths(base string, paths []string) error {
 	}
 	return nil
 }
+
+// ValidateSymlinkTarget checks that a symlink stored at linkPath (relative to base)
+// pointing at target resolves within the base directory.
+// Absolute targets are always rejected, even if they happen to point inside base.
+func ValidateSymlinkTarget(base, linkPath, target string) error {
+	if target == "" {
+		return fmt.Errorf("symlink %q has an empty target", linkPath)
+	}
+
+	// Reject null bytes (potential for filesystem attacks)
+	if strings.Contains(target, "\x00") {
+		return fmt.Errorf("symlink %q target contains null byte", linkPath)
+	}
+
+	// Reject absolute targets
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "/") {
+		return fmt.Errorf("symlink %q must have a relative target, got %q", linkPath, target)
+	}
+
+	// Targets are resolved relative to the directory containing the link
+	resolved := filepath.Join(filepath.Dir(filepath.Clean(linkPath)), target)
+	if err := ValidatePath(base, resolved); err != nil {
+		return fmt.Errorf("symlink %q target %q escapes base directory: %w", linkPath, target, err)
+	}
+
+	return nil
+}
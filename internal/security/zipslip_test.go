@@ -293,6 +293,36 @@ func TestValidateAllPaths(t *testing.T) {
 	}
 }
 
+func TestValidateSymlinkTarget(t *testing.T) {
+	base := "/tmp/workspace"
+
+	tests := []struct {
+		name     string
+		linkPath string
+		target   string
+		wantErr  bool
+	}{
+		{name: "sibling file", linkPath: "link.txt", target: "target.txt"},
+		{name: "nested target", linkPath: "link", target: "dir/file.txt"},
+		{name: "parent within base", linkPath: "dir/link", target: "../file.txt"},
+		{name: "base itself", linkPath: "dir/link", target: ".."},
+		{name: "escapes via parent", linkPath: "link", target: "../outside", wantErr: true},
+		{name: "escapes from nested dir", linkPath: "a/b/link", target: "../../../etc/passwd", wantErr: true},
+		{name: "absolute target", linkPath: "link", target: "/etc/passwd", wantErr: true},
+		{name: "empty target", linkPath: "link", target: "", wantErr: true},
+		{name: "null byte", linkPath: "link", target: "file\x00.txt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSymlinkTarget(base, tt.linkPath, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSymlinkTarget(%q, %q) error = %v, wantErr %v", tt.linkPath, tt.target, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidatePath_WindowsPaths(t *testing.T) {
 	// Test Windows-specific path patterns
 	tests := []struct {